@@ -0,0 +1,153 @@
+// dnsctl operates on the Cloud DNS zones of clusters outside of the
+// operator, e.g. to back up a cluster zone or hand it over.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/zonefile"
+)
+
+const usage = `Usage: dnsctl <command> [flags]
+
+Commands:
+  export  Export a cluster zone as BIND zone file
+  import  Import the records of a BIND zone file into a cluster zone
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runExport(ctx context.Context, args []string) error {
+	var project, zone, output string
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&project, "project", "", "The GCP project of the zone.")
+	flags.StringVar(&zone, "zone", "", "The name of the Cloud DNS zone, which is the name of the cluster for cluster zones.")
+	flags.StringVar(&output, "output", "-", "The file the zone is written to. Defaults to stdout.")
+	_ = flags.Parse(args)
+
+	if project == "" || zone == "" {
+		return microerror.Mask(fmt.Errorf("--project and --zone must be set"))
+	}
+
+	dnsService, err := clouddns.NewService(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if output == "-" {
+		return zonefile.Export(ctx, dnsService, project, zone, os.Stdout)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = zonefile.Export(ctx, dnsService, project, zone, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return microerror.Mask(err)
+}
+
+func runImport(ctx context.Context, args []string) error {
+	var project, zone, input, onConflict string
+	var dryRun bool
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&project, "project", "", "The GCP project of the zone.")
+	flags.StringVar(&zone, "zone", "", "The name of the Cloud DNS zone, which is the name of the cluster for cluster zones.")
+	flags.StringVar(&input, "file", "-", "The zone file to import. Defaults to stdin.")
+	flags.StringVar(&onConflict, "on-conflict", string(zonefile.ConflictFail), fmt.Sprintf("How records conflicting with existing records are handled. One of %v.", zonefile.ConflictPolicies))
+	flags.BoolVar(&dryRun, "dry-run", false, "Only print the plan without changing the zone.")
+	_ = flags.Parse(args)
+
+	if project == "" || zone == "" {
+		return microerror.Mask(fmt.Errorf("--project and --zone must be set"))
+	}
+	policy := zonefile.ConflictPolicy(onConflict)
+	if !isConflictPolicy(policy) {
+		return microerror.Mask(fmt.Errorf("--on-conflict must be one of %v", zonefile.ConflictPolicies))
+	}
+
+	dnsService, err := clouddns.NewService(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	managedZone, err := dnsService.ManagedZones.Get(project, zone).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	reader := os.Stdin
+	if input != "-" {
+		reader, err = os.Open(input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		defer reader.Close()
+	}
+
+	records, err := zonefile.Parse(reader, managedZone.DnsName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	plan, err := zonefile.NewPlan(ctx, dnsService, project, zone, records, policy)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = plan.Write(os.Stdout)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if dryRun {
+		return nil
+	}
+
+	return zonefile.Apply(ctx, dnsService, plan)
+}
+
+func isConflictPolicy(policy zonefile.ConflictPolicy) bool {
+	for _, valid := range zonefile.ConflictPolicies {
+		if policy == valid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,609 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/shard"
+)
+
+const (
+	FinalizerDNS = "dns-operator-gcp.finalizers.giantswarm.io"
+
+	// ConditionDNSReady is set on the CAPI cluster and reports whether all
+	// DNS records of the cluster are registered.
+	ConditionDNSReady capi.ConditionType = "DNSReady"
+
+	// ConditionDNSDelegationVerified is set on the CAPI cluster and reports
+	// whether the records of the cluster actually resolve through the
+	// delegation in the parent zone.
+	ConditionDNSDelegationVerified capi.ConditionType = "DNSDelegationVerified"
+
+	ReasonRegistrationFailed = "RegistrationFailed"
+	ReasonVerificationFailed = "VerificationFailed"
+
+	requeueAfter = time.Minute * 10
+)
+
+//counterfeiter:generate . ClusterClient
+type ClusterClient interface {
+	Get(context.Context, types.NamespacedName) (*clusterview.Cluster, error)
+	AddFinalizer(context.Context, *clusterview.Cluster, string) error
+	RemoveFinalizer(context.Context, *clusterview.Cluster, string) error
+	SetCondition(context.Context, *capi.Cluster, *capi.Condition) error
+}
+
+//counterfeiter:generate . Registrar
+type Registrar interface {
+	// Name identifies the registrar in the dependencies of other registrars.
+	Name() string
+	// Dependencies are the names of the registrars, which have to register
+	// their records before this registrar and unregister them after it.
+	Dependencies() []string
+	Register(context.Context, *clusterview.Cluster) error
+	Unregister(context.Context, *clusterview.Cluster) error
+}
+
+// ZoneRegistrar is implemented by the registrars managing zones of the cluster,
+// which are kept when the deletion policy of the cluster retains its DNS.
+//
+//counterfeiter:generate . ZoneRegistrar
+type ZoneRegistrar interface {
+	Registrar
+	// Retain marks the zone as retained, so that it is not considered
+	// orphaned once the cluster is gone.
+	Retain(context.Context, *clusterview.Cluster) error
+	// UnregisterDelegation only removes the delegation of the zone from the
+	// parent zone.
+	UnregisterDelegation(context.Context, *clusterview.Cluster) error
+}
+
+// DelegationVerifier checks that the registered records resolve.
+//
+//counterfeiter:generate . DelegationVerifier
+type DelegationVerifier interface {
+	// Verify returns a pendingError, when the records do not resolve yet.
+	Verify(context.Context, *clusterview.Cluster) error
+	// Forget is called once the cluster is deleted.
+	Forget(*clusterview.Cluster)
+}
+
+// pendingError is returned by registrars which could only partially register
+// their records. Other registrars keep running and the cluster is requeued.
+type pendingError interface {
+	error
+	ConditionReason() string
+	RequeueAfter() time.Duration
+}
+
+// conflictError is returned by registrars which refuse to overwrite existing
+// zones or records. It has to be resolved by hand, so it is reported with its
+// reason and only retried with the regular requeue interval.
+type conflictError interface {
+	error
+	ConditionReason() string
+}
+
+type ClusterReconciler struct {
+	client     ClusterClient
+	registrars []Registrar
+	// verifier is optional, the delegation is not verified when it is nil.
+	verifier DelegationVerifier
+
+	// cleanupDisabledRegistrars is the default for removing the records of
+	// registrars disabled on a cluster, when the cluster does not set the
+	// annotation.CleanupDisabledRegistrars annotation.
+	cleanupDisabledRegistrars bool
+
+	// auditSink is optional. When it is set, the registrars record their
+	// mutations in it.
+	auditSink audit.Sink
+}
+
+func NewClusterReconciler(client ClusterClient, registrars []Registrar, verifier DelegationVerifier, cleanupDisabledRegistrars bool, auditSink audit.Sink) *ClusterReconciler {
+	return &ClusterReconciler{
+		client:                    client,
+		registrars:                registrars,
+		verifier:                  verifier,
+		cleanupDisabledRegistrars: cleanupDisabledRegistrars,
+		auditSink:                 auditSink,
+	}
+}
+
+// SetupOptions configures the controller of the reconciler.
+type SetupOptions struct {
+	MaxConcurrentReconciles int
+	// Events is optional. CAPI clusters received from it are reconciled,
+	// e.g. after their api endpoint failed over.
+	Events <-chan event.GenericEvent
+	// InfrastructureKinds are the kinds of infrastructure clusters served by
+	// the client of the reconciler. GCPClusters and GCPManagedClusters are
+	// watched, when they are served. Clusters of other infrastructure
+	// providers only follow changes of the CAPI cluster and the periodic
+	// requeue.
+	InfrastructureKinds []string
+	// Shard filters the events of CAPI clusters down to the clusters served
+	// and the events of other objects down to the namespaces of the shard.
+	// Objects in these namespaces, whose cluster doesn't match the label
+	// selector of the shard, are mapped to no cluster, as the cache doesn't
+	// contain it. It is nil when all clusters are served.
+	Shard *shard.Shard
+}
+
+// SetupWithManager sets up the controller with the Manager. Besides CAPI
+// clusters, it watches bastion GCPMachines and the GCP infrastructure
+// clusters, so that DNS records follow changes without waiting for the
+// periodic requeue. For GKE clusters, it also watches their
+// GCPManagedControlPlanes, whose endpoint the api record points to. Watched
+// objects are mapped to their CAPI clusters through indexes of the cluster
+// references.
+func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options SetupOptions) error {
+	logger := ctrl.Log.WithName("cluster-controller")
+
+	ctx := context.Background()
+	err := mgr.GetFieldIndexer().IndexField(ctx, &capi.Cluster{}, indexInfrastructureRef, indexRef(getInfrastructureRef))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = mgr.GetFieldIndexer().IndexField(ctx, &capi.Cluster{}, indexControlPlaneRef, indexRef(getControlPlaneRef))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&capi.Cluster{}, builder.WithPredicates(predicates.ResourceNotPaused(logger), options.Shard.Predicate())).
+		WithOptions(controller.Options{MaxConcurrentReconciles: options.MaxConcurrentReconciles}).
+		Watches(
+			&source.Kind{Type: &capg.GCPMachine{}},
+			handler.EnqueueRequestsFromMapFunc(bastionToCluster(mgr.GetClient())),
+			builder.WithPredicates(bastionPredicate(), options.Shard.NamespacePredicate()),
+		)
+
+	for _, kind := range options.InfrastructureKinds {
+		switch kind {
+		case k8sclient.KindGCPCluster:
+			b = b.Watches(
+				&source.Kind{Type: &capg.GCPCluster{}},
+				handler.EnqueueRequestsFromMapFunc(refToCluster(mgr.GetClient(), indexInfrastructureRef, k8sclient.KindGCPCluster)),
+				builder.WithPredicates(options.Shard.NamespacePredicate()),
+			)
+		case k8sclient.KindGCPManagedCluster:
+			b = b.
+				Watches(
+					&source.Kind{Type: k8sclient.NewGCPManagedClusterObject()},
+					handler.EnqueueRequestsFromMapFunc(refToCluster(mgr.GetClient(), indexInfrastructureRef, k8sclient.KindGCPManagedCluster)),
+					builder.WithPredicates(options.Shard.NamespacePredicate()),
+				).
+				Watches(
+					&source.Kind{Type: k8sclient.NewGCPManagedControlPlaneObject()},
+					handler.EnqueueRequestsFromMapFunc(refToCluster(mgr.GetClient(), indexControlPlaneRef, k8sclient.GCPManagedControlPlaneGVK.Kind)),
+					builder.WithPredicates(options.Shard.NamespacePredicate()),
+				)
+		}
+	}
+
+	if options.Events != nil {
+		b = b.Watches(&source.Channel{Source: options.Events}, &handler.EnqueueRequestForObject{})
+	}
+
+	return b.Complete(r)
+}
+
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if r.auditSink != nil {
+		// The reconcile ID of the audit trail is logged, so that audit
+		// entries can be correlated with the log of the reconciliation.
+		auditID := string(uuid.NewUUID())
+		logger = logger.WithValues("auditID", auditID)
+		ctx = log.IntoContext(ctx, logger)
+		ctx = audit.IntoContext(ctx, audit.NewRecorder(r.auditSink, req.NamespacedName.String(), auditID))
+	}
+
+	logger.Info("Reconciling")
+	defer logger.Info("Done reconciling")
+
+	cluster, err := r.client.Get(ctx, req.NamespacedName)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			logger.Info("Cluster no longer exists")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+
+	if cluster == nil {
+		logger.Info("Skipping. Cluster does not have a supported infrastructure cluster yet or belongs to another shard")
+		return ctrl.Result{}, nil
+	}
+
+	if annotations.IsPaused(cluster.Cluster, cluster.Infrastructure) {
+		logger.Info("Infrastructure or core cluster is marked as paused. Won't reconcile")
+		return ctrl.Result{}, nil
+	}
+
+	if cluster.IsDeleted() {
+		return r.reconcileDelete(ctx, cluster)
+	}
+
+	return r.reconcileNormal(ctx, cluster)
+}
+
+func (r *ClusterReconciler) reconcileNormal(ctx context.Context, cluster *clusterview.Cluster) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	err := r.client.AddFinalizer(ctx, cluster, FinalizerDNS)
+	if err != nil {
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+
+	enabled, disabled := r.selectRegistrars(cluster)
+
+	errs, err := r.cleanupDisabled(ctx, cluster, disabled)
+	if err != nil {
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+
+	results, err := runRegistrars(enabled, false, func(registrar Registrar) error {
+		return registrar.Register(ctx, cluster)
+	})
+	if err != nil {
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+
+	var pendingErrors []pendingError
+	for _, result := range results {
+		if result.skipped {
+			logger.Info("Skipping registrar, as a registrar it depends on failed", "registrar", result.name)
+			continue
+		}
+
+		var pendingErr pendingError
+		if errors.As(result.err, &pendingErr) {
+			logger.Info("Registration is pending", "registrar", result.name, "reason", pendingErr.ConditionReason(), "message", pendingErr.Error())
+			pendingErrors = append(pendingErrors, pendingErr)
+			continue
+		}
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return r.handleErrors(ctx, cluster.Cluster, errs)
+	}
+
+	result := ctrl.Result{Requeue: true, RequeueAfter: requeueAfter}
+	if len(pendingErrors) > 0 {
+		var messages []string
+		for _, pendingErr := range pendingErrors {
+			messages = append(messages, pendingErr.Error())
+			if pendingErr.RequeueAfter() < result.RequeueAfter {
+				result.RequeueAfter = pendingErr.RequeueAfter()
+			}
+		}
+
+		reason := pendingErrors[0].ConditionReason()
+		condition := conditions.FalseCondition(ConditionDNSReady, reason, capi.ConditionSeverityInfo, "%s", strings.Join(messages, "; "))
+		r.setCondition(ctx, cluster.Cluster, condition)
+	} else {
+		r.setCondition(ctx, cluster.Cluster, conditions.TrueCondition(ConditionDNSReady))
+	}
+
+	// The delegation is only verified, when the operator manages the zone
+	// of the cluster.
+	if r.verifier == nil || !hasRegistrar(enabled, registrar.NameZone) {
+		return result, nil
+	}
+
+	verifyRequeueAfter, err := r.verifyDelegation(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+	if verifyRequeueAfter > 0 && verifyRequeueAfter < result.RequeueAfter {
+		result.RequeueAfter = verifyRequeueAfter
+	}
+
+	return result, nil
+}
+
+// verifyDelegation reports the result of the delegation verification in the
+// DNSDelegationVerified condition. It returns when the cluster needs to be
+// verified again, or zero if it is verified.
+func (r *ClusterReconciler) verifyDelegation(ctx context.Context, cluster *clusterview.Cluster) (time.Duration, error) {
+	err := r.verifier.Verify(ctx, cluster)
+
+	var pendingErr pendingError
+	if errors.As(err, &pendingErr) {
+		condition := conditions.FalseCondition(ConditionDNSDelegationVerified, pendingErr.ConditionReason(), capi.ConditionSeverityWarning, "%s", pendingErr.Error())
+		r.setCondition(ctx, cluster.Cluster, condition)
+		return pendingErr.RequeueAfter(), nil
+	}
+	if err != nil {
+		condition := conditions.FalseCondition(ConditionDNSDelegationVerified, ReasonVerificationFailed, capi.ConditionSeverityError, "%s", err.Error())
+		r.setCondition(ctx, cluster.Cluster, condition)
+		return 0, microerror.Mask(err)
+	}
+
+	r.setCondition(ctx, cluster.Cluster, conditions.TrueCondition(ConditionDNSDelegationVerified))
+	return 0, nil
+}
+
+// reconcileDelete removes the DNS of the cluster according to its deletion
+// policy, before removing the finalizer.
+func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *clusterview.Cluster) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var errs []error
+	var err error
+	policy := annotation.GetDeletionPolicy(cluster.Annotations)
+	switch policy {
+	case annotation.DeletionPolicyDelete:
+		errs, err = r.unregister(ctx, cluster)
+		if err != nil {
+			return ctrl.Result{}, microerror.Mask(err)
+		}
+	case annotation.DeletionPolicyRetain, annotation.DeletionPolicyOrphanRecords:
+		logger.Info("Retaining DNS of the cluster", "deletionPolicy", policy)
+		errs = r.retain(ctx, cluster, policy == annotation.DeletionPolicyOrphanRecords)
+	default:
+		errs = []error{fmt.Errorf("unknown deletion policy %q", policy)}
+	}
+
+	if len(errs) > 0 {
+		return r.handleErrors(ctx, cluster.Cluster, errs)
+	}
+
+	err = r.client.RemoveFinalizer(ctx, cluster, FinalizerDNS)
+	if err != nil {
+		return ctrl.Result{}, microerror.Mask(err)
+	}
+
+	if r.verifier != nil {
+		r.verifier.Forget(cluster)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// unregister unregisters the records of all enabled registrars. The records
+// of disabled registrars are only removed, when they are cleaned up.
+func (r *ClusterReconciler) unregister(ctx context.Context, cluster *clusterview.Cluster) ([]error, error) {
+	logger := log.FromContext(ctx)
+
+	registrars, disabled := r.selectRegistrars(cluster)
+	if r.shouldCleanup(cluster) {
+		registrars = r.registrars
+	} else if len(disabled) > 0 {
+		logger.Info("Keeping records of disabled registrars", "registrars", registrarNames(disabled))
+	}
+
+	results, err := runRegistrars(registrars, true, func(registrar Registrar) error {
+		return registrar.Unregister(ctx, cluster)
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var errs []error
+	for _, result := range results {
+		if result.skipped {
+			logger.Info("Skipping registrar, as a registrar depending on it failed", "registrar", result.name)
+			continue
+		}
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+	}
+
+	return errs, nil
+}
+
+// retain keeps the records and the zones of the enabled registrars. The
+// zones are marked as retained and, when orphaning the records, their
+// delegation is removed.
+func (r *ClusterReconciler) retain(ctx context.Context, cluster *clusterview.Cluster, orphanRecords bool) []error {
+	enabled, _ := r.selectRegistrars(cluster)
+
+	var errs []error
+	for _, registrar := range enabled {
+		zoneRegistrar, ok := registrar.(ZoneRegistrar)
+		if !ok {
+			continue
+		}
+
+		if orphanRecords {
+			err := zoneRegistrar.UnregisterDelegation(ctx, cluster)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		err := zoneRegistrar.Retain(ctx, cluster)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// selectRegistrars splits the registrars into the ones enabled and the ones
+// disabled on the cluster with the annotation.DisabledRegistrars annotation.
+func (r *ClusterReconciler) selectRegistrars(cluster *clusterview.Cluster) (enabled, disabled []Registrar) {
+	disabledNames := map[string]bool{}
+	for _, name := range annotation.GetList(cluster.Annotations, annotation.DisabledRegistrars) {
+		disabledNames[name] = true
+	}
+
+	for _, registrar := range r.registrars {
+		if disabledNames[registrar.Name()] {
+			disabled = append(disabled, registrar)
+		} else {
+			enabled = append(enabled, registrar)
+		}
+	}
+
+	return enabled, disabled
+}
+
+func (r *ClusterReconciler) shouldCleanup(cluster *clusterview.Cluster) bool {
+	return annotation.GetBool(cluster.Annotations, annotation.CleanupDisabledRegistrars, r.cleanupDisabledRegistrars)
+}
+
+// cleanupDisabled unregisters the records of the disabled registrars, if the
+// cluster wants them cleaned up. Unregistering is idempotent, so it is done
+// on every reconciliation, which also removes records created by a registrar
+// enabled again in the meantime.
+func (r *ClusterReconciler) cleanupDisabled(ctx context.Context, cluster *clusterview.Cluster, disabled []Registrar) ([]error, error) {
+	logger := log.FromContext(ctx)
+
+	if len(disabled) == 0 {
+		return nil, nil
+	}
+
+	if !r.shouldCleanup(cluster) {
+		logger.Info("Skipping disabled registrars", "registrars", registrarNames(disabled))
+		return nil, nil
+	}
+
+	logger.Info("Cleaning up records of disabled registrars", "registrars", registrarNames(disabled))
+	results, err := runRegistrars(disabled, true, func(registrar Registrar) error {
+		return registrar.Unregister(ctx, cluster)
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+	}
+
+	return errs, nil
+}
+
+// handleErrors reports the errors of the registrars in the DNSReady
+// condition. Pending errors and Cloud DNS errors are requeued according to
+// their classification instead of being returned, so that rate limits are
+// not hit even harder by the exponential backoff of the controller and
+// permanent errors are not retried in a hot loop. Any other error is
+// returned.
+func (r *ClusterReconciler) handleErrors(ctx context.Context, cluster *capi.Cluster, errs []error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	aggregate := utilerrors.NewAggregate(errs)
+
+	result := ctrl.Result{Requeue: true, RequeueAfter: requeueAfter}
+	reason := ""
+	severity := capi.ConditionSeverityWarning
+	classified := true
+	for _, err := range errs {
+		// Registrars which can't make progress until something else
+		// changes, e.g. when foreign records block the deletion of a zone,
+		// are retried later like transient errors.
+		var pendingErr pendingError
+		if errors.As(err, &pendingErr) {
+			logger.Info("Registrar is blocked", "reason", pendingErr.ConditionReason(), "requeueAfter", pendingErr.RequeueAfter(), "error", err.Error())
+			if reason == "" {
+				reason = pendingErr.ConditionReason()
+			}
+			if pendingErr.RequeueAfter() < result.RequeueAfter {
+				result.RequeueAfter = pendingErr.RequeueAfter()
+			}
+			continue
+		}
+
+		var conflictErr conflictError
+		if errors.As(err, &conflictErr) {
+			logger.Error(err, "Registrar refused to overwrite existing resource", "reason", conflictErr.ConditionReason())
+			if reason == "" {
+				reason = conflictErr.ConditionReason()
+			}
+			severity = capi.ConditionSeverityError
+			continue
+		}
+
+		dnsErr, ok := classifyError(err)
+		if !ok {
+			classified = false
+			continue
+		}
+
+		if reason == "" {
+			reason = dnsErr.reason
+		}
+
+		if dnsErr.transient {
+			logger.Info("Cloud DNS request failed temporarily", "reason", dnsErr.reason, "requeueAfter", dnsErr.requeueAfter, "error", err.Error())
+			if dnsErr.requeueAfter < result.RequeueAfter {
+				result.RequeueAfter = dnsErr.requeueAfter
+			}
+		} else {
+			logger.Error(err, "Cloud DNS request failed permanently", "reason", dnsErr.reason)
+			severity = capi.ConditionSeverityError
+		}
+	}
+
+	if !classified {
+		condition := conditions.FalseCondition(ConditionDNSReady, ReasonRegistrationFailed, capi.ConditionSeverityError, "%s", aggregate.Error())
+		r.setCondition(ctx, cluster, condition)
+		return ctrl.Result{}, microerror.Mask(aggregate)
+	}
+
+	condition := conditions.FalseCondition(ConditionDNSReady, reason, severity, "%s", aggregate.Error())
+	r.setCondition(ctx, cluster, condition)
+	return result, nil
+}
+
+// setCondition reports the condition on the CAPI cluster. Failing to do so
+// must not fail the reconciliation of the records, so the error is only
+// logged.
+func (r *ClusterReconciler) setCondition(ctx context.Context, cluster *capi.Cluster, condition *capi.Condition) {
+	logger := log.FromContext(ctx)
+
+	err := r.client.SetCondition(ctx, cluster, condition)
+	if err != nil {
+		logger.Error(err, "Failed to set condition", "condition", condition.Type)
+	}
+}
+
+func hasRegistrar(registrars []Registrar, name string) bool {
+	for _, registrar := range registrars {
+		if registrar.Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
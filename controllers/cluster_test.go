@@ -0,0 +1,794 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/api/googleapi"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/controllers/controllersfakes"
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+var _ = Describe("ClusterReconciler", func() {
+	var (
+		ctx context.Context
+
+		reconciler *controllers.ClusterReconciler
+		client     *controllersfakes.FakeClusterClient
+
+		firstRegistrar  *controllersfakes.FakeRegistrar
+		secondRegistrar *controllersfakes.FakeRegistrar
+		registrars      []controllers.Registrar
+
+		verifier                  controllers.DelegationVerifier
+		cleanupDisabledRegistrars bool
+		auditSink                 audit.Sink
+
+		cluster      *capi.Cluster
+		view         *clusterview.Cluster
+		result       ctrl.Result
+		reconcileErr error
+	)
+
+	BeforeEach(func() {
+		logger := zap.New(zap.WriteTo(GinkgoWriter))
+		ctx = log.IntoContext(context.Background(), logger)
+
+		client = new(controllersfakes.FakeClusterClient)
+		firstRegistrar = new(controllersfakes.FakeRegistrar)
+		secondRegistrar = new(controllersfakes.FakeRegistrar)
+
+		firstRegistrar.NameReturns("first")
+		secondRegistrar.NameReturns("second")
+		secondRegistrar.DependenciesReturns([]string{"first"})
+		registrars = []controllers.Registrar{firstRegistrar, secondRegistrar}
+
+		verifier = nil
+		cleanupDisabledRegistrars = false
+		auditSink = nil
+
+		cluster = &capi.Cluster{}
+		view = &clusterview.Cluster{
+			Cluster:        cluster,
+			Infrastructure: &unstructured.Unstructured{Object: map[string]interface{}{}},
+		}
+		client.GetReturns(view, nil)
+	})
+
+	JustBeforeEach(func() {
+		reconciler = controllers.NewClusterReconciler(
+			client,
+			registrars,
+			verifier,
+			cleanupDisabledRegistrars,
+			auditSink,
+		)
+
+		request := ctrl.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      "foo",
+				Namespace: "bar",
+			},
+		}
+		result, reconcileErr = reconciler.Reconcile(ctx, request)
+	})
+
+	It("gets the cluster", func() {
+		Expect(client.GetCallCount()).To(Equal(1))
+	})
+
+	It("adds a finalizer to the infrastructure cluster", func() {
+		Expect(client.AddFinalizerCallCount()).To(Equal(1))
+
+		_, actualCluster, finalizer := client.AddFinalizerArgsForCall(0)
+		Expect(actualCluster).To(Equal(view))
+		Expect(finalizer).To(Equal(controllers.FinalizerDNS))
+	})
+
+	It("uses the registrars to register the records", func() {
+		Expect(firstRegistrar.RegisterCallCount()).To(Equal(1))
+		_, actualCluster := firstRegistrar.RegisterArgsForCall(0)
+		Expect(actualCluster).To(Equal(view))
+
+		Expect(secondRegistrar.RegisterCallCount()).To(Equal(1))
+		_, actualCluster = secondRegistrar.RegisterArgsForCall(0)
+		Expect(actualCluster).To(Equal(view))
+	})
+
+	It("does not audit the registrars", func() {
+		registrarCtx, _ := firstRegistrar.RegisterArgsForCall(0)
+		Expect(audit.FromContext(registrarCtx)).To(BeNil())
+	})
+
+	When("an audit sink is set", func() {
+		var buffer *bytes.Buffer
+
+		BeforeEach(func() {
+			buffer = &bytes.Buffer{}
+			auditSink = audit.NewJSONLines(buffer, "")
+			firstRegistrar.RegisterStub = func(ctx context.Context, _ *clusterview.Cluster) error {
+				audit.FromContext(ctx).Record(ctx, audit.Entry{Action: audit.ActionCreate, Name: "api.foo.example.com."})
+				return nil
+			}
+		})
+
+		It("records the mutations of the registrars with the cluster", func() {
+			var entry audit.Entry
+			Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+			Expect(entry.Name).To(Equal("api.foo.example.com."))
+			Expect(entry.Cluster).To(Equal("bar/foo"))
+			Expect(entry.ReconcileID).NotTo(BeEmpty())
+		})
+	})
+
+	It("marks the dns as ready", func() {
+		Expect(client.SetConditionCallCount()).To(Equal(1))
+		_, actualCluster, condition := client.SetConditionArgsForCall(0)
+		Expect(actualCluster).To(Equal(cluster))
+		Expect(condition.Type).To(Equal(controllers.ConditionDNSReady))
+		Expect(condition.Status).To(BeEquivalentTo("True"))
+	})
+
+	It("requeues the cluster", func() {
+		Expect(reconcileErr).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Minute * 10))
+	})
+
+	When("the infrastructure cluster is marked for deletion", func() {
+		BeforeEach(func() {
+			now := v1.Now()
+			view.Infrastructure.SetDeletionTimestamp(&now)
+		})
+
+		It("removes the finalizer", func() {
+			Expect(client.RemoveFinalizerCallCount()).To(Equal(1))
+			_, actualCluster, finalizer := client.RemoveFinalizerArgsForCall(0)
+			Expect(actualCluster).To(Equal(view))
+			Expect(finalizer).To(Equal(controllers.FinalizerDNS))
+		})
+
+		It("uses the registrars to unregister the records", func() {
+			Expect(firstRegistrar.UnregisterCallCount()).To(Equal(1))
+			_, actualCluster := firstRegistrar.UnregisterArgsForCall(0)
+			Expect(actualCluster).To(Equal(view))
+
+			Expect(secondRegistrar.UnregisterCallCount()).To(Equal(1))
+			_, actualCluster = secondRegistrar.UnregisterArgsForCall(0)
+			Expect(actualCluster).To(Equal(view))
+		})
+
+		When("a registrar fails to unregister ", func() {
+			BeforeEach(func() {
+				secondRegistrar.UnregisterReturns(errors.New("boom"))
+			})
+
+			It("does not reconcile", func() {
+				Expect(reconcileErr).To(HaveOccurred())
+				Expect(firstRegistrar.UnregisterCallCount()).To(Equal(0))
+			})
+
+			It("does not remove the finalizer", func() {
+				Expect(client.RemoveFinalizerCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the dns of the cluster is retained", func() {
+			var zoneRegistrar *controllersfakes.FakeZoneRegistrar
+
+			BeforeEach(func() {
+				zoneRegistrar = new(controllersfakes.FakeZoneRegistrar)
+				zoneRegistrar.NameReturns("zone")
+				registrars = append(registrars, zoneRegistrar)
+
+				view.Annotations = map[string]string{
+					annotation.DeletionPolicy: annotation.DeletionPolicyRetain,
+				}
+			})
+
+			It("does not unregister any records", func() {
+				Expect(firstRegistrar.UnregisterCallCount()).To(Equal(0))
+				Expect(secondRegistrar.UnregisterCallCount()).To(Equal(0))
+				Expect(zoneRegistrar.UnregisterCallCount()).To(Equal(0))
+				Expect(zoneRegistrar.UnregisterDelegationCallCount()).To(Equal(0))
+			})
+
+			It("retains the zone", func() {
+				Expect(zoneRegistrar.RetainCallCount()).To(Equal(1))
+				_, actualCluster := zoneRegistrar.RetainArgsForCall(0)
+				Expect(actualCluster).To(Equal(view))
+			})
+
+			It("removes the finalizer", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(client.RemoveFinalizerCallCount()).To(Equal(1))
+			})
+
+			When("retaining the zone fails", func() {
+				BeforeEach(func() {
+					zoneRegistrar.RetainReturns(errors.New("boom"))
+				})
+
+				It("does not remove the finalizer", func() {
+					Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+					Expect(client.RemoveFinalizerCallCount()).To(Equal(0))
+				})
+			})
+
+			When("only the records are orphaned", func() {
+				BeforeEach(func() {
+					view.Annotations[annotation.DeletionPolicy] = annotation.DeletionPolicyOrphanRecords
+				})
+
+				It("unregisters the delegation and retains the zone", func() {
+					Expect(zoneRegistrar.UnregisterDelegationCallCount()).To(Equal(1))
+					Expect(zoneRegistrar.RetainCallCount()).To(Equal(1))
+					Expect(zoneRegistrar.UnregisterCallCount()).To(Equal(0))
+					Expect(client.RemoveFinalizerCallCount()).To(Equal(1))
+				})
+			})
+
+			When("the deletion policy is unknown", func() {
+				BeforeEach(func() {
+					view.Annotations[annotation.DeletionPolicy] = "Keep"
+				})
+
+				It("does not remove the finalizer", func() {
+					Expect(reconcileErr).To(MatchError(ContainSubstring("unknown deletion policy")))
+					Expect(zoneRegistrar.RetainCallCount()).To(Equal(0))
+					Expect(zoneRegistrar.UnregisterCallCount()).To(Equal(0))
+					Expect(client.RemoveFinalizerCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		When("foreign records block the deletion of the zone", func() {
+			BeforeEach(func() {
+				foreignRecordsErr := registrar.NewForeignRecordsError("foo", []string{"_acme-challenge.foo.example.com. TXT"})
+				secondRegistrar.UnregisterReturns(foreignRecordsErr)
+			})
+
+			It("retries the deletion later", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Minute * 5))
+				Expect(client.RemoveFinalizerCallCount()).To(Equal(0))
+			})
+
+			It("reports the foreign records", func() {
+				Expect(client.SetConditionCallCount()).To(Equal(1))
+				_, _, condition := client.SetConditionArgsForCall(0)
+				Expect(condition.Status).To(BeEquivalentTo("False"))
+				Expect(condition.Reason).To(Equal(registrar.ReasonForeignRecords))
+				Expect(condition.Message).To(ContainSubstring("_acme-challenge.foo.example.com. TXT"))
+			})
+		})
+
+		When("a registrar is rate limited while unregistering", func() {
+			BeforeEach(func() {
+				secondRegistrar.UnregisterReturns(&googleapi.Error{Code: http.StatusTooManyRequests})
+			})
+
+			It("requeues the cluster", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Second * 30))
+			})
+
+			It("does not remove the finalizer", func() {
+				Expect(client.RemoveFinalizerCallCount()).To(Equal(0))
+			})
+		})
+
+		When("removing the finalizer fails", func() {
+			BeforeEach(func() {
+				client.RemoveFinalizerReturns(errors.New("boom"))
+			})
+
+			It("does not reconcile", func() {
+				Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+			})
+		})
+	})
+
+	When("getting the cluster fails", func() {
+		BeforeEach(func() {
+			client.GetReturns(nil, errors.New("boom"))
+		})
+
+		It("returns an error", func() {
+			Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+		})
+	})
+
+	When("the cluster does not exist", func() {
+		BeforeEach(func() {
+			client.GetReturns(nil, k8serrors.NewNotFound(schema.GroupResource{}, "foo"))
+		})
+
+		It("does not requeue the event", func() {
+			Expect(result.Requeue).To(BeFalse())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(reconcileErr).NotTo(HaveOccurred())
+		})
+	})
+
+	When("the cluster does not have an infrastructure cluster yet", func() {
+		BeforeEach(func() {
+			client.GetReturns(nil, nil)
+		})
+
+		It("does not requeue the event", func() {
+			Expect(result.Requeue).To(BeFalse())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(reconcileErr).NotTo(HaveOccurred())
+		})
+	})
+
+	When("the cluster is paused", func() {
+		BeforeEach(func() {
+			cluster.Spec.Paused = true
+		})
+
+		It("does not reconcile", func() {
+			Expect(result.Requeue).To(BeFalse())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(reconcileErr).NotTo(HaveOccurred())
+		})
+	})
+
+	When("the infrastructure cluster is paused", func() {
+		BeforeEach(func() {
+			view.Infrastructure.SetAnnotations(map[string]string{
+				capi.PausedAnnotation: "true",
+			})
+		})
+
+		It("does not reconcile", func() {
+			Expect(result.Requeue).To(BeFalse())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(reconcileErr).NotTo(HaveOccurred())
+		})
+	})
+
+	When("adding the finalizer fails", func() {
+		BeforeEach(func() {
+			client.AddFinalizerReturns(errors.New("boom"))
+		})
+
+		It("does not reconcile", func() {
+			Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+		})
+	})
+
+	When("a registrar fails", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(errors.New("boom"))
+		})
+
+		It("does not reconcile", func() {
+			Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(0))
+		})
+
+		It("marks the dns as not ready", func() {
+			Expect(client.SetConditionCallCount()).To(Equal(1))
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Type).To(Equal(controllers.ConditionDNSReady))
+			Expect(condition.Status).To(BeEquivalentTo("False"))
+			Expect(condition.Reason).To(Equal(controllers.ReasonRegistrationFailed))
+			Expect(condition.Message).To(ContainSubstring("boom"))
+		})
+	})
+
+	When("the registrars do not depend on each other", func() {
+		BeforeEach(func() {
+			secondRegistrar.DependenciesReturns(nil)
+		})
+
+		It("runs the registrars concurrently", func() {
+			// Each registrar waits for the other one to start, which
+			// only finishes in time when both run at the same time
+			firstStarted := make(chan struct{})
+			secondStarted := make(chan struct{})
+			firstRegistrar.RegisterStub = func(context.Context, *clusterview.Cluster) error {
+				close(firstStarted)
+				select {
+				case <-secondStarted:
+					return nil
+				case <-time.After(time.Second):
+					return errors.New("registrars did not run concurrently")
+				}
+			}
+			secondRegistrar.RegisterStub = func(context.Context, *clusterview.Cluster) error {
+				close(secondStarted)
+				select {
+				case <-firstStarted:
+					return nil
+				case <-time.After(time.Second):
+					return errors.New("registrars did not run concurrently")
+				}
+			}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("both registrars fail", func() {
+			BeforeEach(func() {
+				firstRegistrar.RegisterReturns(errors.New("boom"))
+				secondRegistrar.RegisterReturns(errors.New("bang"))
+			})
+
+			It("returns both errors", func() {
+				Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+				Expect(reconcileErr).To(MatchError(ContainSubstring("bang")))
+			})
+
+			It("reports both errors", func() {
+				_, _, condition := client.SetConditionArgsForCall(0)
+				Expect(condition.Message).To(ContainSubstring("boom"))
+				Expect(condition.Message).To(ContainSubstring("bang"))
+			})
+		})
+	})
+
+	When("the registrars depend on each other", func() {
+		BeforeEach(func() {
+			firstRegistrar.DependenciesReturns([]string{"second"})
+		})
+
+		It("returns an error", func() {
+			Expect(reconcileErr).To(HaveOccurred())
+			Expect(firstRegistrar.RegisterCallCount()).To(Equal(0))
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(0))
+		})
+	})
+
+	When("a registrar depends on a registrar which is not configured", func() {
+		BeforeEach(func() {
+			firstRegistrar.DependenciesReturns([]string{"other"})
+		})
+
+		It("ignores the dependency", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(firstRegistrar.RegisterCallCount()).To(Equal(1))
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(1))
+		})
+	})
+
+	When("the delegation is verified", func() {
+		var fakeVerifier *controllersfakes.FakeDelegationVerifier
+
+		BeforeEach(func() {
+			fakeVerifier = new(controllersfakes.FakeDelegationVerifier)
+			verifier = fakeVerifier
+
+			firstRegistrar.NameReturns(registrar.NameZone)
+			secondRegistrar.DependenciesReturns([]string{registrar.NameZone})
+		})
+
+		It("verifies the delegation of the cluster", func() {
+			Expect(fakeVerifier.VerifyCallCount()).To(Equal(1))
+			_, actualCluster := fakeVerifier.VerifyArgsForCall(0)
+			Expect(actualCluster).To(Equal(view))
+		})
+
+		It("marks the delegation as verified", func() {
+			Expect(client.SetConditionCallCount()).To(Equal(2))
+			_, _, condition := client.SetConditionArgsForCall(1)
+			Expect(condition.Type).To(Equal(controllers.ConditionDNSDelegationVerified))
+			Expect(condition.Status).To(BeEquivalentTo("True"))
+			Expect(result.RequeueAfter).To(Equal(time.Minute * 10))
+		})
+
+		When("the delegation does not resolve yet", func() {
+			BeforeEach(func() {
+				fakeVerifier.VerifyReturns(registrar.NewPendingError("DelegationNotVerified", "not delegated", time.Second*30))
+			})
+
+			It("requeues the cluster until it is verified", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(time.Second * 30))
+			})
+
+			It("reports the failed verification", func() {
+				_, _, condition := client.SetConditionArgsForCall(1)
+				Expect(condition.Type).To(Equal(controllers.ConditionDNSDelegationVerified))
+				Expect(condition.Status).To(BeEquivalentTo("False"))
+				Expect(condition.Reason).To(Equal("DelegationNotVerified"))
+				Expect(condition.Message).To(Equal("not delegated"))
+			})
+
+			It("keeps the dns ready", func() {
+				_, _, condition := client.SetConditionArgsForCall(0)
+				Expect(condition.Type).To(Equal(controllers.ConditionDNSReady))
+				Expect(condition.Status).To(BeEquivalentTo("True"))
+			})
+		})
+
+		When("the verification fails", func() {
+			BeforeEach(func() {
+				fakeVerifier.VerifyReturns(errors.New("boom"))
+			})
+
+			It("returns an error", func() {
+				Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+			})
+		})
+
+		When("a registrar fails", func() {
+			BeforeEach(func() {
+				firstRegistrar.RegisterReturns(errors.New("boom"))
+			})
+
+			It("does not verify the delegation", func() {
+				Expect(fakeVerifier.VerifyCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the zone registrar is disabled on the cluster", func() {
+			BeforeEach(func() {
+				view.Annotations = map[string]string{
+					annotation.DisabledRegistrars: registrar.NameZone,
+				}
+			})
+
+			It("does not verify the delegation", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(fakeVerifier.VerifyCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the infrastructure cluster is deleted", func() {
+			BeforeEach(func() {
+				now := v1.Now()
+				view.Infrastructure.SetDeletionTimestamp(&now)
+			})
+
+			It("forgets the cluster", func() {
+				Expect(fakeVerifier.ForgetCallCount()).To(Equal(1))
+				Expect(fakeVerifier.VerifyCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	When("a registrar is disabled on the cluster", func() {
+		BeforeEach(func() {
+			view.Annotations = map[string]string{
+				annotation.DisabledRegistrars: "second, other",
+			}
+		})
+
+		It("only runs the enabled registrars", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(firstRegistrar.RegisterCallCount()).To(Equal(1))
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(0))
+		})
+
+		It("keeps the records of the disabled registrar", func() {
+			Expect(secondRegistrar.UnregisterCallCount()).To(Equal(0))
+		})
+
+		When("the cluster is deleted", func() {
+			BeforeEach(func() {
+				now := v1.Now()
+				view.Infrastructure.SetDeletionTimestamp(&now)
+			})
+
+			It("only unregisters the records of the enabled registrars", func() {
+				Expect(firstRegistrar.UnregisterCallCount()).To(Equal(1))
+				Expect(secondRegistrar.UnregisterCallCount()).To(Equal(0))
+			})
+		})
+
+		When("the records of disabled registrars are cleaned up", func() {
+			BeforeEach(func() {
+				cleanupDisabledRegistrars = true
+			})
+
+			It("unregisters the records of the disabled registrar", func() {
+				Expect(reconcileErr).NotTo(HaveOccurred())
+				Expect(secondRegistrar.UnregisterCallCount()).To(Equal(1))
+				Expect(firstRegistrar.UnregisterCallCount()).To(Equal(0))
+			})
+
+			When("the cleanup fails", func() {
+				BeforeEach(func() {
+					secondRegistrar.UnregisterReturns(errors.New("boom"))
+				})
+
+				It("returns the error", func() {
+					Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+				})
+			})
+
+			When("the cluster opts out of the cleanup", func() {
+				BeforeEach(func() {
+					view.Annotations[annotation.CleanupDisabledRegistrars] = "false"
+				})
+
+				It("keeps the records of the disabled registrar", func() {
+					Expect(secondRegistrar.UnregisterCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		When("the cluster opts in to the cleanup", func() {
+			BeforeEach(func() {
+				view.Annotations[annotation.CleanupDisabledRegistrars] = "true"
+			})
+
+			It("unregisters the records of the disabled registrar", func() {
+				Expect(secondRegistrar.UnregisterCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	When("a registrar refuses to overwrite an existing record", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(registrar.NewConflictError(registrar.ReasonRecordConflict, "record exists"))
+		})
+
+		It("does not run the registrars depending on it", func() {
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(0))
+		})
+
+		It("does not hot loop", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute * 10))
+		})
+
+		It("reports the conflict", func() {
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Status).To(BeEquivalentTo("False"))
+			Expect(condition.Reason).To(Equal(registrar.ReasonRecordConflict))
+			Expect(condition.Severity).To(Equal(capi.ConditionSeverityError))
+			Expect(condition.Message).To(Equal("record exists"))
+		})
+	})
+
+	When("a registrar is rate limited", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(&googleapi.Error{
+				Code:   http.StatusTooManyRequests,
+				Header: http.Header{"Retry-After": []string{"42"}},
+			})
+		})
+
+		It("requeues the cluster after the requested delay", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Second * 42))
+		})
+
+		It("reports the rate limit", func() {
+			Expect(client.SetConditionCallCount()).To(Equal(1))
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Status).To(BeEquivalentTo("False"))
+			Expect(condition.Reason).To(Equal(controllers.ReasonRateLimited))
+			Expect(condition.Severity).To(Equal(capi.ConditionSeverityWarning))
+		})
+	})
+
+	When("a registrar fails with a server error", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(&googleapi.Error{Code: http.StatusServiceUnavailable})
+		})
+
+		It("requeues the cluster quickly", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Second * 10))
+		})
+	})
+
+	When("a registrar exceeds the rate limit of the project", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(&googleapi.Error{
+				Code:   http.StatusForbidden,
+				Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}},
+			})
+		})
+
+		It("treats the error as transient", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Second * 30))
+		})
+	})
+
+	When("a registrar is not permitted to manage records", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(&googleapi.Error{Code: http.StatusForbidden})
+		})
+
+		It("does not hot loop", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute * 10))
+		})
+
+		It("reports the permanent error", func() {
+			Expect(client.SetConditionCallCount()).To(Equal(1))
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Status).To(BeEquivalentTo("False"))
+			Expect(condition.Reason).To(Equal(controllers.ReasonPermissionDenied))
+			Expect(condition.Severity).To(Equal(capi.ConditionSeverityError))
+		})
+	})
+
+	When("a registrar exceeds the quota of the project", func() {
+		BeforeEach(func() {
+			firstRegistrar.RegisterReturns(&googleapi.Error{
+				Code:   http.StatusForbidden,
+				Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}},
+			})
+		})
+
+		It("reports the exceeded quota", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Reason).To(Equal(controllers.ReasonQuotaExceeded))
+		})
+	})
+
+	When("a registrar is pending", func() {
+		BeforeEach(func() {
+			pendingErr := registrar.NewPendingError("SomethingPending", "something is pending", time.Second*30)
+			firstRegistrar.RegisterReturns(pendingErr)
+		})
+
+		It("runs the remaining registrars", func() {
+			Expect(secondRegistrar.RegisterCallCount()).To(Equal(1))
+		})
+
+		It("requeues the cluster quickly", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Second * 30))
+		})
+
+		It("reports the pending registration", func() {
+			Expect(client.SetConditionCallCount()).To(Equal(1))
+			_, _, condition := client.SetConditionArgsForCall(0)
+			Expect(condition.Type).To(Equal(controllers.ConditionDNSReady))
+			Expect(condition.Status).To(BeEquivalentTo("False"))
+			Expect(condition.Reason).To(Equal("SomethingPending"))
+			Expect(condition.Message).To(Equal("something is pending"))
+		})
+
+		When("another registrar fails", func() {
+			BeforeEach(func() {
+				secondRegistrar.RegisterReturns(errors.New("boom"))
+			})
+
+			It("returns the error", func() {
+				Expect(reconcileErr).To(MatchError(ContainSubstring("boom")))
+			})
+		})
+	})
+
+	When("setting the condition fails", func() {
+		BeforeEach(func() {
+			client.SetConditionReturns(errors.New("boom"))
+		})
+
+		It("still reconciles", func() {
+			Expect(reconcileErr).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Minute * 10))
+		})
+	})
+})
@@ -6,18 +6,18 @@ import (
 	"sync"
 
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
-	v1beta1a "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/api/v1beta1"
 
 	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 )
 
-type FakeGCPClusterClient struct {
-	AddFinalizerStub        func(context.Context, *v1beta1.GCPCluster, string) error
+type FakeClusterClient struct {
+	AddFinalizerStub        func(context.Context, *clusterview.Cluster, string) error
 	addFinalizerMutex       sync.RWMutex
 	addFinalizerArgsForCall []struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 		arg3 string
 	}
 	addFinalizerReturns struct {
@@ -26,39 +26,25 @@ type FakeGCPClusterClient struct {
 	addFinalizerReturnsOnCall map[int]struct {
 		result1 error
 	}
-	GetStub        func(context.Context, types.NamespacedName) (*v1beta1.GCPCluster, error)
+	GetStub        func(context.Context, types.NamespacedName) (*clusterview.Cluster, error)
 	getMutex       sync.RWMutex
 	getArgsForCall []struct {
 		arg1 context.Context
 		arg2 types.NamespacedName
 	}
 	getReturns struct {
-		result1 *v1beta1.GCPCluster
+		result1 *clusterview.Cluster
 		result2 error
 	}
 	getReturnsOnCall map[int]struct {
-		result1 *v1beta1.GCPCluster
+		result1 *clusterview.Cluster
 		result2 error
 	}
-	GetOwnerStub        func(context.Context, *v1beta1.GCPCluster) (*v1beta1a.Cluster, error)
-	getOwnerMutex       sync.RWMutex
-	getOwnerArgsForCall []struct {
-		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
-	}
-	getOwnerReturns struct {
-		result1 *v1beta1a.Cluster
-		result2 error
-	}
-	getOwnerReturnsOnCall map[int]struct {
-		result1 *v1beta1a.Cluster
-		result2 error
-	}
-	RemoveFinalizerStub        func(context.Context, *v1beta1.GCPCluster, string) error
+	RemoveFinalizerStub        func(context.Context, *clusterview.Cluster, string) error
 	removeFinalizerMutex       sync.RWMutex
 	removeFinalizerArgsForCall []struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 		arg3 string
 	}
 	removeFinalizerReturns struct {
@@ -67,16 +53,29 @@ type FakeGCPClusterClient struct {
 	removeFinalizerReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetConditionStub        func(context.Context, *v1beta1.Cluster, *v1beta1.Condition) error
+	setConditionMutex       sync.RWMutex
+	setConditionArgsForCall []struct {
+		arg1 context.Context
+		arg2 *v1beta1.Cluster
+		arg3 *v1beta1.Condition
+	}
+	setConditionReturns struct {
+		result1 error
+	}
+	setConditionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizer(arg1 context.Context, arg2 *v1beta1.GCPCluster, arg3 string) error {
+func (fake *FakeClusterClient) AddFinalizer(arg1 context.Context, arg2 *clusterview.Cluster, arg3 string) error {
 	fake.addFinalizerMutex.Lock()
 	ret, specificReturn := fake.addFinalizerReturnsOnCall[len(fake.addFinalizerArgsForCall)]
 	fake.addFinalizerArgsForCall = append(fake.addFinalizerArgsForCall, struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 		arg3 string
 	}{arg1, arg2, arg3})
 	stub := fake.AddFinalizerStub
@@ -92,26 +91,26 @@ func (fake *FakeGCPClusterClient) AddFinalizer(arg1 context.Context, arg2 *v1bet
 	return fakeReturns.result1
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizerCallCount() int {
+func (fake *FakeClusterClient) AddFinalizerCallCount() int {
 	fake.addFinalizerMutex.RLock()
 	defer fake.addFinalizerMutex.RUnlock()
 	return len(fake.addFinalizerArgsForCall)
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizerCalls(stub func(context.Context, *v1beta1.GCPCluster, string) error) {
+func (fake *FakeClusterClient) AddFinalizerCalls(stub func(context.Context, *clusterview.Cluster, string) error) {
 	fake.addFinalizerMutex.Lock()
 	defer fake.addFinalizerMutex.Unlock()
 	fake.AddFinalizerStub = stub
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizerArgsForCall(i int) (context.Context, *v1beta1.GCPCluster, string) {
+func (fake *FakeClusterClient) AddFinalizerArgsForCall(i int) (context.Context, *clusterview.Cluster, string) {
 	fake.addFinalizerMutex.RLock()
 	defer fake.addFinalizerMutex.RUnlock()
 	argsForCall := fake.addFinalizerArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizerReturns(result1 error) {
+func (fake *FakeClusterClient) AddFinalizerReturns(result1 error) {
 	fake.addFinalizerMutex.Lock()
 	defer fake.addFinalizerMutex.Unlock()
 	fake.AddFinalizerStub = nil
@@ -120,7 +119,7 @@ func (fake *FakeGCPClusterClient) AddFinalizerReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeGCPClusterClient) AddFinalizerReturnsOnCall(i int, result1 error) {
+func (fake *FakeClusterClient) AddFinalizerReturnsOnCall(i int, result1 error) {
 	fake.addFinalizerMutex.Lock()
 	defer fake.addFinalizerMutex.Unlock()
 	fake.AddFinalizerStub = nil
@@ -134,7 +133,7 @@ func (fake *FakeGCPClusterClient) AddFinalizerReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
-func (fake *FakeGCPClusterClient) Get(arg1 context.Context, arg2 types.NamespacedName) (*v1beta1.GCPCluster, error) {
+func (fake *FakeClusterClient) Get(arg1 context.Context, arg2 types.NamespacedName) (*clusterview.Cluster, error) {
 	fake.getMutex.Lock()
 	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
 	fake.getArgsForCall = append(fake.getArgsForCall, struct {
@@ -154,122 +153,57 @@ func (fake *FakeGCPClusterClient) Get(arg1 context.Context, arg2 types.Namespace
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeGCPClusterClient) GetCallCount() int {
+func (fake *FakeClusterClient) GetCallCount() int {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	return len(fake.getArgsForCall)
 }
 
-func (fake *FakeGCPClusterClient) GetCalls(stub func(context.Context, types.NamespacedName) (*v1beta1.GCPCluster, error)) {
+func (fake *FakeClusterClient) GetCalls(stub func(context.Context, types.NamespacedName) (*clusterview.Cluster, error)) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = stub
 }
 
-func (fake *FakeGCPClusterClient) GetArgsForCall(i int) (context.Context, types.NamespacedName) {
+func (fake *FakeClusterClient) GetArgsForCall(i int) (context.Context, types.NamespacedName) {
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
 	argsForCall := fake.getArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeGCPClusterClient) GetReturns(result1 *v1beta1.GCPCluster, result2 error) {
+func (fake *FakeClusterClient) GetReturns(result1 *clusterview.Cluster, result2 error) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = nil
 	fake.getReturns = struct {
-		result1 *v1beta1.GCPCluster
+		result1 *clusterview.Cluster
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeGCPClusterClient) GetReturnsOnCall(i int, result1 *v1beta1.GCPCluster, result2 error) {
+func (fake *FakeClusterClient) GetReturnsOnCall(i int, result1 *clusterview.Cluster, result2 error) {
 	fake.getMutex.Lock()
 	defer fake.getMutex.Unlock()
 	fake.GetStub = nil
 	if fake.getReturnsOnCall == nil {
 		fake.getReturnsOnCall = make(map[int]struct {
-			result1 *v1beta1.GCPCluster
+			result1 *clusterview.Cluster
 			result2 error
 		})
 	}
 	fake.getReturnsOnCall[i] = struct {
-		result1 *v1beta1.GCPCluster
-		result2 error
-	}{result1, result2}
-}
-
-func (fake *FakeGCPClusterClient) GetOwner(arg1 context.Context, arg2 *v1beta1.GCPCluster) (*v1beta1a.Cluster, error) {
-	fake.getOwnerMutex.Lock()
-	ret, specificReturn := fake.getOwnerReturnsOnCall[len(fake.getOwnerArgsForCall)]
-	fake.getOwnerArgsForCall = append(fake.getOwnerArgsForCall, struct {
-		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
-	}{arg1, arg2})
-	stub := fake.GetOwnerStub
-	fakeReturns := fake.getOwnerReturns
-	fake.recordInvocation("GetOwner", []interface{}{arg1, arg2})
-	fake.getOwnerMutex.Unlock()
-	if stub != nil {
-		return stub(arg1, arg2)
-	}
-	if specificReturn {
-		return ret.result1, ret.result2
-	}
-	return fakeReturns.result1, fakeReturns.result2
-}
-
-func (fake *FakeGCPClusterClient) GetOwnerCallCount() int {
-	fake.getOwnerMutex.RLock()
-	defer fake.getOwnerMutex.RUnlock()
-	return len(fake.getOwnerArgsForCall)
-}
-
-func (fake *FakeGCPClusterClient) GetOwnerCalls(stub func(context.Context, *v1beta1.GCPCluster) (*v1beta1a.Cluster, error)) {
-	fake.getOwnerMutex.Lock()
-	defer fake.getOwnerMutex.Unlock()
-	fake.GetOwnerStub = stub
-}
-
-func (fake *FakeGCPClusterClient) GetOwnerArgsForCall(i int) (context.Context, *v1beta1.GCPCluster) {
-	fake.getOwnerMutex.RLock()
-	defer fake.getOwnerMutex.RUnlock()
-	argsForCall := fake.getOwnerArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
-}
-
-func (fake *FakeGCPClusterClient) GetOwnerReturns(result1 *v1beta1a.Cluster, result2 error) {
-	fake.getOwnerMutex.Lock()
-	defer fake.getOwnerMutex.Unlock()
-	fake.GetOwnerStub = nil
-	fake.getOwnerReturns = struct {
-		result1 *v1beta1a.Cluster
-		result2 error
-	}{result1, result2}
-}
-
-func (fake *FakeGCPClusterClient) GetOwnerReturnsOnCall(i int, result1 *v1beta1a.Cluster, result2 error) {
-	fake.getOwnerMutex.Lock()
-	defer fake.getOwnerMutex.Unlock()
-	fake.GetOwnerStub = nil
-	if fake.getOwnerReturnsOnCall == nil {
-		fake.getOwnerReturnsOnCall = make(map[int]struct {
-			result1 *v1beta1a.Cluster
-			result2 error
-		})
-	}
-	fake.getOwnerReturnsOnCall[i] = struct {
-		result1 *v1beta1a.Cluster
+		result1 *clusterview.Cluster
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizer(arg1 context.Context, arg2 *v1beta1.GCPCluster, arg3 string) error {
+func (fake *FakeClusterClient) RemoveFinalizer(arg1 context.Context, arg2 *clusterview.Cluster, arg3 string) error {
 	fake.removeFinalizerMutex.Lock()
 	ret, specificReturn := fake.removeFinalizerReturnsOnCall[len(fake.removeFinalizerArgsForCall)]
 	fake.removeFinalizerArgsForCall = append(fake.removeFinalizerArgsForCall, struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 		arg3 string
 	}{arg1, arg2, arg3})
 	stub := fake.RemoveFinalizerStub
@@ -285,26 +219,26 @@ func (fake *FakeGCPClusterClient) RemoveFinalizer(arg1 context.Context, arg2 *v1
 	return fakeReturns.result1
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizerCallCount() int {
+func (fake *FakeClusterClient) RemoveFinalizerCallCount() int {
 	fake.removeFinalizerMutex.RLock()
 	defer fake.removeFinalizerMutex.RUnlock()
 	return len(fake.removeFinalizerArgsForCall)
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizerCalls(stub func(context.Context, *v1beta1.GCPCluster, string) error) {
+func (fake *FakeClusterClient) RemoveFinalizerCalls(stub func(context.Context, *clusterview.Cluster, string) error) {
 	fake.removeFinalizerMutex.Lock()
 	defer fake.removeFinalizerMutex.Unlock()
 	fake.RemoveFinalizerStub = stub
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizerArgsForCall(i int) (context.Context, *v1beta1.GCPCluster, string) {
+func (fake *FakeClusterClient) RemoveFinalizerArgsForCall(i int) (context.Context, *clusterview.Cluster, string) {
 	fake.removeFinalizerMutex.RLock()
 	defer fake.removeFinalizerMutex.RUnlock()
 	argsForCall := fake.removeFinalizerArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizerReturns(result1 error) {
+func (fake *FakeClusterClient) RemoveFinalizerReturns(result1 error) {
 	fake.removeFinalizerMutex.Lock()
 	defer fake.removeFinalizerMutex.Unlock()
 	fake.RemoveFinalizerStub = nil
@@ -313,7 +247,7 @@ func (fake *FakeGCPClusterClient) RemoveFinalizerReturns(result1 error) {
 	}{result1}
 }
 
-func (fake *FakeGCPClusterClient) RemoveFinalizerReturnsOnCall(i int, result1 error) {
+func (fake *FakeClusterClient) RemoveFinalizerReturnsOnCall(i int, result1 error) {
 	fake.removeFinalizerMutex.Lock()
 	defer fake.removeFinalizerMutex.Unlock()
 	fake.RemoveFinalizerStub = nil
@@ -327,17 +261,80 @@ func (fake *FakeGCPClusterClient) RemoveFinalizerReturnsOnCall(i int, result1 er
 	}{result1}
 }
 
-func (fake *FakeGCPClusterClient) Invocations() map[string][][]interface{} {
+func (fake *FakeClusterClient) SetCondition(arg1 context.Context, arg2 *v1beta1.Cluster, arg3 *v1beta1.Condition) error {
+	fake.setConditionMutex.Lock()
+	ret, specificReturn := fake.setConditionReturnsOnCall[len(fake.setConditionArgsForCall)]
+	fake.setConditionArgsForCall = append(fake.setConditionArgsForCall, struct {
+		arg1 context.Context
+		arg2 *v1beta1.Cluster
+		arg3 *v1beta1.Condition
+	}{arg1, arg2, arg3})
+	stub := fake.SetConditionStub
+	fakeReturns := fake.setConditionReturns
+	fake.recordInvocation("SetCondition", []interface{}{arg1, arg2, arg3})
+	fake.setConditionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClusterClient) SetConditionCallCount() int {
+	fake.setConditionMutex.RLock()
+	defer fake.setConditionMutex.RUnlock()
+	return len(fake.setConditionArgsForCall)
+}
+
+func (fake *FakeClusterClient) SetConditionCalls(stub func(context.Context, *v1beta1.Cluster, *v1beta1.Condition) error) {
+	fake.setConditionMutex.Lock()
+	defer fake.setConditionMutex.Unlock()
+	fake.SetConditionStub = stub
+}
+
+func (fake *FakeClusterClient) SetConditionArgsForCall(i int) (context.Context, *v1beta1.Cluster, *v1beta1.Condition) {
+	fake.setConditionMutex.RLock()
+	defer fake.setConditionMutex.RUnlock()
+	argsForCall := fake.setConditionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClusterClient) SetConditionReturns(result1 error) {
+	fake.setConditionMutex.Lock()
+	defer fake.setConditionMutex.Unlock()
+	fake.SetConditionStub = nil
+	fake.setConditionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterClient) SetConditionReturnsOnCall(i int, result1 error) {
+	fake.setConditionMutex.Lock()
+	defer fake.setConditionMutex.Unlock()
+	fake.SetConditionStub = nil
+	if fake.setConditionReturnsOnCall == nil {
+		fake.setConditionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setConditionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClusterClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.addFinalizerMutex.RLock()
 	defer fake.addFinalizerMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
-	fake.getOwnerMutex.RLock()
-	defer fake.getOwnerMutex.RUnlock()
 	fake.removeFinalizerMutex.RLock()
 	defer fake.removeFinalizerMutex.RUnlock()
+	fake.setConditionMutex.RLock()
+	defer fake.setConditionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -345,7 +342,7 @@ func (fake *FakeGCPClusterClient) Invocations() map[string][][]interface{} {
 	return copiedInvocations
 }
 
-func (fake *FakeGCPClusterClient) recordInvocation(key string, args []interface{}) {
+func (fake *FakeClusterClient) recordInvocation(key string, args []interface{}) {
 	fake.invocationsMutex.Lock()
 	defer fake.invocationsMutex.Unlock()
 	if fake.invocations == nil {
@@ -357,4 +354,4 @@ func (fake *FakeGCPClusterClient) recordInvocation(key string, args []interface{
 	fake.invocations[key] = append(fake.invocations[key], args)
 }
 
-var _ controllers.GCPClusterClient = new(FakeGCPClusterClient)
+var _ controllers.ClusterClient = new(FakeClusterClient)
@@ -5,17 +5,36 @@ import (
 	"context"
 	"sync"
 
-	"sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
-
 	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 )
 
 type FakeRegistrar struct {
-	RegisterStub        func(context.Context, *v1beta1.GCPCluster) error
+	DependenciesStub        func() []string
+	dependenciesMutex       sync.RWMutex
+	dependenciesArgsForCall []struct {
+	}
+	dependenciesReturns struct {
+		result1 []string
+	}
+	dependenciesReturnsOnCall map[int]struct {
+		result1 []string
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	RegisterStub        func(context.Context, *clusterview.Cluster) error
 	registerMutex       sync.RWMutex
 	registerArgsForCall []struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}
 	registerReturns struct {
 		result1 error
@@ -23,11 +42,11 @@ type FakeRegistrar struct {
 	registerReturnsOnCall map[int]struct {
 		result1 error
 	}
-	UnregisterStub        func(context.Context, *v1beta1.GCPCluster) error
+	UnregisterStub        func(context.Context, *clusterview.Cluster) error
 	unregisterMutex       sync.RWMutex
 	unregisterArgsForCall []struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}
 	unregisterReturns struct {
 		result1 error
@@ -39,12 +58,118 @@ type FakeRegistrar struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeRegistrar) Register(arg1 context.Context, arg2 *v1beta1.GCPCluster) error {
+func (fake *FakeRegistrar) Dependencies() []string {
+	fake.dependenciesMutex.Lock()
+	ret, specificReturn := fake.dependenciesReturnsOnCall[len(fake.dependenciesArgsForCall)]
+	fake.dependenciesArgsForCall = append(fake.dependenciesArgsForCall, struct {
+	}{})
+	stub := fake.DependenciesStub
+	fakeReturns := fake.dependenciesReturns
+	fake.recordInvocation("Dependencies", []interface{}{})
+	fake.dependenciesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRegistrar) DependenciesCallCount() int {
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	return len(fake.dependenciesArgsForCall)
+}
+
+func (fake *FakeRegistrar) DependenciesCalls(stub func() []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = stub
+}
+
+func (fake *FakeRegistrar) DependenciesReturns(result1 []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	fake.dependenciesReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeRegistrar) DependenciesReturnsOnCall(i int, result1 []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	if fake.dependenciesReturnsOnCall == nil {
+		fake.dependenciesReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.dependenciesReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeRegistrar) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRegistrar) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeRegistrar) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeRegistrar) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeRegistrar) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeRegistrar) Register(arg1 context.Context, arg2 *clusterview.Cluster) error {
 	fake.registerMutex.Lock()
 	ret, specificReturn := fake.registerReturnsOnCall[len(fake.registerArgsForCall)]
 	fake.registerArgsForCall = append(fake.registerArgsForCall, struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}{arg1, arg2})
 	stub := fake.RegisterStub
 	fakeReturns := fake.registerReturns
@@ -65,13 +190,13 @@ func (fake *FakeRegistrar) RegisterCallCount() int {
 	return len(fake.registerArgsForCall)
 }
 
-func (fake *FakeRegistrar) RegisterCalls(stub func(context.Context, *v1beta1.GCPCluster) error) {
+func (fake *FakeRegistrar) RegisterCalls(stub func(context.Context, *clusterview.Cluster) error) {
 	fake.registerMutex.Lock()
 	defer fake.registerMutex.Unlock()
 	fake.RegisterStub = stub
 }
 
-func (fake *FakeRegistrar) RegisterArgsForCall(i int) (context.Context, *v1beta1.GCPCluster) {
+func (fake *FakeRegistrar) RegisterArgsForCall(i int) (context.Context, *clusterview.Cluster) {
 	fake.registerMutex.RLock()
 	defer fake.registerMutex.RUnlock()
 	argsForCall := fake.registerArgsForCall[i]
@@ -101,12 +226,12 @@ func (fake *FakeRegistrar) RegisterReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeRegistrar) Unregister(arg1 context.Context, arg2 *v1beta1.GCPCluster) error {
+func (fake *FakeRegistrar) Unregister(arg1 context.Context, arg2 *clusterview.Cluster) error {
 	fake.unregisterMutex.Lock()
 	ret, specificReturn := fake.unregisterReturnsOnCall[len(fake.unregisterArgsForCall)]
 	fake.unregisterArgsForCall = append(fake.unregisterArgsForCall, struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}{arg1, arg2})
 	stub := fake.UnregisterStub
 	fakeReturns := fake.unregisterReturns
@@ -127,13 +252,13 @@ func (fake *FakeRegistrar) UnregisterCallCount() int {
 	return len(fake.unregisterArgsForCall)
 }
 
-func (fake *FakeRegistrar) UnregisterCalls(stub func(context.Context, *v1beta1.GCPCluster) error) {
+func (fake *FakeRegistrar) UnregisterCalls(stub func(context.Context, *clusterview.Cluster) error) {
 	fake.unregisterMutex.Lock()
 	defer fake.unregisterMutex.Unlock()
 	fake.UnregisterStub = stub
 }
 
-func (fake *FakeRegistrar) UnregisterArgsForCall(i int) (context.Context, *v1beta1.GCPCluster) {
+func (fake *FakeRegistrar) UnregisterArgsForCall(i int) (context.Context, *clusterview.Cluster) {
 	fake.unregisterMutex.RLock()
 	defer fake.unregisterMutex.RUnlock()
 	argsForCall := fake.unregisterArgsForCall[i]
@@ -166,6 +291,10 @@ func (fake *FakeRegistrar) UnregisterReturnsOnCall(i int, result1 error) {
 func (fake *FakeRegistrar) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
 	fake.registerMutex.RLock()
 	defer fake.registerMutex.RUnlock()
 	fake.unregisterMutex.RLock()
@@ -0,0 +1,473 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package controllersfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+type FakeZoneRegistrar struct {
+	DependenciesStub        func() []string
+	dependenciesMutex       sync.RWMutex
+	dependenciesArgsForCall []struct {
+	}
+	dependenciesReturns struct {
+		result1 []string
+	}
+	dependenciesReturnsOnCall map[int]struct {
+		result1 []string
+	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct {
+	}
+	nameReturns struct {
+		result1 string
+	}
+	nameReturnsOnCall map[int]struct {
+		result1 string
+	}
+	RegisterStub        func(context.Context, *clusterview.Cluster) error
+	registerMutex       sync.RWMutex
+	registerArgsForCall []struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}
+	registerReturns struct {
+		result1 error
+	}
+	registerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RetainStub        func(context.Context, *clusterview.Cluster) error
+	retainMutex       sync.RWMutex
+	retainArgsForCall []struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}
+	retainReturns struct {
+		result1 error
+	}
+	retainReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UnregisterStub        func(context.Context, *clusterview.Cluster) error
+	unregisterMutex       sync.RWMutex
+	unregisterArgsForCall []struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}
+	unregisterReturns struct {
+		result1 error
+	}
+	unregisterReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UnregisterDelegationStub        func(context.Context, *clusterview.Cluster) error
+	unregisterDelegationMutex       sync.RWMutex
+	unregisterDelegationArgsForCall []struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}
+	unregisterDelegationReturns struct {
+		result1 error
+	}
+	unregisterDelegationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeZoneRegistrar) Dependencies() []string {
+	fake.dependenciesMutex.Lock()
+	ret, specificReturn := fake.dependenciesReturnsOnCall[len(fake.dependenciesArgsForCall)]
+	fake.dependenciesArgsForCall = append(fake.dependenciesArgsForCall, struct {
+	}{})
+	stub := fake.DependenciesStub
+	fakeReturns := fake.dependenciesReturns
+	fake.recordInvocation("Dependencies", []interface{}{})
+	fake.dependenciesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) DependenciesCallCount() int {
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	return len(fake.dependenciesArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) DependenciesCalls(stub func() []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = stub
+}
+
+func (fake *FakeZoneRegistrar) DependenciesReturns(result1 []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	fake.dependenciesReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) DependenciesReturnsOnCall(i int, result1 []string) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	if fake.dependenciesReturnsOnCall == nil {
+		fake.dependenciesReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.dependenciesReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) Name() string {
+	fake.nameMutex.Lock()
+	ret, specificReturn := fake.nameReturnsOnCall[len(fake.nameArgsForCall)]
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct {
+	}{})
+	stub := fake.NameStub
+	fakeReturns := fake.nameReturns
+	fake.recordInvocation("Name", []interface{}{})
+	fake.nameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) NameCalls(stub func() string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = stub
+}
+
+func (fake *FakeZoneRegistrar) NameReturns(result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) NameReturnsOnCall(i int, result1 string) {
+	fake.nameMutex.Lock()
+	defer fake.nameMutex.Unlock()
+	fake.NameStub = nil
+	if fake.nameReturnsOnCall == nil {
+		fake.nameReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.nameReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) Register(arg1 context.Context, arg2 *clusterview.Cluster) error {
+	fake.registerMutex.Lock()
+	ret, specificReturn := fake.registerReturnsOnCall[len(fake.registerArgsForCall)]
+	fake.registerArgsForCall = append(fake.registerArgsForCall, struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}{arg1, arg2})
+	stub := fake.RegisterStub
+	fakeReturns := fake.registerReturns
+	fake.recordInvocation("Register", []interface{}{arg1, arg2})
+	fake.registerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) RegisterCallCount() int {
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	return len(fake.registerArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) RegisterCalls(stub func(context.Context, *clusterview.Cluster) error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = stub
+}
+
+func (fake *FakeZoneRegistrar) RegisterArgsForCall(i int) (context.Context, *clusterview.Cluster) {
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	argsForCall := fake.registerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeZoneRegistrar) RegisterReturns(result1 error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = nil
+	fake.registerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) RegisterReturnsOnCall(i int, result1 error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = nil
+	if fake.registerReturnsOnCall == nil {
+		fake.registerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.registerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) Retain(arg1 context.Context, arg2 *clusterview.Cluster) error {
+	fake.retainMutex.Lock()
+	ret, specificReturn := fake.retainReturnsOnCall[len(fake.retainArgsForCall)]
+	fake.retainArgsForCall = append(fake.retainArgsForCall, struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}{arg1, arg2})
+	stub := fake.RetainStub
+	fakeReturns := fake.retainReturns
+	fake.recordInvocation("Retain", []interface{}{arg1, arg2})
+	fake.retainMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) RetainCallCount() int {
+	fake.retainMutex.RLock()
+	defer fake.retainMutex.RUnlock()
+	return len(fake.retainArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) RetainCalls(stub func(context.Context, *clusterview.Cluster) error) {
+	fake.retainMutex.Lock()
+	defer fake.retainMutex.Unlock()
+	fake.RetainStub = stub
+}
+
+func (fake *FakeZoneRegistrar) RetainArgsForCall(i int) (context.Context, *clusterview.Cluster) {
+	fake.retainMutex.RLock()
+	defer fake.retainMutex.RUnlock()
+	argsForCall := fake.retainArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeZoneRegistrar) RetainReturns(result1 error) {
+	fake.retainMutex.Lock()
+	defer fake.retainMutex.Unlock()
+	fake.RetainStub = nil
+	fake.retainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) RetainReturnsOnCall(i int, result1 error) {
+	fake.retainMutex.Lock()
+	defer fake.retainMutex.Unlock()
+	fake.RetainStub = nil
+	if fake.retainReturnsOnCall == nil {
+		fake.retainReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.retainReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) Unregister(arg1 context.Context, arg2 *clusterview.Cluster) error {
+	fake.unregisterMutex.Lock()
+	ret, specificReturn := fake.unregisterReturnsOnCall[len(fake.unregisterArgsForCall)]
+	fake.unregisterArgsForCall = append(fake.unregisterArgsForCall, struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}{arg1, arg2})
+	stub := fake.UnregisterStub
+	fakeReturns := fake.unregisterReturns
+	fake.recordInvocation("Unregister", []interface{}{arg1, arg2})
+	fake.unregisterMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) UnregisterCallCount() int {
+	fake.unregisterMutex.RLock()
+	defer fake.unregisterMutex.RUnlock()
+	return len(fake.unregisterArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) UnregisterCalls(stub func(context.Context, *clusterview.Cluster) error) {
+	fake.unregisterMutex.Lock()
+	defer fake.unregisterMutex.Unlock()
+	fake.UnregisterStub = stub
+}
+
+func (fake *FakeZoneRegistrar) UnregisterArgsForCall(i int) (context.Context, *clusterview.Cluster) {
+	fake.unregisterMutex.RLock()
+	defer fake.unregisterMutex.RUnlock()
+	argsForCall := fake.unregisterArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeZoneRegistrar) UnregisterReturns(result1 error) {
+	fake.unregisterMutex.Lock()
+	defer fake.unregisterMutex.Unlock()
+	fake.UnregisterStub = nil
+	fake.unregisterReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) UnregisterReturnsOnCall(i int, result1 error) {
+	fake.unregisterMutex.Lock()
+	defer fake.unregisterMutex.Unlock()
+	fake.UnregisterStub = nil
+	if fake.unregisterReturnsOnCall == nil {
+		fake.unregisterReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unregisterReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegation(arg1 context.Context, arg2 *clusterview.Cluster) error {
+	fake.unregisterDelegationMutex.Lock()
+	ret, specificReturn := fake.unregisterDelegationReturnsOnCall[len(fake.unregisterDelegationArgsForCall)]
+	fake.unregisterDelegationArgsForCall = append(fake.unregisterDelegationArgsForCall, struct {
+		arg1 context.Context
+		arg2 *clusterview.Cluster
+	}{arg1, arg2})
+	stub := fake.UnregisterDelegationStub
+	fakeReturns := fake.unregisterDelegationReturns
+	fake.recordInvocation("UnregisterDelegation", []interface{}{arg1, arg2})
+	fake.unregisterDelegationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegationCallCount() int {
+	fake.unregisterDelegationMutex.RLock()
+	defer fake.unregisterDelegationMutex.RUnlock()
+	return len(fake.unregisterDelegationArgsForCall)
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegationCalls(stub func(context.Context, *clusterview.Cluster) error) {
+	fake.unregisterDelegationMutex.Lock()
+	defer fake.unregisterDelegationMutex.Unlock()
+	fake.UnregisterDelegationStub = stub
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegationArgsForCall(i int) (context.Context, *clusterview.Cluster) {
+	fake.unregisterDelegationMutex.RLock()
+	defer fake.unregisterDelegationMutex.RUnlock()
+	argsForCall := fake.unregisterDelegationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegationReturns(result1 error) {
+	fake.unregisterDelegationMutex.Lock()
+	defer fake.unregisterDelegationMutex.Unlock()
+	fake.UnregisterDelegationStub = nil
+	fake.unregisterDelegationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) UnregisterDelegationReturnsOnCall(i int, result1 error) {
+	fake.unregisterDelegationMutex.Lock()
+	defer fake.unregisterDelegationMutex.Unlock()
+	fake.UnregisterDelegationStub = nil
+	if fake.unregisterDelegationReturnsOnCall == nil {
+		fake.unregisterDelegationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unregisterDelegationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeZoneRegistrar) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	fake.retainMutex.RLock()
+	defer fake.retainMutex.RUnlock()
+	fake.unregisterMutex.RLock()
+	defer fake.unregisterMutex.RUnlock()
+	fake.unregisterDelegationMutex.RLock()
+	defer fake.unregisterDelegationMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeZoneRegistrar) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ controllers.ZoneRegistrar = new(FakeZoneRegistrar)
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	ReasonRateLimited        = "RateLimited"
+	ReasonQuotaExceeded      = "QuotaExceeded"
+	ReasonPermissionDenied   = "PermissionDenied"
+	ReasonPreconditionFailed = "PreconditionFailed"
+	ReasonServiceUnavailable = "ServiceUnavailable"
+	ReasonInvalidRequest     = "InvalidRequest"
+
+	rateLimitedRequeueAfter = time.Second * 30
+	transientRequeueAfter   = time.Second * 10
+)
+
+// cloudDNSError is the classification of an error returned by the Cloud DNS
+// API. Transient errors are retried after requeueAfter. Permanent errors
+// won't go away by retrying, they need someone to fix the quota or the
+// permissions of the operator, so they are only retried with the regular
+// requeue interval.
+type cloudDNSError struct {
+	reason       string
+	transient    bool
+	requeueAfter time.Duration
+}
+
+// classifyError returns the classification of Cloud DNS API errors and false
+// for any other error.
+func classifyError(err error) (cloudDNSError, bool) {
+	var googleErr *googleapi.Error
+	if !errors.As(err, &googleErr) {
+		return cloudDNSError{}, false
+	}
+
+	switch {
+	case googleErr.Code == http.StatusTooManyRequests:
+		return transientError(googleErr, ReasonRateLimited, rateLimitedRequeueAfter), true
+	case googleErr.Code >= http.StatusInternalServerError:
+		return transientError(googleErr, ReasonServiceUnavailable, transientRequeueAfter), true
+	case googleErr.Code == http.StatusPreconditionFailed:
+		// The record set was changed concurrently, the next attempt will
+		// read the new state.
+		return transientError(googleErr, ReasonPreconditionFailed, transientRequeueAfter), true
+	case googleErr.Code == http.StatusForbidden:
+		switch {
+		case hasErrorReason(googleErr, "rateLimitExceeded", "userRateLimitExceeded"):
+			return transientError(googleErr, ReasonRateLimited, rateLimitedRequeueAfter), true
+		case hasErrorReason(googleErr, "quotaExceeded"):
+			return cloudDNSError{reason: ReasonQuotaExceeded}, true
+		default:
+			return cloudDNSError{reason: ReasonPermissionDenied}, true
+		}
+	case googleErr.Code == http.StatusBadRequest:
+		return cloudDNSError{reason: ReasonInvalidRequest}, true
+	}
+
+	return cloudDNSError{}, false
+}
+
+func transientError(googleErr *googleapi.Error, reason string, defaultRequeueAfter time.Duration) cloudDNSError {
+	requeueAfter := defaultRequeueAfter
+	if retryAfter, ok := parseRetryAfter(googleErr.Header); ok {
+		requeueAfter = retryAfter
+	}
+
+	return cloudDNSError{
+		reason:       reason,
+		transient:    true,
+		requeueAfter: requeueAfter,
+	}
+}
+
+// parseRetryAfter supports both forms of the Retry-After header, delay
+// seconds and an HTTP date.
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func hasErrorReason(googleErr *googleapi.Error, reasons ...string) bool {
+	for _, item := range googleErr.Errors {
+		for _, reason := range reasons {
+			if item.Reason == reason {
+				return true
+			}
+		}
+	}
+
+	return false
+}
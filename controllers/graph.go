@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+)
+
+// registrarResult is the outcome of running a single registrar.
+type registrarResult struct {
+	name string
+	err  error
+	// skipped is set when the registrar did not run, because a registrar it
+	// waited for failed.
+	skipped bool
+}
+
+// failed reports whether registrars waiting for this one have to be
+// skipped. Pending registrars registered what they could, so they don't
+// block their dependents.
+func (r registrarResult) failed() bool {
+	if r.skipped {
+		return true
+	}
+
+	var pendingErr pendingError
+	return r.err != nil && !errors.As(r.err, &pendingErr)
+}
+
+// runRegistrars runs fn for all registrars concurrently, while making sure a
+// registrar only runs after all registrars it depends on. When reverse is
+// set, registrars wait for the registrars depending on them instead, which is
+// the order needed to unregister. Dependencies on registrars which are not in
+// the list are ignored. The results are returned in the order of the
+// registrars.
+func runRegistrars(registrars []Registrar, reverse bool, fn func(Registrar) error) ([]registrarResult, error) {
+	waitFor, err := buildRegistrarGraph(registrars, reverse)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	indexes := map[string]int{}
+	done := map[string]chan struct{}{}
+	for i, registrar := range registrars {
+		indexes[registrar.Name()] = i
+		done[registrar.Name()] = make(chan struct{})
+	}
+
+	results := make([]registrarResult, len(registrars))
+	var wg sync.WaitGroup
+	for i, registrar := range registrars {
+		wg.Add(1)
+		go func(i int, registrar Registrar) {
+			defer wg.Done()
+
+			name := registrar.Name()
+			defer close(done[name])
+
+			results[i].name = name
+			for _, dependency := range waitFor[name] {
+				<-done[dependency]
+				if results[indexes[dependency]].failed() {
+					results[i].skipped = true
+				}
+			}
+			if results[i].skipped {
+				return
+			}
+
+			results[i].err = fn(registrar)
+		}(i, registrar)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// buildRegistrarGraph returns the names of the registrars each registrar has
+// to wait for. It fails when registrar names are not unique or the
+// dependencies contain a cycle, as running the registrars would dead lock.
+func buildRegistrarGraph(registrars []Registrar, reverse bool) (map[string][]string, error) {
+	names := map[string]bool{}
+	for _, registrar := range registrars {
+		if names[registrar.Name()] {
+			return nil, microerror.Mask(fmt.Errorf("registrar %q is not unique", registrar.Name()))
+		}
+		names[registrar.Name()] = true
+	}
+
+	waitFor := map[string][]string{}
+	for _, registrar := range registrars {
+		for _, dependency := range registrar.Dependencies() {
+			if !names[dependency] {
+				continue
+			}
+
+			if reverse {
+				waitFor[dependency] = append(waitFor[dependency], registrar.Name())
+			} else {
+				waitFor[registrar.Name()] = append(waitFor[registrar.Name()], dependency)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return microerror.Mask(fmt.Errorf("registrar %q depends on itself", name))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dependency := range waitFor[name] {
+			err := visit(dependency)
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, registrar := range registrars {
+		err := visit(registrar.Name())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return waitFor, nil
+}
+
+func registrarNames(registrars []Registrar) []string {
+	var names []string
+	for _, registrar := range registrars {
+		names = append(names, registrar.Name())
+	}
+
+	return names
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+)
+
+const (
+	// indexInfrastructureRef indexes CAPI clusters by the kind and name of
+	// their infrastructure cluster.
+	indexInfrastructureRef = "spec.infrastructureRef"
+	// indexControlPlaneRef indexes CAPI clusters by the kind and name of
+	// their control plane.
+	indexControlPlaneRef = "spec.controlPlaneRef"
+)
+
+// indexRef returns the indexer of CAPI clusters by the reference.
+func indexRef(getRef func(*capi.Cluster) *corev1.ObjectReference) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		cluster, ok := obj.(*capi.Cluster)
+		if !ok {
+			return nil
+		}
+
+		ref := getRef(cluster)
+		if ref == nil || ref.Name == "" {
+			return nil
+		}
+
+		return []string{refKey(ref.Kind, ref.Name)}
+	}
+}
+
+func getInfrastructureRef(cluster *capi.Cluster) *corev1.ObjectReference {
+	return cluster.Spec.InfrastructureRef
+}
+
+func getControlPlaneRef(cluster *capi.Cluster) *corev1.ObjectReference {
+	return cluster.Spec.ControlPlaneRef
+}
+
+// refToCluster maps an object of the kind to the CAPI clusters referencing
+// it in the index.
+func refToCluster(reader client.Reader, index, kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		return listReferencingClusters(reader, index, obj.GetNamespace(), refKey(kind, obj.GetName()))
+	}
+}
+
+// bastionToCluster maps a bastion GCPMachine to the CAPI cluster referencing
+// the GCPCluster named by the bastion deployment label.
+func bastionToCluster(reader client.Reader) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		infrastructureName, ok := k8sclient.ClusterNameFromBastionLabel(obj.GetLabels()[k8sclient.LabelBastionKey])
+		if !ok {
+			return nil
+		}
+
+		return listReferencingClusters(reader, indexInfrastructureRef, obj.GetNamespace(), refKey(k8sclient.KindGCPCluster, infrastructureName))
+	}
+}
+
+// bastionPredicate filters GCPMachine events down to bastion machines whose
+// addresses, index or deletion state changed.
+func bastionPredicate() predicate.Predicate {
+	isBastion := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := k8sclient.ClusterNameFromBastionLabel(obj.GetLabels()[k8sclient.LabelBastionKey])
+		return ok
+	})
+
+	bastionChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldMachine, ok := e.ObjectOld.(*capg.GCPMachine)
+			if !ok {
+				return false
+			}
+			newMachine, ok := e.ObjectNew.(*capg.GCPMachine)
+			if !ok {
+				return false
+			}
+
+			return !reflect.DeepEqual(oldMachine.Status.Addresses, newMachine.Status.Addresses) ||
+				oldMachine.Annotations[k8sclient.AnnotationBastionIndex] != newMachine.Annotations[k8sclient.AnnotationBastionIndex] ||
+				!oldMachine.DeletionTimestamp.Equal(newMachine.DeletionTimestamp)
+		},
+	}
+
+	return predicate.And(isBastion, bastionChanged)
+}
+
+// listReferencingClusters returns the requests of the CAPI clusters in the
+// namespace, whose reference in the index has the key.
+func listReferencingClusters(reader client.Reader, index, namespace, key string) []reconcile.Request {
+	ctx := context.Background()
+	logger := log.FromContext(ctx).WithValues("namespace", namespace, index, key)
+
+	clusters := &capi.ClusterList{}
+	err := reader.List(ctx, clusters, client.InNamespace(namespace), client.MatchingFields{index: key})
+	if err != nil {
+		logger.Error(err, "Failed to list referencing clusters")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, cluster := range clusters.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKeyFromObject(&cluster),
+		})
+	}
+
+	return requests
+}
+
+func refKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
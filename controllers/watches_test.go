@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+)
+
+var _ = Describe("Watches", func() {
+	const namespace = "org-a"
+
+	newCluster := func(name string, infrastructureKind, infrastructureName string) *capi.Cluster {
+		cluster := &capi.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+		if infrastructureName != "" {
+			cluster.Spec.InfrastructureRef = &corev1.ObjectReference{
+				Kind: infrastructureKind,
+				Name: infrastructureName,
+			}
+		}
+		return cluster
+	}
+
+	newInfrastructure := func(name string) *capg.GCPCluster {
+		return &capg.GCPCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	}
+
+	newBastion := func(label string) *capg.GCPMachine {
+		machine := &capg.GCPMachine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bastion-1",
+				Namespace: namespace,
+			},
+		}
+		if label != "" {
+			machine.Labels = map[string]string{k8sclient.LabelBastionKey: label}
+		}
+		return machine
+	}
+
+	newClient := func(objects ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(capi.AddToScheme(scheme)).To(Succeed())
+		Expect(capg.AddToScheme(scheme)).To(Succeed())
+		return &indexedClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			indexes: map[string]client.IndexerFunc{
+				indexInfrastructureRef: indexRef(getInfrastructureRef),
+				indexControlPlaneRef:   indexRef(getControlPlaneRef),
+			},
+		}
+	}
+
+	requestFor := func(name string) []reconcile.Request {
+		return []reconcile.Request{
+			{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}},
+		}
+	}
+
+	DescribeTable("indexRef",
+		func(obj client.Object, expected []string) {
+			Expect(indexRef(getInfrastructureRef)(obj)).To(Equal(expected))
+		},
+		Entry("indexes the kind and name of the reference",
+			newCluster("capi-cluster", k8sclient.KindGCPCluster, "infra-cluster"),
+			[]string{"GCPCluster/infra-cluster"},
+		),
+		Entry("ignores clusters without reference",
+			newCluster("capi-cluster", "", ""),
+			nil,
+		),
+		Entry("ignores other objects",
+			newInfrastructure("infra-cluster"),
+			nil,
+		),
+	)
+
+	DescribeTable("refToCluster",
+		func(objects []client.Object, obj client.Object, expected []reconcile.Request) {
+			runtimeClient := newClient(objects...)
+			Expect(refToCluster(runtimeClient, indexInfrastructureRef, k8sclient.KindGCPCluster)(obj)).To(Equal(expected))
+		},
+		Entry("maps to the referencing cluster",
+			[]client.Object{
+				newCluster("capi-cluster", k8sclient.KindGCPCluster, "infra-cluster"),
+				newCluster("other-cluster", k8sclient.KindGCPCluster, "other-infra-cluster"),
+			},
+			newInfrastructure("infra-cluster"),
+			requestFor("capi-cluster"),
+		),
+		Entry("ignores clusters referencing another kind of the same name",
+			[]client.Object{newCluster("capi-cluster", k8sclient.KindGCPManagedCluster, "infra-cluster")},
+			newInfrastructure("infra-cluster"),
+			nil,
+		),
+		Entry("ignores objects without referencing cluster",
+			nil,
+			newInfrastructure("infra-cluster"),
+			nil,
+		),
+	)
+
+	DescribeTable("bastionToCluster",
+		func(objects []client.Object, obj client.Object, expected []reconcile.Request) {
+			runtimeClient := newClient(objects...)
+			Expect(bastionToCluster(runtimeClient)(obj)).To(Equal(expected))
+		},
+		Entry("maps to the cluster referencing the infrastructure cluster",
+			[]client.Object{newCluster("capi-cluster", k8sclient.KindGCPCluster, "infra-cluster")},
+			newBastion(k8sclient.BastionLabel("infra-cluster")),
+			requestFor("capi-cluster"),
+		),
+		Entry("ignores machines without bastion label",
+			[]client.Object{newCluster("capi-cluster", k8sclient.KindGCPCluster, "infra-cluster")},
+			newBastion(""),
+			nil,
+		),
+		Entry("ignores machines with another deployment label",
+			[]client.Object{newCluster("capi-cluster", k8sclient.KindGCPCluster, "infra-cluster")},
+			newBastion("infra-cluster-md-0"),
+			nil,
+		),
+		Entry("ignores bastions without referencing cluster",
+			[]client.Object{newCluster("infra-cluster", k8sclient.KindGCPCluster, "other-infra-cluster")},
+			newBastion(k8sclient.BastionLabel("infra-cluster")),
+			nil,
+		),
+	)
+
+	DescribeTable("bastionPredicate",
+		func(mutate func(*capg.GCPMachine), label string, expected bool) {
+			oldMachine := newBastion(label)
+			oldMachine.Annotations = map[string]string{k8sclient.AnnotationBastionIndex: "0"}
+			oldMachine.Status.Addresses = []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.1"},
+			}
+			newMachine := oldMachine.DeepCopy()
+			mutate(newMachine)
+
+			Expect(bastionPredicate().Update(event.UpdateEvent{
+				ObjectOld: oldMachine,
+				ObjectNew: newMachine,
+			})).To(Equal(expected))
+		},
+		Entry("accepts changed addresses",
+			func(machine *capg.GCPMachine) {
+				machine.Status.Addresses[0].Address = "203.0.113.2"
+			},
+			k8sclient.BastionLabel("infra-cluster"),
+			true,
+		),
+		Entry("accepts a changed index",
+			func(machine *capg.GCPMachine) {
+				machine.Annotations[k8sclient.AnnotationBastionIndex] = "1"
+			},
+			k8sclient.BastionLabel("infra-cluster"),
+			true,
+		),
+		Entry("accepts a deleted bastion",
+			func(machine *capg.GCPMachine) {
+				now := metav1.Now()
+				machine.DeletionTimestamp = &now
+			},
+			k8sclient.BastionLabel("infra-cluster"),
+			true,
+		),
+		Entry("ignores other changes",
+			func(machine *capg.GCPMachine) {
+				machine.Labels["other"] = "label"
+			},
+			k8sclient.BastionLabel("infra-cluster"),
+			false,
+		),
+		Entry("ignores changes of other machines",
+			func(machine *capg.GCPMachine) {
+				machine.Status.Addresses[0].Address = "203.0.113.2"
+			},
+			"infra-cluster-md-0",
+			false,
+		),
+	)
+})
+
+// indexedClient serves lists matching fields from the indexes, like the
+// client of the manager, which reads from its cache.
+type indexedClient struct {
+	client.Client
+	indexes map[string]client.IndexerFunc
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOptions := &client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+	fieldSelector := listOptions.FieldSelector
+	listOptions.FieldSelector = nil
+
+	err := c.Client.List(ctx, list, listOptions)
+	if err != nil || fieldSelector == nil {
+		return err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	var matching []runtime.Object
+	for _, item := range items {
+		obj := item.(client.Object)
+		matches := true
+		for _, requirement := range fieldSelector.Requirements() {
+			indexer, ok := c.indexes[requirement.Field]
+			if !ok {
+				return fmt.Errorf("no index for field %q", requirement.Field)
+			}
+			if !containsString(indexer(obj), requirement.Value) {
+				matches = false
+			}
+		}
+		if matches {
+			matching = append(matching, item)
+		}
+	}
+
+	return meta.SetList(list, matching)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
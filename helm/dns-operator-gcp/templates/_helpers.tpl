@@ -33,3 +33,15 @@ Selector labels
 app.kubernetes.io/name: {{ include "name" . | quote }}
 app.kubernetes.io/instance: {{ .Release.Name | quote }}
 {{- end -}}
+
+{{/*
+Whether the release runs the components serving the clusters of all shards.
+Defaults to false for shards with namespaces or a label selector.
+*/}}
+{{- define "shard.clusterWide" -}}
+{{- if kindIs "bool" .Values.shard.clusterWide -}}
+{{- .Values.shard.clusterWide -}}
+{{- else -}}
+{{- not (or .Values.shard.namespaces .Values.shard.labelSelector) -}}
+{{- end -}}
+{{- end -}}
@@ -18,6 +18,14 @@ room for such suffix.
 {{- include "resource.default.name" . -}}-psp
 {{- end -}}
 
+{{- define "resource.webhook.name" -}}
+{{- include "resource.default.name" . -}}-webhook
+{{- end -}}
+
+{{- define "resource.acmeSolver.name" -}}
+{{- include "resource.default.name" . -}}-acme-solver
+{{- end -}}
+
 {{- define "resource.default.namespace" -}}
 giantswarm
 {{- end -}}
@@ -17,14 +17,20 @@ limitations under the License.
 package main
 
 import (
-	"context"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"go.uber.org/zap/zapcore"
+	computev1 "google.golang.org/api/compute/v1"
 	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -32,12 +38,23 @@ import (
 	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/acmesolver"
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/compute"
+	"github.com/giantswarm/dns-operator-gcp/pkg/dnsverify"
+	"github.com/giantswarm/dns-operator-gcp/pkg/healthcheck"
 	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+	"github.com/giantswarm/dns-operator-gcp/pkg/ratelimit"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/shard"
+	"github.com/giantswarm/dns-operator-gcp/pkg/sweeper"
+	"github.com/giantswarm/dns-operator-gcp/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -61,6 +78,44 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var gcInterval time.Duration
+	var gcGracePeriod time.Duration
+	var gcReportOnly bool
+	var gcProjects string
+	var cloudDNSQPS float64
+	var cloudDNSBurst int
+	var maxConcurrentReconciles int
+	var enabledRegistrars string
+	var cleanupDisabledRegistrars bool
+	var managementCluster string
+	var cloudLogging bool
+	var forceZoneDeletion bool
+	var verifyDelegation bool
+	var verifyParentNameServers string
+	var verifyZoneNameServers string
+	var infrastructureKinds string
+	var apiInternalPrivateZone bool
+	var apiHealthCheck bool
+	var apiHealthCheckProbe string
+	var apiHealthCheckInterval time.Duration
+	var apiHealthCheckTimeout time.Duration
+	var apiHealthCheckFailureThreshold int
+	var apiHealthCheckSuccessThreshold int
+	var apiHealthCheckCAFile string
+	var apiHealthCheckTokenFile string
+	var enableWebhook bool
+	var auditSinkName string
+	var acmeSolver bool
+	var acmeSolverGroupName string
+	var acmeSolverCrossNamespace bool
+	var acmeSolverBindAddress string
+	var acmeSolverCertDir string
+	var auditFile string
+	var auditHeadInterval time.Duration
+	var shardName string
+	var shardNamespaces string
+	var shardLabelSelector string
+	var clusterWide bool
 	flag.StringVar(&gcpProject, "gcp-project", "",
 		"The gcp project id where the dns records will be created.")
 	flag.StringVar(&baseDomain, "base-domain", "",
@@ -74,6 +129,82 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&gcInterval, "gc-interval", time.Hour,
+		"The interval at which orphaned cluster zones and delegations are swept. Set to 0 to disable.")
+	flag.DurationVar(&gcGracePeriod, "gc-grace-period", time.Hour*24,
+		"The time a cluster zone or delegation has to be orphaned before it is deleted.")
+	flag.BoolVar(&gcReportOnly, "gc-report-only", true,
+		"Only report orphaned cluster zones and delegations instead of deleting them.")
+	flag.StringVar(&gcProjects, "gc-projects", "",
+		"Comma separated list of GCP projects swept for orphaned cluster zones in addition to the parent project and the projects of the existing clusters.")
+	flag.Float64Var(&cloudDNSQPS, "cloud-dns-qps", 5,
+		"The maximum number of Cloud DNS requests per second per GCP project.")
+	flag.IntVar(&cloudDNSBurst, "cloud-dns-burst", 10,
+		"The maximum burst of Cloud DNS requests per GCP project.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of clusters reconciled concurrently.")
+	flag.StringVar(&enabledRegistrars, "registrars", "zone,api,bastion,wildcard",
+		"Comma separated list of the registrars managing records of all clusters.")
+	flag.BoolVar(&cleanupDisabledRegistrars, "cleanup-disabled-registrars", false,
+		"Remove the records of registrars disabled on a cluster, unless the cluster overrides it with an annotation.")
+	flag.StringVar(&managementCluster, "management-cluster", "",
+		"The name of the management cluster, used to label the cluster zones it manages.")
+	flag.BoolVar(&cloudLogging, "cloud-logging", false,
+		"Log the queries of cluster zones to Cloud Logging, unless the cluster overrides it with an annotation.")
+	flag.BoolVar(&forceZoneDeletion, "force-zone-deletion", false,
+		"Delete cluster zones even if they contain records not managed by the operator, unless the cluster overrides it with an annotation.")
+	flag.BoolVar(&verifyDelegation, "verify-delegation", false,
+		"Verify that the delegation and the records of cluster zones resolve by querying their name servers.")
+	flag.StringVar(&verifyParentNameServers, "verify-parent-nameservers", "",
+		"Comma separated list of name server addresses queried for the delegation instead of the parent zone name servers.")
+	flag.StringVar(&verifyZoneNameServers, "verify-zone-nameservers", "",
+		"Comma separated list of name server addresses queried for the records instead of the cluster zone name servers.")
+	flag.StringVar(&infrastructureKinds, "infrastructure-kinds", k8sclient.KindGCPCluster,
+		"Comma separated list of the infrastructure cluster kinds whose clusters get DNS records, e.g. GCPCluster,GCPManagedCluster. GCPManagedCluster requires the CAPG GKE CRDs to be installed.")
+	flag.BoolVar(&apiInternalPrivateZone, "api-internal-private-zone", false,
+		"Publish the api-internal record in a private zone visible in the network of the cluster instead of the cluster zone.")
+	flag.BoolVar(&apiHealthCheck, "api-health-check", false,
+		"Probe the control plane endpoint of clusters with a backup endpoint and fail the api record over to the backup endpoint when it is unhealthy.")
+	flag.StringVar(&apiHealthCheckProbe, "api-health-check-probe", healthcheck.ProbeHTTPS,
+		"How api endpoints are probed, either \"https\" for the /readyz endpoint of the API server or \"tcp\" for accepting connections.")
+	flag.DurationVar(&apiHealthCheckInterval, "api-health-check-interval", time.Second*10,
+		"The interval at which api endpoints are probed.")
+	flag.DurationVar(&apiHealthCheckTimeout, "api-health-check-timeout", time.Second*5,
+		"The timeout of a single api endpoint probe.")
+	flag.IntVar(&apiHealthCheckFailureThreshold, "api-health-check-failure-threshold", 3,
+		"The number of consecutive failed probes of the control plane endpoint before failing over to the backup endpoint.")
+	flag.IntVar(&apiHealthCheckSuccessThreshold, "api-health-check-success-threshold", 3,
+		"The number of consecutive successful probes of the control plane endpoint before failing back to it.")
+	flag.StringVar(&apiHealthCheckCAFile, "api-health-check-ca-file", "",
+		"The PEM encoded CA bundle the serving certificates of the API servers are verified against by the https probe. The name in the certificate is not verified, as endpoints are probed by address. Certificates are not verified when empty.")
+	flag.StringVar(&apiHealthCheckTokenFile, "api-health-check-token-file", "",
+		"The file containing the bearer token the https probe authenticates with. The probe is anonymous when empty, which requires the API servers to allow anonymous requests to /readyz.")
+	flag.StringVar(&shardName, "shard-name", "",
+		"Name of the shard of clusters served by this deployment of the operator. Each shard runs its own leader election.")
+	flag.StringVar(&shardNamespaces, "shard-namespaces", "",
+		"Comma separated list of the namespaces of the CAPI clusters in the shard. All namespaces when empty.")
+	flag.StringVar(&shardLabelSelector, "shard-label-selector", "",
+		"Label selector of the CAPI clusters in the shard, e.g. \"dns-operator-gcp.giantswarm.io/shard=a\". All clusters when empty.")
+	flag.BoolVar(&clusterWide, "cluster-wide", true,
+		"Run the components serving the clusters of all shards, the sweeper and the ACME solver. Enable it in exactly one deployment. Defaults to false, when the shard has namespaces or a label selector.")
+	flag.StringVar(&auditSinkName, "audit-sink", "",
+		"Where to write the audit trail of record mutations, either \"stdout\" or \"file\". Auditing is disabled when empty.")
+	flag.StringVar(&auditFile, "audit-file", "/var/log/dns-operator-gcp/audit.jsonl",
+		"The file the audit trail is appended to with --audit-sink=file. The operator refuses to start, when the existing trail does not verify.")
+	flag.DurationVar(&auditHeadInterval, "audit-head-interval", time.Hour,
+		"The interval at which the hash of the last audit entry is logged, so that a rewritten trail can be detected. Set to 0 to disable.")
+	flag.BoolVar(&acmeSolver, "acme-solver", false,
+		"Serve a cert-manager DNS-01 webhook solver, which presents the challenges of certificates for cluster domains in the cluster zones.")
+	flag.StringVar(&acmeSolverGroupName, "acme-solver-group-name", "acme.dns-operator-gcp.giantswarm.io",
+		"The API group of the ACME solver, referenced as groupName in the webhook solver config of cert-manager issuers.")
+	flag.BoolVar(&acmeSolverCrossNamespace, "acme-solver-cross-namespace", false,
+		"Present the challenges of certificates for clusters in other namespaces than the one of the certificate.")
+	flag.StringVar(&acmeSolverBindAddress, "acme-solver-bind-address", ":8443",
+		"The address the ACME solver is served on.")
+	flag.StringVar(&acmeSolverCertDir, "acme-solver-cert-dir", "/tmp/acme-solver/serving-certs",
+		"The directory containing the tls.crt and tls.key of the ACME solver.")
+	flag.BoolVar(&enableWebhook, "webhook", false,
+		"Serve the validating webhook of clusters, which requires a serving certificate in the webhook server cert directory.")
 
 	opts := zap.Options{
 		Development: true,
@@ -84,46 +215,245 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	ctx := ctrl.SetupSignalHandler()
+
+	clusterShard, err := shard.New(shardName, splitList(shardNamespaces), shardLabelSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid shard")
+		os.Exit(1)
+	}
+
+	if !isFlagSet("cluster-wide") {
+		clusterWide = !clusterShard.Selective()
+	}
+	if acmeSolver && !clusterWide {
+		setupLog.Error(fmt.Errorf("--acme-solver requires --cluster-wide"), "invalid flags")
+		os.Exit(1)
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		NewCache:               clusterShard.NewCache(&capi.Cluster{}),
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "c6d2deb7.giantswarm.io",
+		LeaderElectionID:       clusterShard.LeaderElectionID("c6d2deb7.giantswarm.io"),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	service, err := clouddns.NewService(context.Background())
+	// The rate limiting transport wraps the authenticating transport, so
+	// that requests wait for the rate limit before an access token is
+	// attached, which could otherwise expire while waiting.
+	authTransport, err := htransport.NewTransport(ctx,
+		http.DefaultTransport,
+		option.WithScopes(clouddns.NdevClouddnsReadwriteScope),
+	)
+	if err != nil {
+		setupLog.Error(err, "failed to create Cloud DNS transport")
+		os.Exit(1)
+	}
+	transport := ratelimit.NewTransport(authTransport, cloudDNSQPS, cloudDNSBurst)
+
+	service, err := clouddns.NewService(ctx, option.WithHTTPClient(&http.Client{Transport: transport}))
 	if err != nil {
 		setupLog.Error(err, "failed to create Cloud DNS client")
 		os.Exit(1)
 	}
 
+	// auditSink is an interface, so that the registrars get a nil interface
+	// when auditing is disabled.
+	var auditSink audit.Sink
+	var auditLines *audit.JSONLines
+	var auditFileSink *audit.File
+	switch auditSinkName {
+	case "":
+	case audit.SinkStdout:
+		auditLines = audit.NewStdout()
+		auditSink = auditLines
+	case audit.SinkFile:
+		auditFileSink, err = audit.NewFile(ctx, auditFile)
+		if err != nil {
+			setupLog.Error(err, "failed to open audit file")
+			os.Exit(1)
+		}
+		auditLines = auditFileSink.JSONLines
+		auditSink = auditFileSink
+	default:
+		setupLog.Error(fmt.Errorf("unknown audit sink %q", auditSinkName), "invalid audit sink")
+		os.Exit(1)
+	}
+
+	if auditLines != nil && auditHeadInterval > 0 {
+		err = mgr.Add(audit.NewHeadLogger(auditLines, auditHeadInterval))
+		if err != nil {
+			setupLog.Error(err, "failed to setup audit head logger")
+			os.Exit(1)
+		}
+	}
+
 	runtimeClient := mgr.GetClient()
-	client := k8sclient.NewGCPCluster(runtimeClient)
+	kinds := splitList(infrastructureKinds)
+	client := k8sclient.NewClusters(runtimeClient, kinds, gcpProject, clusterShard)
+	// The sweeper and the ACME solver have to know the clusters of all
+	// shards, which the cache of a sharded operator does not contain.
+	allClustersClient := runtimeClient
+	if clusterShard.Selective() {
+		allClustersClient, err = crclient.New(mgr.GetConfig(), crclient.Options{
+			Scheme: mgr.GetScheme(),
+			Mapper: mgr.GetRESTMapper(),
+		})
+		if err != nil {
+			setupLog.Error(err, "failed to create uncached client")
+			os.Exit(1)
+		}
+	}
+	allClusters := k8sclient.NewClusters(allClustersClient, kinds, gcpProject, nil)
 	bastionsClient := k8sclient.NewBastions(runtimeClient, controllers.FinalizerDNS)
-	zoneRegistrar := registrar.NewZone(baseDomain, parentDNSZone, gcpProject, service)
-	apiRegistrar := registrar.NewAPI(baseDomain, service)
+	// The health checker is only set when enabled, so that the api registrar
+	// gets a nil interface otherwise.
+	var healthChecker registrar.HealthChecker
+	var healthEvents chan event.GenericEvent
+	if apiHealthCheck {
+		prober, err := healthcheck.NewProber(apiHealthCheckProbe, healthcheck.HTTPSProberConfig{
+			Timeout:   apiHealthCheckTimeout,
+			CAFile:    apiHealthCheckCAFile,
+			TokenFile: apiHealthCheckTokenFile,
+		})
+		if err != nil {
+			setupLog.Error(err, "invalid api health check probe")
+			os.Exit(1)
+		}
+
+		healthEvents = make(chan event.GenericEvent)
+		checker := healthcheck.New(healthcheck.Config{
+			Prober:           prober,
+			Interval:         apiHealthCheckInterval,
+			FailureThreshold: apiHealthCheckFailureThreshold,
+			SuccessThreshold: apiHealthCheckSuccessThreshold,
+			Recorder:         mgr.GetEventRecorderFor("dns-operator-gcp"),
+			Events:           healthEvents,
+			Client:           runtimeClient,
+		})
+		err = mgr.Add(checker)
+		if err != nil {
+			setupLog.Error(err, "failed to setup api health checker")
+			os.Exit(1)
+		}
+		healthChecker = checker
+	}
+
+	// The Compute Engine client is only needed to resolve the address of the
+	// internal load balancer, so that the operator doesn't need Compute
+	// Engine permissions otherwise.
+	var addressResolver registrar.AddressResolver
+	if containsValue(splitList(enabledRegistrars), registrar.NameAPIInternal) {
+		computeService, err := computev1.NewService(ctx, option.WithScopes(computev1.ComputeReadonlyScope))
+		if err != nil {
+			setupLog.Error(err, "failed to create Compute Engine client")
+			os.Exit(1)
+		}
+		addressResolver = compute.NewAddresses(computeService)
+	}
+
+	zoneRegistrar := registrar.NewZone(baseDomain, parentDNSZone, gcpProject, managementCluster, cloudLogging, forceZoneDeletion, service)
+	apiRegistrar := registrar.NewAPI(baseDomain, healthChecker, service)
+	apiInternalRegistrar := registrar.NewAPIInternal(baseDomain, managementCluster, apiInternalPrivateZone, addressResolver, service)
 	bastionRegistrar := registrar.NewBastion(baseDomain, bastionsClient, service)
 	wildcardRegistrar := registrar.NewWildcard(baseDomain, service)
-	registrars := []controllers.Registrar{
+	registrars, err := selectRegistrars(enabledRegistrars, []controllers.Registrar{
 		zoneRegistrar,
 		apiRegistrar,
+		apiInternalRegistrar,
 		bastionRegistrar,
 		wildcardRegistrar,
+	})
+	if err != nil {
+		setupLog.Error(err, "invalid registrars")
+		os.Exit(1)
+	}
+
+	var verifier controllers.DelegationVerifier
+	if verifyDelegation {
+		verifier = dnsverify.New(dnsverify.Config{
+			BaseDomain:        baseDomain,
+			ParentDNSZone:     parentDNSZone,
+			ParentGCPProject:  gcpProject,
+			ParentNameServers: splitList(verifyParentNameServers),
+			ZoneNameServers:   splitList(verifyZoneNameServers),
+		}, service)
 	}
-	controller := controllers.NewGCPClusterReconciler(client, registrars)
-	err = controller.SetupWithManager(mgr)
+
+	controller := controllers.NewClusterReconciler(client, registrars, verifier, cleanupDisabledRegistrars, auditSink)
+	err = controller.SetupWithManager(mgr, controllers.SetupOptions{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Events:                  healthEvents,
+		InfrastructureKinds:     kinds,
+		Shard:                   clusterShard,
+	})
 	if err != nil {
-		setupLog.Error(err, "failed to setup controller", "controller", "GCPCluster")
+		setupLog.Error(err, "failed to setup controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 
+	if gcInterval > 0 && clusterWide {
+		orphanSweeper := sweeper.New(sweeper.Config{
+			BaseDomain:        baseDomain,
+			ParentDNSZone:     parentDNSZone,
+			ParentGCPProject:  gcpProject,
+			ManagementCluster: managementCluster,
+			Projects:          splitList(gcProjects),
+			Interval:          gcInterval,
+			GracePeriod:       gcGracePeriod,
+			ReportOnly:        gcReportOnly,
+			ForceDeletion:     forceZoneDeletion,
+			AuditSink:         auditSink,
+		}, allClusters, service)
+		err = mgr.Add(orphanSweeper)
+		if err != nil {
+			setupLog.Error(err, "failed to setup sweeper")
+			os.Exit(1)
+		}
+	}
+
+	if acmeSolver {
+		solver := registrar.NewACMEChallenge(baseDomain, allClusters, acmeSolverCrossNamespace, auditSink, service)
+		solverServer := acmesolver.New(acmesolver.Config{
+			GroupName:   acmeSolverGroupName,
+			BindAddress: acmeSolverBindAddress,
+			CertDir:     acmeSolverCertDir,
+		}, solver, mgr.GetAPIReader())
+		err = mgr.Add(solverServer)
+		if err != nil {
+			setupLog.Error(err, "failed to setup acme solver")
+			os.Exit(1)
+		}
+	}
+
+	if enableWebhook {
+		validator := webhook.NewClusterValidator(webhook.Config{
+			BaseDomain:     baseDomain,
+			DefaultProject: gcpProject,
+			Registrars: []string{
+				zoneRegistrar.Name(),
+				apiRegistrar.Name(),
+				apiInternalRegistrar.Name(),
+				bastionRegistrar.Name(),
+				wildcardRegistrar.Name(),
+			},
+			EnabledRegistrars:   registrarNames(registrars),
+			InfrastructureKinds: kinds,
+		}, service)
+		err = validator.SetupWithManager(mgr)
+		if err != nil {
+			setupLog.Error(err, "failed to setup webhook")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -136,8 +466,83 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctx)
+	// The audit file is closed explicitly, as os.Exit skips deferred calls.
+	if auditFileSink != nil {
+		closeErr := auditFileSink.Close()
+		if closeErr != nil {
+			setupLog.Error(closeErr, "failed to close audit file")
+		}
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// selectRegistrars returns the registrars named in the comma separated list,
+// in the order of all registrars.
+func selectRegistrars(names string, all []controllers.Registrar) ([]controllers.Registrar, error) {
+	enabled := map[string]bool{}
+	for _, name := range splitList(names) {
+		enabled[name] = true
+	}
+
+	var registrars []controllers.Registrar
+	for _, registrar := range all {
+		if enabled[registrar.Name()] {
+			registrars = append(registrars, registrar)
+			delete(enabled, registrar.Name())
+		}
+	}
+
+	for name := range enabled {
+		return nil, fmt.Errorf("unknown registrar %q", name)
+	}
+
+	return registrars, nil
+}
+
+// registrarNames returns the names of the registrars. It is never nil, so
+// that no registrars enabled are told apart from an unset list.
+func registrarNames(registrars []controllers.Registrar) []string {
+	names := []string{}
+	for _, registrar := range registrars {
+		names = append(names, registrar.Name())
+	}
+
+	return names
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFlagSet returns whether the flag was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
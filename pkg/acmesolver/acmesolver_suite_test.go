@@ -0,0 +1,13 @@
+package acmesolver_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestACMESolver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ACME Solver Suite")
+}
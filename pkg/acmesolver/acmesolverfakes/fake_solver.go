@@ -0,0 +1,191 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package acmesolverfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/acmesolver"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+type FakeSolver struct {
+	CleanUpStub        func(context.Context, registrar.ACMEChallengeRequest) error
+	cleanUpMutex       sync.RWMutex
+	cleanUpArgsForCall []struct {
+		arg1 context.Context
+		arg2 registrar.ACMEChallengeRequest
+	}
+	cleanUpReturns struct {
+		result1 error
+	}
+	cleanUpReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PresentStub        func(context.Context, registrar.ACMEChallengeRequest) error
+	presentMutex       sync.RWMutex
+	presentArgsForCall []struct {
+		arg1 context.Context
+		arg2 registrar.ACMEChallengeRequest
+	}
+	presentReturns struct {
+		result1 error
+	}
+	presentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSolver) CleanUp(arg1 context.Context, arg2 registrar.ACMEChallengeRequest) error {
+	fake.cleanUpMutex.Lock()
+	ret, specificReturn := fake.cleanUpReturnsOnCall[len(fake.cleanUpArgsForCall)]
+	fake.cleanUpArgsForCall = append(fake.cleanUpArgsForCall, struct {
+		arg1 context.Context
+		arg2 registrar.ACMEChallengeRequest
+	}{arg1, arg2})
+	stub := fake.CleanUpStub
+	fakeReturns := fake.cleanUpReturns
+	fake.recordInvocation("CleanUp", []interface{}{arg1, arg2})
+	fake.cleanUpMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSolver) CleanUpCallCount() int {
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	return len(fake.cleanUpArgsForCall)
+}
+
+func (fake *FakeSolver) CleanUpCalls(stub func(context.Context, registrar.ACMEChallengeRequest) error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = stub
+}
+
+func (fake *FakeSolver) CleanUpArgsForCall(i int) (context.Context, registrar.ACMEChallengeRequest) {
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	argsForCall := fake.cleanUpArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSolver) CleanUpReturns(result1 error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = nil
+	fake.cleanUpReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSolver) CleanUpReturnsOnCall(i int, result1 error) {
+	fake.cleanUpMutex.Lock()
+	defer fake.cleanUpMutex.Unlock()
+	fake.CleanUpStub = nil
+	if fake.cleanUpReturnsOnCall == nil {
+		fake.cleanUpReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cleanUpReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSolver) Present(arg1 context.Context, arg2 registrar.ACMEChallengeRequest) error {
+	fake.presentMutex.Lock()
+	ret, specificReturn := fake.presentReturnsOnCall[len(fake.presentArgsForCall)]
+	fake.presentArgsForCall = append(fake.presentArgsForCall, struct {
+		arg1 context.Context
+		arg2 registrar.ACMEChallengeRequest
+	}{arg1, arg2})
+	stub := fake.PresentStub
+	fakeReturns := fake.presentReturns
+	fake.recordInvocation("Present", []interface{}{arg1, arg2})
+	fake.presentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeSolver) PresentCallCount() int {
+	fake.presentMutex.RLock()
+	defer fake.presentMutex.RUnlock()
+	return len(fake.presentArgsForCall)
+}
+
+func (fake *FakeSolver) PresentCalls(stub func(context.Context, registrar.ACMEChallengeRequest) error) {
+	fake.presentMutex.Lock()
+	defer fake.presentMutex.Unlock()
+	fake.PresentStub = stub
+}
+
+func (fake *FakeSolver) PresentArgsForCall(i int) (context.Context, registrar.ACMEChallengeRequest) {
+	fake.presentMutex.RLock()
+	defer fake.presentMutex.RUnlock()
+	argsForCall := fake.presentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSolver) PresentReturns(result1 error) {
+	fake.presentMutex.Lock()
+	defer fake.presentMutex.Unlock()
+	fake.PresentStub = nil
+	fake.presentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSolver) PresentReturnsOnCall(i int, result1 error) {
+	fake.presentMutex.Lock()
+	defer fake.presentMutex.Unlock()
+	fake.PresentStub = nil
+	if fake.presentReturnsOnCall == nil {
+		fake.presentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.presentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.cleanUpMutex.RLock()
+	defer fake.cleanUpMutex.RUnlock()
+	fake.presentMutex.RLock()
+	defer fake.presentMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ acmesolver.Solver = new(FakeSolver)
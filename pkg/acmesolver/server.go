@@ -0,0 +1,296 @@
+// Package acmesolver serves a cert-manager DNS-01 webhook solver, which
+// presents the challenges of ACME certificates in the cluster zones. The
+// solver is an aggregated API of the Kubernetes API server, which proxies the
+// requests of cert-manager after authorizing them. Requests are only accepted
+// from the API server, which authenticates with its front proxy client
+// certificate.
+package acmesolver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+const (
+	// SolverName is the solver name referenced in the webhook solver config
+	// of cert-manager issuers.
+	SolverName = "dns-operator-gcp"
+
+	apiVersion = "v1alpha1"
+
+	// The API server publishes the CA of its front proxy client certificate
+	// in this config map.
+	authenticationConfigMapNamespace = "kube-system"
+	authenticationConfigMapName      = "extension-apiserver-authentication"
+	requestHeaderClientCAKey         = "requestheader-client-ca-file"
+	requestHeaderAllowedNamesKey     = "requestheader-allowed-names"
+
+	shutdownTimeout = time.Second * 10
+)
+
+//counterfeiter:generate . Solver
+type Solver interface {
+	Present(context.Context, registrar.ACMEChallengeRequest) error
+	CleanUp(context.Context, registrar.ACMEChallengeRequest) error
+}
+
+type Config struct {
+	// GroupName is the API group of the solver, which is referenced in the
+	// webhook solver config of cert-manager issuers.
+	GroupName string
+	// BindAddress is the address the solver is served on with TLS.
+	BindAddress string
+	// CertDir contains the tls.crt and tls.key of the serving certificate.
+	CertDir string
+}
+
+// Server serves the solver API. It implements manager.Runnable.
+type Server struct {
+	config Config
+	solver Solver
+	// reader reads the front proxy client CA of the API server. It must not
+	// be cached, as the config map lives outside of the namespaces the
+	// operator watches.
+	reader client.Reader
+
+	allowedNames map[string]bool
+}
+
+func New(config Config, solver Solver, reader client.Reader) *Server {
+	return &Server{
+		config: config,
+		solver: solver,
+		reader: reader,
+	}
+}
+
+// NeedLeaderElection lets all replicas serve challenges.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	logger := s.getLogger(ctx)
+
+	clientCAs, err := s.loadClientCAs(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	watcher, err := certwatcher.New(filepath.Join(s.config.CertDir, "tls.crt"), filepath.Join(s.config.CertDir, "tls.key"))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	go func() {
+		err := watcher.Start(ctx)
+		if err != nil {
+			logger.Error(err, "Failed to watch serving certificate")
+		}
+	}()
+
+	server := &http.Server{
+		Addr:    s.config.BindAddress,
+		Handler: s.Handler(),
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: watcher.GetCertificate,
+			ClientAuth:     tls.RequireAndVerifyClientCert,
+			ClientCAs:      clientCAs,
+		},
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Serving ACME solver", "address", s.config.BindAddress, "group", s.config.GroupName)
+	err = server.ListenAndServeTLS("", "")
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return microerror.Mask(err)
+}
+
+// Handler returns the handler of the solver API. Clients must have been
+// authenticated by the TLS config of the server.
+func (s *Server) Handler() http.Handler {
+	groupVersion := fmt.Sprintf("/apis/%s/%s", s.config.GroupName, apiVersion)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(groupVersion, s.serveDiscovery)
+	mux.HandleFunc(groupVersion+"/"+SolverName, s.serveChallenge)
+	return s.authorize(mux)
+}
+
+// authorize only lets requests of the allowed front proxy client
+// certificates through. All certificates signed by the CA are allowed, when
+// the API server does not restrict the names.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.allowedNames) > 0 {
+			if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || !s.allowedNames[r.TLS.PeerCertificates[0].Subject.CommonName] {
+				http.Error(w, "client certificate is not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resources := &metav1.APIResourceList{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "APIResourceList",
+		},
+		GroupVersion: fmt.Sprintf("%s/%s", s.config.GroupName, apiVersion),
+		APIResources: []metav1.APIResource{
+			{
+				Name:         SolverName,
+				SingularName: SolverName,
+				Namespaced:   false,
+				Kind:         PayloadKind,
+				Verbs:        metav1.Verbs{"create"},
+			},
+		},
+	}
+	writeJSON(w, http.StatusOK, resources)
+}
+
+func (s *Server) serveChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload := &ChallengePayload{}
+	err := json.NewDecoder(r.Body).Decode(payload)
+	if err != nil || payload.Request == nil {
+		http.Error(w, "request must be a ChallengePayload with a request", http.StatusBadRequest)
+		return
+	}
+
+	request := payload.Request
+	ctx := r.Context()
+	logger := s.getLogger(ctx).WithValues("challenge", request.UID, "action", request.Action, "dnsName", request.DNSName)
+
+	fqdn := request.ResolvedFQDN
+	if fqdn == "" {
+		fqdn = fmt.Sprintf("%s.%s.", registrar.ACMEChallengeLabel, strings.TrimPrefix(request.DNSName, "*."))
+	}
+	challenge := registrar.ACMEChallengeRequest{
+		ID:        string(request.UID),
+		FQDN:      fqdn,
+		Key:       request.Key,
+		Namespace: request.ResourceNamespace,
+	}
+
+	ctx = log.IntoContext(ctx, logger)
+	switch request.Action {
+	case ActionPresent:
+		err = s.solver.Present(ctx, challenge)
+	case ActionCleanUp:
+		err = s.solver.CleanUp(ctx, challenge)
+	default:
+		err = fmt.Errorf("unknown challenge action %q", request.Action)
+	}
+
+	writeJSON(w, http.StatusOK, newResponse(request.UID, logger, err))
+}
+
+func newResponse(uid types.UID, logger logr.Logger, err error) *ChallengePayload {
+	response := &ChallengeResponse{
+		UID:     uid,
+		Success: err == nil,
+	}
+	if err != nil {
+		logger.Error(err, "Failed to solve challenge")
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonInternalError,
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return &ChallengePayload{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: PayloadAPIVersion,
+			Kind:       PayloadKind,
+		},
+		Response: response,
+	}
+}
+
+// loadClientCAs reads the CA of the front proxy client certificate of the API
+// server and the names it may use.
+func (s *Server) loadClientCAs(ctx context.Context) (*x509.CertPool, error) {
+	configMap := &corev1.ConfigMap{}
+	err := s.reader.Get(ctx, client.ObjectKey{Namespace: authenticationConfigMapNamespace, Name: authenticationConfigMapName}, configMap)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM([]byte(configMap.Data[requestHeaderClientCAKey])) {
+		return nil, microerror.Mask(fmt.Errorf("config map %s/%s does not contain a front proxy client CA", authenticationConfigMapNamespace, authenticationConfigMapName))
+	}
+
+	var allowedNames []string
+	if value := configMap.Data[requestHeaderAllowedNamesKey]; value != "" {
+		err = json.Unmarshal([]byte(value), &allowedNames)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	s.allowedNames = map[string]bool{}
+	for _, name := range allowedNames {
+		s.allowedNames[name] = true
+	}
+
+	return clientCAs, nil
+}
+
+func (s *Server) getLogger(ctx context.Context) logr.Logger {
+	logger := log.FromContext(ctx)
+	return logger.WithName("acme-solver")
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(value)
+}
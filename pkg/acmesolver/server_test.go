@@ -0,0 +1,142 @@
+package acmesolver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/acmesolver"
+	"github.com/giantswarm/dns-operator-gcp/pkg/acmesolver/acmesolverfakes"
+)
+
+var _ = Describe("Server", func() {
+	const groupVersionPath = "/apis/acme.example.com/v1alpha1"
+
+	var (
+		solver  *acmesolverfakes.FakeSolver
+		handler http.Handler
+
+		request  *acmesolver.ChallengeRequest
+		recorder *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		solver = new(acmesolverfakes.FakeSolver)
+		handler = acmesolver.New(acmesolver.Config{GroupName: "acme.example.com"}, solver, nil).Handler()
+
+		request = &acmesolver.ChallengeRequest{
+			UID:               "1234",
+			Action:            acmesolver.ActionPresent,
+			Type:              "dns-01",
+			DNSName:           "*.test-cluster.example.com",
+			Key:               "challenge-key",
+			ResourceNamespace: "org-test",
+			ResolvedFQDN:      "_acme-challenge.test-cluster.example.com.",
+		}
+		recorder = httptest.NewRecorder()
+	})
+
+	postChallenge := func() *acmesolver.ChallengeResponse {
+		body, err := json.Marshal(&acmesolver.ChallengePayload{
+			TypeMeta: metav1.TypeMeta{APIVersion: acmesolver.PayloadAPIVersion, Kind: acmesolver.PayloadKind},
+			Request:  request,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, groupVersionPath+"/"+acmesolver.SolverName, bytes.NewReader(body)))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		payload := &acmesolver.ChallengePayload{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), payload)).To(Succeed())
+		Expect(payload.Kind).To(Equal(acmesolver.PayloadKind))
+		Expect(payload.Response).NotTo(BeNil())
+		Expect(payload.Response.UID).To(BeEquivalentTo("1234"))
+		return payload.Response
+	}
+
+	It("serves the discovery of the solver", func() {
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, groupVersionPath, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		resources := &metav1.APIResourceList{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), resources)).To(Succeed())
+		Expect(resources.GroupVersion).To(Equal("acme.example.com/v1alpha1"))
+		Expect(resources.APIResources).To(HaveLen(1))
+		Expect(resources.APIResources[0].Name).To(Equal(acmesolver.SolverName))
+		Expect(resources.APIResources[0].Verbs).To(ConsistOf("create"))
+	})
+
+	It("presents challenges", func() {
+		response := postChallenge()
+		Expect(response.Success).To(BeTrue())
+
+		Expect(solver.PresentCallCount()).To(Equal(1))
+		_, challenge := solver.PresentArgsForCall(0)
+		Expect(challenge.ID).To(Equal("1234"))
+		Expect(challenge.FQDN).To(Equal("_acme-challenge.test-cluster.example.com."))
+		Expect(challenge.Key).To(Equal("challenge-key"))
+		Expect(challenge.Namespace).To(Equal("org-test"))
+	})
+
+	It("cleans up challenges", func() {
+		request.Action = acmesolver.ActionCleanUp
+
+		response := postChallenge()
+		Expect(response.Success).To(BeTrue())
+		Expect(solver.CleanUpCallCount()).To(Equal(1))
+		Expect(solver.PresentCallCount()).To(BeZero())
+	})
+
+	When("the fqdn was not resolved", func() {
+		BeforeEach(func() {
+			request.ResolvedFQDN = ""
+		})
+
+		It("derives it from the dns name", func() {
+			postChallenge()
+			_, challenge := solver.PresentArgsForCall(0)
+			Expect(challenge.FQDN).To(Equal("_acme-challenge.test-cluster.example.com."))
+		})
+	})
+
+	When("the solver fails", func() {
+		BeforeEach(func() {
+			solver.PresentReturns(errors.New("boom"))
+		})
+
+		It("reports the failure", func() {
+			response := postChallenge()
+			Expect(response.Success).To(BeFalse())
+			Expect(response.Result).NotTo(BeNil())
+			Expect(response.Result.Message).To(ContainSubstring("boom"))
+		})
+	})
+
+	When("the action is unknown", func() {
+		BeforeEach(func() {
+			request.Action = "Renew"
+		})
+
+		It("reports the failure", func() {
+			response := postChallenge()
+			Expect(response.Success).To(BeFalse())
+			Expect(response.Result.Message).To(ContainSubstring(`unknown challenge action "Renew"`))
+		})
+	})
+
+	It("rejects payloads without request", func() {
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, groupVersionPath+"/"+acmesolver.SolverName, bytes.NewReader([]byte(`{}`))))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("rejects other methods", func() {
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, groupVersionPath+"/"+acmesolver.SolverName, nil))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
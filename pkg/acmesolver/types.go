@@ -0,0 +1,58 @@
+package acmesolver
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// The types of the cert-manager webhook solver API. They mirror the types of
+// the webhook.acme.cert-manager.io/v1alpha1 API of cert-manager, which the
+// operator does not depend on.
+
+const (
+	PayloadAPIVersion = "webhook.acme.cert-manager.io/v1alpha1"
+	PayloadKind       = "ChallengePayload"
+)
+
+type ChallengeAction string
+
+const (
+	ActionPresent ChallengeAction = "Present"
+	ActionCleanUp ChallengeAction = "CleanUp"
+)
+
+// ChallengePayload is posted by cert-manager with the request of a challenge
+// and returned with the response.
+type ChallengePayload struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Request  *ChallengeRequest  `json:"request,omitempty"`
+	Response *ChallengeResponse `json:"response,omitempty"`
+}
+
+type ChallengeRequest struct {
+	UID    types.UID       `json:"uid"`
+	Action ChallengeAction `json:"action"`
+	Type   string          `json:"type"`
+	// DNSName is the name the certificate is requested for, e.g.
+	// *.test.example.com.
+	DNSName string `json:"dnsName"`
+	// Key is the value of the TXT record.
+	Key               string `json:"key"`
+	ResourceNamespace string `json:"resourceNamespace"`
+	// ResolvedFQDN is the fully qualified name of the TXT record, e.g.
+	// _acme-challenge.test.example.com.
+	ResolvedFQDN string `json:"resolvedFQDN,omitempty"`
+	ResolvedZone string `json:"resolvedZone,omitempty"`
+
+	AllowAmbientCredentials bool            `json:"allowAmbientCredentials"`
+	Config                  json.RawMessage `json:"config,omitempty"`
+}
+
+type ChallengeResponse struct {
+	UID     types.UID      `json:"uid"`
+	Success bool           `json:"success"`
+	Result  *metav1.Status `json:"status,omitempty"`
+}
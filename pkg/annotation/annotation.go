@@ -0,0 +1,117 @@
+// Package annotation contains the annotations users can set on clusters to
+// change how the operator manages the DNS records of the cluster. They can be
+// set on the CAPI cluster or its infrastructure cluster, e.g. the GCPCluster,
+// which takes precedence.
+package annotation
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// GCPProject is the GCP project the cluster zone is created in. It
+	// overrides the project of the infrastructure cluster and is required
+	// for infrastructure providers without a GCP project, unless the
+	// --gcp-project flag applies.
+	GCPProject = "dns-operator-gcp.giantswarm.io/gcp-project"
+
+	// DisabledRegistrars is a comma separated list of registrar names, which
+	// must not manage any records of the cluster, e.g. "bastion,wildcard".
+	// It can only disable registrars enabled with the --registrars flag,
+	// registrars disabled for all clusters can't be enabled per cluster.
+	DisabledRegistrars = "dns-operator-gcp.giantswarm.io/disabled-registrars"
+
+	// CleanupDisabledRegistrars controls whether the records, which disabled
+	// registrars created before, are removed. It overrides the
+	// --cleanup-disabled-registrars flag and must be "true" or "false".
+	CleanupDisabledRegistrars = "dns-operator-gcp.giantswarm.io/cleanup-disabled-registrars"
+
+	// ForceZoneDeletion allows deleting the cluster zone, even if it contains
+	// records which were not created by the operator. It overrides the
+	// --force-zone-deletion flag and must be "true" or "false".
+	ForceZoneDeletion = "dns-operator-gcp.giantswarm.io/force-zone-deletion"
+
+	// CloudLogging enables logging the queries of the cluster zone to Cloud
+	// Logging. It overrides the --cloud-logging flag and must be "true" or
+	// "false".
+	CloudLogging = "dns-operator-gcp.giantswarm.io/cloud-logging"
+
+	// DeletionPolicy controls what happens to the DNS of the cluster, when
+	// the cluster is deleted. It is one of DeletionPolicyDelete,
+	// DeletionPolicyRetain and DeletionPolicyOrphanRecords.
+	DeletionPolicy = "dns-operator-gcp.giantswarm.io/deletion-policy"
+
+	// APIRoutingPolicy publishes the api record as a routing policy record
+	// set instead of pointing it to the control plane endpoint. The value is
+	// a JSON encoded RoutingPolicy, e.g.
+	// {"type":"weighted","endpoints":[{"address":"1.2.3.4","weight":3},{"address":"5.6.7.8","weight":1}]}
+	// or
+	// {"type":"geo","endpoints":[{"address":"1.2.3.4","location":"europe-west1"},{"address":"5.6.7.8","location":"us-east1"}]}.
+	APIRoutingPolicy = "dns-operator-gcp.giantswarm.io/api-routing-policy"
+
+	// APIBackupEndpoint is the IPv4 address the api record fails over to,
+	// when the control plane endpoint is unhealthy. It is probed on the port
+	// of the control plane endpoint, unless given as address:port. It
+	// requires the --api-health-check flag and can't be combined with
+	// APIRoutingPolicy.
+	APIBackupEndpoint = "dns-operator-gcp.giantswarm.io/api-backup-endpoint"
+
+	// APIInternalEndpoint is the IPv4 address the api-internal record points
+	// to. It overrides the internal load balancer address of the
+	// infrastructure cluster.
+	APIInternalEndpoint = "dns-operator-gcp.giantswarm.io/api-internal-endpoint"
+)
+
+const (
+	// DeletionPolicyDelete deletes all records, the zone and its delegation.
+	// It is the default.
+	DeletionPolicyDelete = "Delete"
+	// DeletionPolicyRetain keeps the zone, its records and its delegation,
+	// so that a later cluster of the same name can adopt them.
+	DeletionPolicyRetain = "Retain"
+	// DeletionPolicyOrphanRecords keeps the zone and its records, but
+	// removes the delegation from the parent zone.
+	DeletionPolicyOrphanRecords = "OrphanRecords"
+)
+
+// GetDeletionPolicy returns the deletion policy of the cluster, which
+// defaults to DeletionPolicyDelete.
+func GetDeletionPolicy(annotations map[string]string) string {
+	policy, ok := annotations[DeletionPolicy]
+	if !ok || policy == "" {
+		return DeletionPolicyDelete
+	}
+
+	return policy
+}
+
+// GetList returns the trimmed, non empty values of a comma separated list
+// annotation.
+func GetList(annotations map[string]string, key string) []string {
+	var values []string
+	for _, value := range strings.Split(annotations[key], ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// GetBool returns the value of a boolean annotation and the given default,
+// when the annotation is missing or not a boolean.
+func GetBool(annotations map[string]string, key string, defaultValue bool) bool {
+	value, ok := annotations[key]
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return parsed
+}
@@ -0,0 +1,85 @@
+package annotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// RoutingPolicyWeighted distributes the queries between the endpoints
+	// according to their weight.
+	RoutingPolicyWeighted = "weighted"
+	// RoutingPolicyGeo answers the queries with the endpoints of the
+	// location closest to the client.
+	RoutingPolicyGeo = "geo"
+)
+
+// RoutingPolicy is the value of the APIRoutingPolicy annotation.
+type RoutingPolicy struct {
+	Type      string            `json:"type"`
+	Endpoints []RoutingEndpoint `json:"endpoints"`
+}
+
+// RoutingEndpoint is an IPv4 address the api record resolves to. Weight is
+// only used by weighted policies and Location, a GCP region, only by geo
+// policies.
+type RoutingEndpoint struct {
+	Address  string  `json:"address"`
+	Weight   float64 `json:"weight,omitempty"`
+	Location string  `json:"location,omitempty"`
+}
+
+// GetRoutingPolicy returns the validated routing policy of the annotation or
+// nil, when the annotation is missing.
+func GetRoutingPolicy(annotations map[string]string, key string) (*RoutingPolicy, error) {
+	value, ok := annotations[key]
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	policy := &RoutingPolicy{}
+	err := json.Unmarshal([]byte(value), policy)
+	if err != nil {
+		return nil, microerror.Mask(fmt.Errorf("annotation %s is not a valid routing policy: %w", key, err))
+	}
+
+	err = policy.validate()
+	if err != nil {
+		return nil, microerror.Mask(fmt.Errorf("annotation %s is not a valid routing policy: %w", key, err))
+	}
+
+	return policy, nil
+}
+
+func (p *RoutingPolicy) validate() error {
+	if p.Type != RoutingPolicyWeighted && p.Type != RoutingPolicyGeo {
+		return fmt.Errorf("type must be %q or %q, got %q", RoutingPolicyWeighted, RoutingPolicyGeo, p.Type)
+	}
+
+	if len(p.Endpoints) == 0 {
+		return fmt.Errorf("no endpoints given")
+	}
+
+	for _, endpoint := range p.Endpoints {
+		ip := net.ParseIP(endpoint.Address)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("endpoint address %q is not an IPv4 address", endpoint.Address)
+		}
+
+		switch p.Type {
+		case RoutingPolicyWeighted:
+			if endpoint.Weight < 0 {
+				return fmt.Errorf("endpoint %s has negative weight %g", endpoint.Address, endpoint.Weight)
+			}
+		case RoutingPolicyGeo:
+			if endpoint.Location == "" {
+				return fmt.Errorf("endpoint %s has no location", endpoint.Address)
+			}
+		}
+	}
+
+	return nil
+}
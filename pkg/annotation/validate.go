@@ -0,0 +1,90 @@
+package annotation
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// gcpProjectRegexp matches GCP project IDs, optionally scoped to a domain,
+// e.g. "my-project" or "example.com:my-project".
+var gcpProjectRegexp = regexp.MustCompile(`^([a-z0-9.-]+:)?[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
+// Validate returns the errors of the operator annotations, whose values can't
+// be used. Annotations the operator does not know are ignored.
+func Validate(annotations map[string]string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if value, ok := annotations[GCPProject]; ok && !gcpProjectRegexp.MatchString(value) {
+		errs = append(errs, field.Invalid(path.Key(GCPProject), value, "must be a GCP project ID"))
+	}
+
+	for _, key := range []string{CleanupDisabledRegistrars, ForceZoneDeletion, CloudLogging} {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseBool(value); err != nil {
+			errs = append(errs, field.Invalid(path.Key(key), value, `must be "true" or "false"`))
+		}
+	}
+
+	if value, ok := annotations[DeletionPolicy]; ok {
+		switch value {
+		case DeletionPolicyDelete, DeletionPolicyRetain, DeletionPolicyOrphanRecords:
+		default:
+			errs = append(errs, field.NotSupported(path.Key(DeletionPolicy), value,
+				[]string{DeletionPolicyDelete, DeletionPolicyRetain, DeletionPolicyOrphanRecords}))
+		}
+	}
+
+	policy, err := GetRoutingPolicy(annotations, APIRoutingPolicy)
+	if err != nil {
+		errs = append(errs, field.Invalid(path.Key(APIRoutingPolicy), annotations[APIRoutingPolicy], err.Error()))
+	}
+
+	if value, ok := annotations[APIBackupEndpoint]; ok {
+		if err := validateEndpoint(value); err != nil {
+			errs = append(errs, field.Invalid(path.Key(APIBackupEndpoint), value, err.Error()))
+		}
+		if policy != nil {
+			errs = append(errs, field.Forbidden(path.Key(APIBackupEndpoint), fmt.Sprintf("can't be combined with %s", APIRoutingPolicy)))
+		}
+	}
+
+	if value, ok := annotations[APIInternalEndpoint]; ok && !isIPv4(value) {
+		errs = append(errs, field.Invalid(path.Key(APIInternalEndpoint), value, "must be an IPv4 address"))
+	}
+
+	return errs
+}
+
+// validateEndpoint checks that the endpoint is an IPv4 address with an
+// optional port.
+func validateEndpoint(endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host, port = endpoint, ""
+	}
+
+	if !isIPv4(host) {
+		return fmt.Errorf("must be an IPv4 address with an optional port")
+	}
+
+	if port != "" {
+		number, err := strconv.Atoi(port)
+		if err != nil || number < 1 || number > 65535 {
+			return fmt.Errorf("port %q must be between 1 and 65535", port)
+		}
+	}
+
+	return nil
+}
+
+func isIPv4(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() != nil
+}
@@ -0,0 +1,120 @@
+// Package audit keeps a trail of the DNS records the operator created,
+// changed or deleted. The trail is written to a Sink separately from the
+// regular log output. Entries are chained by their hashes, so that removed or
+// modified entries can be detected with Verify.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entry describes a single mutation of a record set.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Action    Action    `json:"action"`
+	Project   string    `json:"project"`
+	Zone      string    `json:"zone"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	// Before is the data of the record set before it was updated or
+	// deleted.
+	Before []string `json:"before,omitempty"`
+	// After is the data of the record set after it was created or updated.
+	After []string `json:"after,omitempty"`
+	// Cluster is the namespaced name of the CAPI cluster the record belongs
+	// to. It is empty for records deleted by the sweeper.
+	Cluster string `json:"cluster,omitempty"`
+	// ReconcileID identifies the reconciliation or sweep which mutated the
+	// record. It is logged alongside the regular log output.
+	ReconcileID string `json:"reconcileID,omitempty"`
+
+	// PreviousHash is the hash of the previous entry of the trail. It is
+	// empty for the first entry.
+	PreviousHash string `json:"previousHash"`
+	// Hash is the hash of the entry including the hash of the previous
+	// entry.
+	Hash string `json:"hash"`
+}
+
+// Sink persists the entries of the trail.
+type Sink interface {
+	Write(Entry) error
+}
+
+// computeHash returns the hash of the entry without its own hash.
+func computeHash(entry Entry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Recorder writes the entries of a single reconciliation or sweep to the
+// sink. The nil Recorder discards all entries.
+type Recorder struct {
+	sink        Sink
+	cluster     string
+	reconcileID string
+	now         func() time.Time
+}
+
+func NewRecorder(sink Sink, cluster, reconcileID string) *Recorder {
+	return &Recorder{
+		sink:        sink,
+		cluster:     cluster,
+		reconcileID: reconcileID,
+		now:         time.Now,
+	}
+}
+
+// Record writes the entry with the cluster and reconcile ID of the recorder.
+// Failing to write the entry must not fail the mutation, which already
+// happened, so errors are only logged.
+func (r *Recorder) Record(ctx context.Context, entry Entry) {
+	if r == nil {
+		return
+	}
+
+	entry.Timestamp = r.now().UTC()
+	entry.Cluster = r.cluster
+	entry.ReconcileID = r.reconcileID
+
+	err := r.sink.Write(entry)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to write audit entry", "record", entry.Name, "type", entry.Type, "action", entry.Action)
+	}
+}
+
+type recorderKey struct{}
+
+// IntoContext returns a context carrying the recorder, which registrars get
+// with FromContext.
+func IntoContext(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// FromContext returns the recorder of the context. It is nil, when auditing
+// is disabled.
+func FromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
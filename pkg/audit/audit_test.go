@@ -0,0 +1,187 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+)
+
+type failingSink struct{}
+
+func (failingSink) Write(audit.Entry) error {
+	return errors.New("boom")
+}
+
+var _ = Describe("Audit", func() {
+	var (
+		ctx    context.Context
+		buffer *bytes.Buffer
+		sink   *audit.JSONLines
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		buffer = &bytes.Buffer{}
+		sink = audit.NewJSONLines(buffer, "")
+	})
+
+	readEntries := func(data string) []audit.Entry {
+		var entries []audit.Entry
+		for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+			var entry audit.Entry
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	Describe("Recorder", func() {
+		It("writes entries with the cluster and reconcile id", func() {
+			recorder := audit.NewRecorder(sink, "org-test/test-cluster", "1234")
+			ctx = audit.IntoContext(ctx, recorder)
+
+			audit.FromContext(ctx).Record(ctx, audit.Entry{
+				Action:  audit.ActionUpdate,
+				Project: "test-project",
+				Zone:    "test-cluster",
+				Name:    "api.test-cluster.example.com.",
+				Type:    "A",
+				Before:  []string{"1.2.3.4"},
+				After:   []string{"5.6.7.8"},
+			})
+
+			entries := readEntries(buffer.String())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Action).To(Equal(audit.ActionUpdate))
+			Expect(entries[0].Cluster).To(Equal("org-test/test-cluster"))
+			Expect(entries[0].ReconcileID).To(Equal("1234"))
+			Expect(entries[0].Before).To(ConsistOf("1.2.3.4"))
+			Expect(entries[0].After).To(ConsistOf("5.6.7.8"))
+			Expect(entries[0].Timestamp).NotTo(BeZero())
+		})
+
+		It("discards entries without recorder", func() {
+			Expect(audit.FromContext(ctx)).To(BeNil())
+			audit.FromContext(ctx).Record(ctx, audit.Entry{Action: audit.ActionCreate})
+		})
+
+		It("does not fail when the sink fails", func() {
+			audit.NewRecorder(failingSink{}, "", "").Record(ctx, audit.Entry{Action: audit.ActionCreate})
+		})
+	})
+
+	Describe("JSONLines", func() {
+		BeforeEach(func() {
+			for _, name := range []string{"a", "b", "c"} {
+				Expect(sink.Write(audit.Entry{Action: audit.ActionCreate, Name: name})).To(Succeed())
+			}
+		})
+
+		It("chains the entries", func() {
+			entries := readEntries(buffer.String())
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].PreviousHash).To(BeEmpty())
+			Expect(entries[1].PreviousHash).To(Equal(entries[0].Hash))
+			Expect(entries[2].PreviousHash).To(Equal(entries[1].Hash))
+
+			lastHash, err := audit.Verify(strings.NewReader(buffer.String()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lastHash).To(Equal(entries[2].Hash))
+		})
+
+		It("detects modified entries", func() {
+			trail := strings.Replace(buffer.String(), `"name":"b"`, `"name":"x"`, 1)
+			_, err := audit.Verify(strings.NewReader(trail))
+			Expect(err).To(MatchError(ContainSubstring("line 2: hash")))
+		})
+
+		It("returns the hash of the last entry as head", func() {
+			entries := readEntries(buffer.String())
+			Expect(sink.Head()).To(Equal(entries[2].Hash))
+		})
+
+		It("detects removed entries", func() {
+			lines := strings.Split(buffer.String(), "\n")
+			trail := strings.Join(append(lines[:1], lines[2:]...), "\n")
+			_, err := audit.Verify(strings.NewReader(trail))
+			Expect(err).To(MatchError(ContainSubstring("line 2: previous hash")))
+		})
+	})
+
+	Describe("File", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+		})
+
+		It("continues the chain of an existing trail", func() {
+			file, err := audit.NewFile(ctx, path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Write(audit.Entry{Action: audit.ActionCreate, Name: "a"})).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			file, err = audit.NewFile(ctx, path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Write(audit.Entry{Action: audit.ActionDelete, Name: "a"})).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			entries := readEntries(string(data))
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[1].PreviousHash).To(Equal(entries[0].Hash))
+		})
+
+		When("the last line is partial", func() {
+			var partial string
+
+			BeforeEach(func() {
+				file, err := audit.NewFile(ctx, path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(file.Write(audit.Entry{Action: audit.ActionCreate, Name: "a"})).To(Succeed())
+				Expect(file.Close()).To(Succeed())
+
+				partial = `{"action":"create","name":"b","previous`
+				trail, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = trail.WriteString(partial)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(trail.Close()).To(Succeed())
+			})
+
+			It("moves the line to the partial file and continues the chain", func() {
+				file, err := audit.NewFile(ctx, path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(file.Write(audit.Entry{Action: audit.ActionDelete, Name: "a"})).To(Succeed())
+				Expect(file.Close()).To(Succeed())
+
+				data, err := os.ReadFile(path)
+				Expect(err).NotTo(HaveOccurred())
+				entries := readEntries(string(data))
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[1].PreviousHash).To(Equal(entries[0].Hash))
+
+				data, err = os.ReadFile(path + ".partial")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(Equal(partial + "\n"))
+			})
+		})
+
+		It("refuses to continue a corrupt trail", func() {
+			Expect(os.WriteFile(path, []byte(`{"action":"create","hash":"1234"}`+"\n"), 0600)).To(Succeed())
+
+			_, err := audit.NewFile(ctx, path)
+			Expect(err).To(MatchError(ContainSubstring("is corrupt")))
+		})
+	})
+})
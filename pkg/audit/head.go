@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// HeadLogger periodically logs the hash of the last entry of the trail. The
+// chain has no anchor outside of the trail: whoever can write the trail can
+// also rewrite it consistently from a modified entry on. The logged hashes
+// are shipped with the regular log output, so that a rewritten trail no
+// longer matches them.
+type HeadLogger struct {
+	sink     *JSONLines
+	interval time.Duration
+}
+
+func NewHeadLogger(sink *JSONLines, interval time.Duration) *HeadLogger {
+	return &HeadLogger{
+		sink:     sink,
+		interval: interval,
+	}
+}
+
+// Start logs the hash until the context is cancelled and once more before
+// returning. It implements manager.Runnable.
+func (l *HeadLogger) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("audit")
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Audit trail head", "hash", l.sink.Head())
+			return nil
+		case <-ticker.C:
+			logger.Info("Audit trail head", "hash", l.sink.Head())
+		}
+	}
+}
+
+// NeedLeaderElection is false, as every replica writes its own trail.
+func (l *HeadLogger) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,252 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+
+	// partialSuffix is appended to the path of the trail to get the file
+	// partial last lines are moved to.
+	partialSuffix = ".partial"
+
+	tailChunkSize = 4096
+)
+
+// JSONLines writes the entries as JSON lines and chains them by their hashes.
+type JSONLines struct {
+	mutex    sync.Mutex
+	writer   io.Writer
+	lastHash string
+}
+
+// NewJSONLines returns a sink writing to the writer. The chain continues
+// from lastHash, which is empty for a new trail.
+func NewJSONLines(writer io.Writer, lastHash string) *JSONLines {
+	return &JSONLines{
+		writer:   writer,
+		lastHash: lastHash,
+	}
+}
+
+// NewStdout returns a sink writing to stdout. The regular log output is
+// written to stderr.
+func NewStdout() *JSONLines {
+	return NewJSONLines(os.Stdout, "")
+}
+
+func (s *JSONLines) Write(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry.PreviousHash = s.lastHash
+	hash, err := computeHash(entry)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	entry.Hash = hash
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = s.writer.Write(append(data, '\n'))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.lastHash = hash
+	return nil
+}
+
+// Head returns the hash of the last entry written, which vouches for the
+// whole trail.
+func (s *JSONLines) Head() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.lastHash
+}
+
+// File is a JSON lines sink appending to a file. The chain continues from
+// the last entry of an existing file, so the trail survives restarts.
+type File struct {
+	*JSONLines
+	file *os.File
+}
+
+// NewFile opens the trail in the file. A partial last line, left behind when
+// the operator was killed while writing an entry, is moved to the file of
+// the same path with the .partial suffix, so that the trail verifies again.
+func NewFile(ctx context.Context, path string) (*File, error) {
+	err := quarantinePartialLine(ctx, path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	lastHash, err := readLastHash(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &File{
+		JSONLines: NewJSONLines(file, lastHash),
+		file:      file,
+	}, nil
+}
+
+func (s *File) Close() error {
+	return s.file.Close()
+}
+
+// Verify reads a trail of JSON lines and checks that every entry matches its
+// hash and references the hash of the previous entry. It returns the hash of
+// the last entry.
+func Verify(reader io.Reader) (string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lastHash := ""
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return "", microerror.Mask(fmt.Errorf("line %d: %w", line, err))
+		}
+
+		if entry.PreviousHash != lastHash {
+			return "", microerror.Mask(fmt.Errorf("line %d: previous hash %q does not match hash %q of the previous entry", line, entry.PreviousHash, lastHash))
+		}
+
+		hash, err := computeHash(entry)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		if entry.Hash != hash {
+			return "", microerror.Mask(fmt.Errorf("line %d: hash %q does not match the entry", line, entry.Hash))
+		}
+
+		lastHash = entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return lastHash, nil
+}
+
+// readLastHash verifies the existing trail of the file and returns the hash
+// of its last entry. A trail which does not verify is not continued, as the
+// new entries would seem to vouch for it.
+func readLastHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+	defer file.Close()
+
+	lastHash, err := Verify(file)
+	if err != nil {
+		return "", microerror.Mask(fmt.Errorf("audit trail %s is corrupt: %w", path, err))
+	}
+
+	return lastHash, nil
+}
+
+// quarantinePartialLine moves the last line of the file to the partial file,
+// when it is not terminated by a newline, and truncates the file to the
+// last complete line.
+func quarantinePartialLine(ctx context.Context, path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// Read the file backwards in chunks until the last newline is found.
+	end := info.Size()
+	offset := end
+	var tail []byte
+	for offset > 0 {
+		size := int64(tailChunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+
+		chunk := make([]byte, size)
+		_, err = file.ReadAt(chunk, offset)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		index := bytes.LastIndexByte(chunk, '\n')
+		if index >= 0 {
+			tail = append(chunk[index+1:], tail...)
+			offset += int64(index) + 1
+			break
+		}
+		tail = append(chunk, tail...)
+	}
+
+	if len(tail) == 0 {
+		return nil
+	}
+
+	partialPath := path + partialSuffix
+	partialFile, err := os.OpenFile(partialPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	_, err = partialFile.Write(append(tail, '\n'))
+	if err != nil {
+		_ = partialFile.Close()
+		return microerror.Mask(err)
+	}
+	err = partialFile.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = file.Truncate(offset)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger := log.FromContext(ctx).WithName("audit")
+	logger.Info("Warning: moved partial last line of the audit trail, the operator was probably killed while writing it", "file", path, "partialFile", partialPath, "bytes", len(tail))
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package clusterview provides a provider neutral view of the clusters the
+// operator manages DNS records for, so that the registrars don't depend on
+// the infrastructure provider of the cluster.
+package clusterview
+
+import (
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+)
+
+// Cluster is a CAPI cluster together with its infrastructure cluster, e.g. a
+// GCPCluster.
+type Cluster struct {
+	// Name is the name of the infrastructure cluster. The cluster zone and
+	// its records are named after it.
+	Name      string
+	Namespace string
+	// Annotations are the annotations of the CAPI cluster, overridden by
+	// the ones of the infrastructure cluster.
+	Annotations map[string]string
+
+	// Project is the GCP project the cluster zone is created in.
+	Project string
+	Region  string
+	Network string
+	// ControlPlaneEndpoint is the endpoint of the Kubernetes API, which the
+	// api record points to. It is empty until the control plane has been
+	// provisioned.
+	ControlPlaneEndpoint capi.APIEndpoint
+	// InternalAPIAddress is the address of the internal load balancer of
+	// the Kubernetes API, which the api-internal record points to. It is
+	// either an IP address or the self link of a Compute Engine address and
+	// empty, when the cluster has no internal load balancer.
+	InternalAPIAddress string
+
+	// Cluster is the CAPI cluster. Conditions are set and events are
+	// recorded on it.
+	Cluster *capi.Cluster
+	// Infrastructure is the infrastructure cluster referenced by the CAPI
+	// cluster. It carries the finalizer of the operator.
+	Infrastructure *unstructured.Unstructured
+}
+
+// New returns the view of the CAPI cluster and its infrastructure cluster.
+// The control plane is optional and only used for its endpoint, e.g. of a
+// GKE GCPManagedControlPlane. Infrastructure providers store their fields in
+// different places, so the fields are read from the first place they are set
+// in:
+//
+//   - Project: the annotation.GCPProject annotation, spec.project of the
+//     infrastructure cluster, the default project.
+//   - ControlPlaneEndpoint: spec.endpoint of the control plane,
+//     spec.controlPlaneEndpoint of the infrastructure cluster and of the CAPI
+//     cluster.
+//   - InternalAPIAddress: the annotation.APIInternalEndpoint annotation,
+//     status.network.apiInternalIpAddress of the infrastructure cluster,
+//     which CAPG sets to the self link of the address.
+//   - Region and Network: spec.region and spec.network.name of the
+//     infrastructure cluster.
+func New(cluster *capi.Cluster, infrastructure, controlPlane *unstructured.Unstructured, defaultProject string) (*Cluster, error) {
+	view := &Cluster{
+		Name:           infrastructure.GetName(),
+		Namespace:      infrastructure.GetNamespace(),
+		Annotations:    map[string]string{},
+		Cluster:        cluster,
+		Infrastructure: infrastructure,
+	}
+
+	for key, value := range cluster.Annotations {
+		view.Annotations[key] = value
+	}
+	for key, value := range infrastructure.GetAnnotations() {
+		view.Annotations[key] = value
+	}
+
+	var err error
+	view.Project, err = nestedString(infrastructure, "spec", "project")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if project := view.Annotations[annotation.GCPProject]; project != "" {
+		view.Project = project
+	}
+	if view.Project == "" {
+		view.Project = defaultProject
+	}
+
+	view.Region, err = nestedString(infrastructure, "spec", "region")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	view.Network, err = nestedString(infrastructure, "spec", "network", "name")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if controlPlane != nil {
+		err = fromUnstructuredField(controlPlane, &view.ControlPlaneEndpoint, "spec", "endpoint")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if view.ControlPlaneEndpoint.Host == "" {
+		err = fromUnstructuredField(infrastructure, &view.ControlPlaneEndpoint, "spec", "controlPlaneEndpoint")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+	if view.ControlPlaneEndpoint.Host == "" {
+		view.ControlPlaneEndpoint = cluster.Spec.ControlPlaneEndpoint
+	}
+
+	view.InternalAPIAddress, err = nestedString(infrastructure, "status", "network", "apiInternalIpAddress")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if address := view.Annotations[annotation.APIInternalEndpoint]; address != "" {
+		view.InternalAPIAddress = address
+	}
+
+	return view, nil
+}
+
+// IsDeleted returns whether the infrastructure cluster is being deleted.
+func (c *Cluster) IsDeleted() bool {
+	return !c.Infrastructure.GetDeletionTimestamp().IsZero()
+}
+
+func nestedString(obj *unstructured.Unstructured, fields ...string) (string, error) {
+	value, _, err := unstructured.NestedString(obj.Object, fields...)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return value, nil
+}
+
+// fromUnstructuredField converts the nested field of the object, if it is
+// set.
+func fromUnstructuredField(obj *unstructured.Unstructured, into interface{}, fields ...string) error {
+	value, ok, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if !ok {
+		return nil
+	}
+
+	return microerror.Mask(runtime.DefaultUnstructuredConverter.FromUnstructured(value, into))
+}
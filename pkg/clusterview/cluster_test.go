@@ -0,0 +1,158 @@
+package clusterview_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+var _ = Describe("Cluster", func() {
+	var (
+		cluster        *capi.Cluster
+		infrastructure *unstructured.Unstructured
+		controlPlane   *unstructured.Unstructured
+
+		view *clusterview.Cluster
+		err  error
+	)
+
+	BeforeEach(func() {
+		cluster = &capi.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: "test",
+				Annotations: map[string]string{
+					annotation.CloudLogging:      "true",
+					annotation.ForceZoneDeletion: "true",
+				},
+			},
+			Spec: capi.ClusterSpec{
+				ControlPlaneEndpoint: capi.APIEndpoint{Host: "10.0.0.1", Port: 6443},
+			},
+		}
+
+		infrastructure = &unstructured.Unstructured{}
+		data, err := os.ReadFile("testdata/gcpcluster.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		data, err = yaml.YAMLToJSON(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(infrastructure.UnmarshalJSON(data)).To(Succeed())
+
+		controlPlane = nil
+	})
+
+	JustBeforeEach(func() {
+		view, err = clusterview.New(cluster, infrastructure, controlPlane, "default-project")
+	})
+
+	It("reads the fields of the infrastructure cluster", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(view.Name).To(Equal("test-cluster-infra"))
+		Expect(view.Namespace).To(Equal("test"))
+		Expect(view.Project).To(Equal("test-project"))
+		Expect(view.Region).To(Equal("europe-west1"))
+		Expect(view.Network).To(Equal("test-network"))
+		Expect(view.ControlPlaneEndpoint).To(Equal(capi.APIEndpoint{Host: "1.2.3.4", Port: 443}))
+		Expect(view.InternalAPIAddress).To(Equal("https://www.googleapis.com/compute/v1/projects/test-project/regions/europe-west1/addresses/test-cluster-infra-api-internal"))
+		Expect(view.Cluster).To(Equal(cluster))
+		Expect(view.Infrastructure).To(Equal(infrastructure))
+	})
+
+	It("merges the annotations of both clusters", func() {
+		Expect(view.Annotations).To(Equal(map[string]string{
+			annotation.CloudLogging:      "false",
+			annotation.ForceZoneDeletion: "true",
+		}))
+	})
+
+	It("is not deleted", func() {
+		Expect(view.IsDeleted()).To(BeFalse())
+	})
+
+	When("the project is set with an annotation", func() {
+		BeforeEach(func() {
+			cluster.Annotations[annotation.GCPProject] = "other-project"
+		})
+
+		It("uses the project of the annotation", func() {
+			Expect(view.Project).To(Equal("other-project"))
+		})
+	})
+
+	When("the internal api endpoint is set with an annotation", func() {
+		BeforeEach(func() {
+			cluster.Annotations[annotation.APIInternalEndpoint] = "10.0.0.2"
+		})
+
+		It("uses the address of the annotation", func() {
+			Expect(view.InternalAPIAddress).To(Equal("10.0.0.2"))
+		})
+	})
+
+	When("the infrastructure cluster does not have a project", func() {
+		BeforeEach(func() {
+			unstructured.RemoveNestedField(infrastructure.Object, "spec", "project")
+		})
+
+		It("uses the default project", func() {
+			Expect(view.Project).To(Equal("default-project"))
+		})
+	})
+
+	When("the infrastructure cluster does not have an endpoint", func() {
+		BeforeEach(func() {
+			unstructured.RemoveNestedField(infrastructure.Object, "spec", "controlPlaneEndpoint")
+		})
+
+		It("uses the endpoint of the CAPI cluster", func() {
+			Expect(view.ControlPlaneEndpoint).To(Equal(capi.APIEndpoint{Host: "10.0.0.1", Port: 6443}))
+		})
+	})
+
+	When("the control plane has an endpoint", func() {
+		BeforeEach(func() {
+			controlPlane = &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"endpoint": map[string]interface{}{
+						"host": "5.6.7.8",
+						"port": int64(443),
+					},
+				},
+			}}
+		})
+
+		It("uses the endpoint of the control plane", func() {
+			Expect(view.ControlPlaneEndpoint).To(Equal(capi.APIEndpoint{Host: "5.6.7.8", Port: 443}))
+		})
+	})
+
+	When("a field has an unexpected type", func() {
+		BeforeEach(func() {
+			Expect(unstructured.SetNestedField(infrastructure.Object, int64(1), "spec", "region")).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(view).To(BeNil())
+		})
+	})
+
+	When("the infrastructure cluster is being deleted", func() {
+		BeforeEach(func() {
+			now := metav1.Now()
+			infrastructure.SetDeletionTimestamp(&now)
+		})
+
+		It("is deleted", func() {
+			Expect(view.IsDeleted()).To(BeTrue())
+		})
+	})
+})
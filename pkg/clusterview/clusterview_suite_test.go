@@ -0,0 +1,13 @@
+package clusterview_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestClusterview(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Clusterview Suite")
+}
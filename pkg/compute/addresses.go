@@ -0,0 +1,63 @@
+// Package compute resolves Compute Engine resources referenced by the
+// infrastructure clusters, e.g. the addresses of their load balancers.
+package compute
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	computev1 "google.golang.org/api/compute/v1"
+)
+
+type Addresses struct {
+	computeService *computev1.Service
+}
+
+func NewAddresses(computeService *computev1.Service) *Addresses {
+	return &Addresses{
+		computeService: computeService,
+	}
+}
+
+// ResolveAddress returns the IP address of the regional or global Compute
+// Engine address with the given self link, e.g.
+// https://www.googleapis.com/compute/v1/projects/my-project/regions/europe-west1/addresses/my-address.
+func (a *Addresses) ResolveAddress(ctx context.Context, selfLink string) (string, error) {
+	project, region, name, err := parseAddressSelfLink(selfLink)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	var address *computev1.Address
+	if region == "" {
+		address, err = a.computeService.GlobalAddresses.Get(project, name).Context(ctx).Do()
+	} else {
+		address, err = a.computeService.Addresses.Get(project, region, name).Context(ctx).Do()
+	}
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return address.Address, nil
+}
+
+// parseAddressSelfLink returns the project, region and name of the address.
+// The region is empty for global addresses.
+func parseAddressSelfLink(selfLink string) (project, region, name string, err error) {
+	path := selfLink
+	if i := strings.Index(path, "/projects/"); i >= 0 {
+		path = path[i+1:]
+	}
+
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 6 && parts[0] == "projects" && parts[2] == "regions" && parts[4] == "addresses":
+		return parts[1], parts[3], parts[5], nil
+	case len(parts) == 5 && parts[0] == "projects" && parts[2] == "global" && parts[3] == "addresses":
+		return parts[1], "", parts[4], nil
+	}
+
+	return "", "", "", microerror.Mask(fmt.Errorf("%q is not the self link of a compute address", selfLink))
+}
@@ -0,0 +1,65 @@
+package compute_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	computev1 "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/compute"
+)
+
+var _ = Describe("Addresses", func() {
+	var (
+		ctx       context.Context
+		server    *httptest.Server
+		addresses *compute.Addresses
+		paths     []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		paths = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			Expect(json.NewEncoder(w).Encode(&computev1.Address{Address: "10.0.0.2"})).To(Succeed())
+		}))
+
+		service, err := computev1.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).NotTo(HaveOccurred())
+		addresses = compute.NewAddresses(service)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("resolves regional addresses", func() {
+		address, err := addresses.ResolveAddress(ctx, "https://www.googleapis.com/compute/v1/projects/test-project/regions/europe-west1/addresses/test-address")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("10.0.0.2"))
+		Expect(paths).To(ConsistOf(HaveSuffix("/projects/test-project/regions/europe-west1/addresses/test-address")))
+	})
+
+	It("resolves global addresses", func() {
+		address, err := addresses.ResolveAddress(ctx, "projects/test-project/global/addresses/test-address")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(address).To(Equal("10.0.0.2"))
+		Expect(paths).To(ConsistOf(HaveSuffix("/projects/test-project/global/addresses/test-address")))
+	})
+
+	When("the self link is not an address", func() {
+		It("returns an error", func() {
+			_, err := addresses.ResolveAddress(ctx, "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/test-network")
+			Expect(err).To(MatchError(ContainSubstring("is not the self link of a compute address")))
+			Expect(paths).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package compute_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCompute(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Compute Suite")
+}
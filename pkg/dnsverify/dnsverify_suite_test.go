@@ -0,0 +1,13 @@
+package dnsverify_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDnsverify(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dnsverify Suite")
+}
@@ -0,0 +1,23 @@
+package dnsverify
+
+import "time"
+
+// NotVerifiedError is returned when the name servers do not serve the
+// delegation or the records of the cluster zone yet. The verification is
+// retried, as the name servers usually catch up within a minute.
+type NotVerifiedError struct {
+	reason  string
+	message string
+}
+
+func (e *NotVerifiedError) Error() string {
+	return e.message
+}
+
+func (e *NotVerifiedError) ConditionReason() string {
+	return e.reason
+}
+
+func (e *NotVerifiedError) RequeueAfter() time.Duration {
+	return verificationRequeueAfter
+}
@@ -0,0 +1,356 @@
+package dnsverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+const (
+	ReasonZoneNotFound          = "ZoneNotFound"
+	ReasonDelegationNotVerified = "DelegationNotVerified"
+	ReasonRecordsNotServed      = "RecordsNotServed"
+
+	verificationRequeueAfter = time.Second * 30
+	queryTimeout             = time.Second * 5
+	defaultPort              = "53"
+)
+
+var verifiedGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dns_operator_gcp",
+		Subsystem: "delegation",
+		Name:      "verified",
+		Help:      "Whether the delegation and the records of the cluster zone resolve, 1 if they do, 0 if they don't.",
+	},
+	[]string{"namespace", "name"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(verifiedGauge)
+}
+
+type Config struct {
+	BaseDomain       string
+	ParentDNSZone    string
+	ParentGCPProject string
+
+	// ParentNameServers overrides the name servers of the parent zone,
+	// which are queried for the delegation. Addresses are host or
+	// host:port.
+	ParentNameServers []string
+	// ZoneNameServers overrides the name servers of the cluster zones,
+	// which are queried for the records.
+	ZoneNameServers []string
+}
+
+// Verifier checks that a cluster zone is reachable by querying the name
+// servers directly, instead of trusting the Cloud DNS API. The parent zone
+// has to delegate the cluster domain to the name servers of the cluster zone
+// and those have to serve the api and bastion records.
+type Verifier struct {
+	config     Config
+	dnsService *clouddns.Service
+}
+
+func New(config Config, dnsService *clouddns.Service) *Verifier {
+	return &Verifier{
+		config:     config,
+		dnsService: dnsService,
+	}
+}
+
+func (v *Verifier) Verify(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := log.FromContext(ctx).WithName("delegation-verifier")
+
+	err := v.verify(ctx, cluster)
+	var notVerifiedErr *NotVerifiedError
+	if errors.As(err, &notVerifiedErr) {
+		logger.Info("Delegation not verified", "reason", notVerifiedErr.ConditionReason(), "message", notVerifiedErr.Error())
+		verifiedGauge.WithLabelValues(cluster.Namespace, cluster.Name).Set(0)
+		return microerror.Mask(err)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger.Info("Delegation verified")
+	verifiedGauge.WithLabelValues(cluster.Namespace, cluster.Name).Set(1)
+	return nil
+}
+
+// Forget drops the metric of a deleted cluster.
+func (v *Verifier) Forget(cluster *clusterview.Cluster) {
+	verifiedGauge.DeleteLabelValues(cluster.Namespace, cluster.Name)
+}
+
+func (v *Verifier) verify(ctx context.Context, cluster *clusterview.Cluster) error {
+	domain := fmt.Sprintf("%s.%s.", cluster.Name, v.config.BaseDomain)
+
+	zone, err := v.dnsService.ManagedZones.Get(cluster.Project, cluster.Name).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return microerror.Mask(&NotVerifiedError{
+			reason:  ReasonZoneNotFound,
+			message: fmt.Sprintf("zone %q does not exist", cluster.Name),
+		})
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	parentNameServers := v.config.ParentNameServers
+	if len(parentNameServers) == 0 {
+		parentZone, err := v.dnsService.ManagedZones.Get(v.config.ParentGCPProject, v.config.ParentDNSZone).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		parentNameServers = parentZone.NameServers
+	}
+
+	err = CheckDelegation(ctx, domain, zone.NameServers, parentNameServers)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	records, err := v.listVerifiedRecords(ctx, cluster, domain)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	zoneNameServers := v.config.ZoneNameServers
+	if len(zoneNameServers) == 0 {
+		zoneNameServers = zone.NameServers
+	}
+
+	err = CheckRecords(ctx, records, zoneNameServers)
+	return microerror.Mask(err)
+}
+
+// listVerifiedRecords returns the api and bastion records registered in the
+// cluster zone.
+func (v *Verifier) listVerifiedRecords(ctx context.Context, cluster *clusterview.Cluster, domain string) ([]*clouddns.ResourceRecordSet, error) {
+	names := map[string]bool{
+		fmt.Sprintf("%s.%s", registrar.EndpointAPI, domain):      true,
+		fmt.Sprintf("%s.%s", registrar.EndpointBastions, domain): true,
+	}
+
+	var records []*clouddns.ResourceRecordSet
+	err := v.dnsService.ResourceRecordSets.List(cluster.Project, cluster.Name).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			for _, record := range page.Rrsets {
+				if record.Type == registrar.RecordA && names[record.Name] {
+					records = append(records, record)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
+// CheckDelegation queries every parent name server for the NS records of the
+// domain and makes sure they delegate to the expected name servers. Parent
+// name servers answer with a referral, so the NS records are taken from the
+// authority section as well as the answer.
+func CheckDelegation(ctx context.Context, domain string, expectedNameServers, parentNameServers []string) error {
+	expected := normalizeNames(expectedNameServers)
+
+	for _, server := range parentNameServers {
+		response, err := query(ctx, server, domain, dns.TypeNS)
+		if err != nil {
+			return &NotVerifiedError{
+				reason:  ReasonDelegationNotVerified,
+				message: fmt.Sprintf("failed to query parent name server %s: %s", server, err),
+			}
+		}
+
+		var actual []string
+		for _, rr := range append(response.Answer, response.Ns...) {
+			ns, ok := rr.(*dns.NS)
+			if ok && strings.EqualFold(ns.Hdr.Name, domain) {
+				actual = append(actual, ns.Ns)
+			}
+		}
+		actual = normalizeNames(actual)
+
+		if !equal(actual, expected) {
+			return &NotVerifiedError{
+				reason:  ReasonDelegationNotVerified,
+				message: fmt.Sprintf("parent name server %s delegates %s to %v, expected %v", server, domain, actual, expected),
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckRecords queries every name server of the cluster zone for the A
+// records and makes sure they are served authoritatively with the registered
+// addresses. Records with a routing policy only answer with some of their
+// addresses, so they must be served with a subset of them. Records of other
+// types are ignored.
+func CheckRecords(ctx context.Context, records []*clouddns.ResourceRecordSet, nameServers []string) error {
+	for _, record := range records {
+		if record.Type != registrar.RecordA {
+			continue
+		}
+
+		expected := recordAddresses(record)
+		sort.Strings(expected)
+
+		for _, server := range nameServers {
+			response, err := query(ctx, server, record.Name, dns.TypeA)
+			if err != nil {
+				return &NotVerifiedError{
+					reason:  ReasonRecordsNotServed,
+					message: fmt.Sprintf("failed to query name server %s: %s", server, err),
+				}
+			}
+
+			var actual []string
+			for _, rr := range response.Answer {
+				a, ok := rr.(*dns.A)
+				if ok && strings.EqualFold(a.Hdr.Name, record.Name) {
+					actual = append(actual, a.A.String())
+				}
+			}
+			sort.Strings(actual)
+
+			served := equal(actual, expected)
+			if record.RoutingPolicy != nil {
+				served = len(actual) > 0 && subset(actual, expected)
+			}
+
+			if !response.Authoritative || !served {
+				return &NotVerifiedError{
+					reason:  ReasonRecordsNotServed,
+					message: fmt.Sprintf("name server %s serves %s as %v, expected %v", server, record.Name, actual, expected),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordAddresses returns all addresses of a record, including the ones of
+// its routing policy.
+func recordAddresses(record *clouddns.ResourceRecordSet) []string {
+	addresses := append([]string{}, record.Rrdatas...)
+	if record.RoutingPolicy == nil {
+		return addresses
+	}
+
+	if record.RoutingPolicy.Wrr != nil {
+		for _, item := range record.RoutingPolicy.Wrr.Items {
+			addresses = append(addresses, item.Rrdatas...)
+		}
+	}
+	if record.RoutingPolicy.Geo != nil {
+		for _, item := range record.RoutingPolicy.Geo.Items {
+			addresses = append(addresses, item.Rrdatas...)
+		}
+	}
+
+	return addresses
+}
+
+func query(ctx context.Context, server, name string, recordType uint16) (*dns.Msg, error) {
+	message := new(dns.Msg)
+	message.SetQuestion(dns.Fqdn(name), recordType)
+	message.RecursionDesired = false
+
+	client := &dns.Client{Timeout: queryTimeout}
+	response, _, err := client.ExchangeContext(ctx, message, serverAddress(server))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return nil, microerror.Mask(fmt.Errorf("query for %s returned %s", name, dns.RcodeToString[response.Rcode]))
+	}
+
+	return response, nil
+}
+
+// serverAddress turns name server names, like the ones returned by Cloud
+// DNS, into addresses.
+func serverAddress(server string) string {
+	_, _, err := net.SplitHostPort(server)
+	if err == nil {
+		return server
+	}
+
+	return net.JoinHostPort(strings.TrimSuffix(server, "."), defaultPort)
+}
+
+func normalizeNames(names []string) []string {
+	var normalized []string
+	for _, name := range names {
+		normalized = append(normalized, strings.ToLower(dns.Fqdn(name)))
+	}
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+func equal(actual, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func subset(actual, expected []string) bool {
+	contained := map[string]bool{}
+	for _, value := range expected {
+		contained[value] = true
+	}
+
+	for _, value := range actual {
+		if !contained[value] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasHttpCode(err error, statusCode int) bool {
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		if googleErr.Code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,205 @@
+package dnsverify_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/dnsverify"
+)
+
+// startServer runs a local DNS server answering from the given records. NS
+// records are returned in the authority section without the authoritative
+// flag, like a parent zone answers with a referral.
+func startServer(records ...dns.RR) string {
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        packetConn,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+			response := new(dns.Msg)
+			response.SetReply(request)
+
+			question := request.Question[0]
+			for _, rr := range records {
+				if !strings.EqualFold(rr.Header().Name, question.Name) || rr.Header().Rrtype != question.Qtype {
+					continue
+				}
+				if rr.Header().Rrtype == dns.TypeNS {
+					response.Ns = append(response.Ns, rr)
+				} else {
+					response.Authoritative = true
+					response.Answer = append(response.Answer, rr)
+				}
+			}
+
+			Expect(w.WriteMsg(response)).To(Succeed())
+		}),
+	}
+	go func() {
+		defer GinkgoRecover()
+		_ = server.ActivateAndServe()
+	}()
+	Eventually(started).Should(BeClosed())
+
+	DeferCleanup(func() {
+		Expect(server.Shutdown()).To(Succeed())
+	})
+
+	return packetConn.LocalAddr().String()
+}
+
+func newRR(record string) dns.RR {
+	rr, err := dns.NewRR(record)
+	Expect(err).NotTo(HaveOccurred())
+	return rr
+}
+
+func expectNotVerified(err error, reason string) {
+	var notVerifiedErr *dnsverify.NotVerifiedError
+	ExpectWithOffset(1, errors.As(err, &notVerifiedErr)).To(BeTrue())
+	ExpectWithOffset(1, notVerifiedErr.ConditionReason()).To(Equal(reason))
+}
+
+var _ = Describe("Verifier", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Describe("CheckDelegation", func() {
+		var parentServer string
+
+		BeforeEach(func() {
+			parentServer = startServer(
+				newRR("test.example.com. 300 IN NS ns-cloud-a1.googledomains.com."),
+				newRR("test.example.com. 300 IN NS ns-cloud-a2.googledomains.com."),
+			)
+		})
+
+		It("verifies the delegation", func() {
+			err := dnsverify.CheckDelegation(ctx, "test.example.com.", []string{
+				"ns-cloud-a2.googledomains.com.",
+				"NS-CLOUD-A1.googledomains.com",
+			}, []string{parentServer})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("the delegation points to other name servers", func() {
+			It("returns an error", func() {
+				err := dnsverify.CheckDelegation(ctx, "test.example.com.", []string{
+					"ns-cloud-b1.googledomains.com.",
+					"ns-cloud-b2.googledomains.com.",
+				}, []string{parentServer})
+				expectNotVerified(err, dnsverify.ReasonDelegationNotVerified)
+			})
+		})
+
+		When("the domain is not delegated", func() {
+			It("returns an error", func() {
+				err := dnsverify.CheckDelegation(ctx, "other.example.com.", []string{
+					"ns-cloud-a1.googledomains.com.",
+				}, []string{parentServer})
+				expectNotVerified(err, dnsverify.ReasonDelegationNotVerified)
+			})
+		})
+	})
+
+	Describe("CheckRecords", func() {
+		var (
+			zoneServer string
+			records    []*clouddns.ResourceRecordSet
+		)
+
+		BeforeEach(func() {
+			zoneServer = startServer(
+				newRR("api.test.example.com. 300 IN A 1.2.3.4"),
+				newRR("bastion.test.example.com. 300 IN A 1.2.3.5"),
+				newRR("bastion.test.example.com. 300 IN A 1.2.3.6"),
+			)
+			records = []*clouddns.ResourceRecordSet{
+				{Name: "api.test.example.com.", Type: "A", Rrdatas: []string{"1.2.3.4"}},
+				{Name: "bastion.test.example.com.", Type: "A", Rrdatas: []string{"1.2.3.6", "1.2.3.5"}},
+			}
+		})
+
+		It("verifies the records", func() {
+			err := dnsverify.CheckRecords(ctx, records, []string{zoneServer})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("a record is not served yet", func() {
+			BeforeEach(func() {
+				records = append(records, &clouddns.ResourceRecordSet{
+					Name: "bastion1.test.example.com.", Type: "A", Rrdatas: []string{"1.2.3.5"},
+				})
+			})
+
+			It("returns an error", func() {
+				err := dnsverify.CheckRecords(ctx, records, []string{zoneServer})
+				expectNotVerified(err, dnsverify.ReasonRecordsNotServed)
+			})
+		})
+
+		When("a record is served with other addresses", func() {
+			BeforeEach(func() {
+				records[0].Rrdatas = []string{"4.3.2.1"}
+			})
+
+			It("returns an error", func() {
+				err := dnsverify.CheckRecords(ctx, records, []string{zoneServer})
+				expectNotVerified(err, dnsverify.ReasonRecordsNotServed)
+			})
+		})
+
+		When("a record has a routing policy", func() {
+			BeforeEach(func() {
+				records[0].Rrdatas = nil
+				records[0].RoutingPolicy = &clouddns.RRSetRoutingPolicy{
+					Wrr: &clouddns.RRSetRoutingPolicyWrrPolicy{
+						Items: []*clouddns.RRSetRoutingPolicyWrrPolicyWrrPolicyItem{
+							{Weight: 1, Rrdatas: []string{"1.2.3.4"}},
+							{Weight: 1, Rrdatas: []string{"4.3.2.1"}},
+						},
+					},
+				}
+			})
+
+			It("verifies the record is served with some of its addresses", func() {
+				err := dnsverify.CheckRecords(ctx, records, []string{zoneServer})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			When("the served address is not part of the policy", func() {
+				BeforeEach(func() {
+					records[0].RoutingPolicy.Wrr.Items = records[0].RoutingPolicy.Wrr.Items[1:]
+				})
+
+				It("returns an error", func() {
+					err := dnsverify.CheckRecords(ctx, records, []string{zoneServer})
+					expectNotVerified(err, dnsverify.ReasonRecordsNotServed)
+				})
+			})
+		})
+
+		When("the name server can't be reached", func() {
+			It("returns an error", func() {
+				canceledCtx, cancel := context.WithCancel(ctx)
+				cancel()
+
+				err := dnsverify.CheckRecords(canceledCtx, records, []string{zoneServer})
+				expectNotVerified(err, dnsverify.ReasonRecordsNotServed)
+			})
+		})
+	})
+})
@@ -0,0 +1,356 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+const (
+	EventReasonFailover = "APIFailover"
+	EventReasonFailback = "APIFailback"
+
+	// AnnotationFailedOver is set on the CAPI cluster to the backup
+	// endpoint the api record failed over to, so that the failover survives
+	// restarts of the operator. It is removed on failback.
+	AnnotationFailedOver = "dns-operator-gcp.giantswarm.io/api-failed-over"
+
+	endpointPrimary = "primary"
+	endpointBackup  = "backup"
+
+	// staleAfter is the time after which clusters are no longer checked,
+	// when the api registrar didn't watch them anymore. Clusters are
+	// reconciled periodically, so this only happens when a cluster is gone
+	// without the api record being unregistered, e.g. because its DNS was
+	// retained.
+	staleAfter = time.Minute * 30
+)
+
+var (
+	endpointHealthyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "dns_operator_gcp",
+			Subsystem: "api",
+			Name:      "endpoint_healthy",
+			Help:      "Whether the primary or backup api endpoint of the cluster passed the last probe, 1 if it did, 0 if it didn't.",
+		},
+		[]string{"namespace", "name", "endpoint"},
+	)
+	failedOverGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "dns_operator_gcp",
+			Subsystem: "api",
+			Name:      "failed_over",
+			Help:      "Whether the api record of the cluster points to the backup endpoint, 1 if it does, 0 if it doesn't.",
+		},
+		[]string{"namespace", "name"},
+	)
+	failoversCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dns_operator_gcp",
+			Subsystem: "api",
+			Name:      "failovers_total",
+			Help:      "Number of times the api record of the cluster failed over to the backup endpoint.",
+		},
+		[]string{"namespace", "name"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(endpointHealthyGauge, failedOverGauge, failoversCounter)
+}
+
+//counterfeiter:generate . Prober
+type Prober interface {
+	// Probe returns an error, when the endpoint at the host:port address
+	// is not healthy.
+	Probe(ctx context.Context, address string) error
+}
+
+type Config struct {
+	// Prober checks the health of the endpoints.
+	Prober Prober
+	// Interval is the time between two probes of an endpoint.
+	Interval time.Duration
+	// FailureThreshold is the number of consecutive failed probes of the
+	// primary endpoint before failing over to the backup endpoint.
+	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successful probes of
+	// the primary endpoint before failing back to it.
+	SuccessThreshold int
+
+	// Recorder records the failovers as events of the CAPI cluster.
+	Recorder record.EventRecorder
+	// Events receives the CAPI cluster after every failover, so that its api
+	// record is updated without waiting for the periodic reconciliation.
+	Events chan<- event.GenericEvent
+	// Client persists the failovers in the AnnotationFailedOver annotation
+	// of the CAPI cluster. Without it, failovers are lost on restart.
+	Client client.Client
+}
+
+type target struct {
+	cluster *capi.Cluster
+	primary string
+	backup  string
+
+	failures    int
+	successes   int
+	failedOver  bool
+	lastWatched time.Time
+}
+
+// Checker probes the primary and backup api endpoints of the watched
+// clusters and decides which of them the api record points to. It fails over
+// to the backup endpoint, once the primary endpoint failed FailureThreshold
+// probes in a row and the backup endpoint is healthy. It fails back, once the
+// primary endpoint passed SuccessThreshold probes in a row, so that a
+// flapping endpoint doesn't make the record flap.
+type Checker struct {
+	config Config
+
+	mutex   sync.Mutex
+	targets map[types.NamespacedName]*target
+	now     func() time.Time
+}
+
+func New(config Config) *Checker {
+	return &Checker{
+		config:  config,
+		targets: map[types.NamespacedName]*target{},
+		now:     time.Now,
+	}
+}
+
+// Watch starts checking the endpoints of the cluster. The endpoints are
+// host:port addresses. A cluster watched for the first time starts failed
+// over, when its AnnotationFailedOver annotation names the backup endpoint.
+// Watching a cluster again with other endpoints resets its state to the
+// primary endpoint.
+func (c *Checker) Watch(cluster *clusterview.Cluster, primary, backup string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	t, ok := c.targets[key]
+	if !ok || t.primary != primary || t.backup != backup {
+		t = &target{
+			primary:    primary,
+			backup:     backup,
+			failedOver: !ok && cluster.Cluster.Annotations[AnnotationFailedOver] == backup,
+		}
+		c.targets[key] = t
+		failedOverGauge.WithLabelValues(cluster.Namespace, cluster.Name).Set(boolToFloat(t.failedOver))
+	}
+
+	t.cluster = cluster.Cluster.DeepCopy()
+	t.lastWatched = c.now()
+}
+
+// UseBackup returns whether the api record of the cluster has to point to
+// the backup endpoint.
+func (c *Checker) UseBackup(cluster *clusterview.Cluster) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t, ok := c.targets[types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}]
+	return ok && t.failedOver
+}
+
+// Forget stops checking the endpoints of the cluster.
+func (c *Checker) Forget(cluster *clusterview.Cluster) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.forget(types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name})
+}
+
+// Start probes the endpoints until the context is cancelled. It implements
+// manager.Runnable.
+func (c *Checker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes sure only the leader, which reconciles the api
+// records, probes the endpoints.
+func (c *Checker) NeedLeaderElection() bool {
+	return true
+}
+
+// Check probes the endpoints of all watched clusters once and fails over or
+// back where needed.
+func (c *Checker) Check(ctx context.Context) {
+	c.mutex.Lock()
+	targets := map[types.NamespacedName]target{}
+	for key, t := range c.targets {
+		if c.now().Sub(t.lastWatched) > staleAfter {
+			c.forget(key)
+			continue
+		}
+		targets[key] = *t
+	}
+	c.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for key, t := range targets {
+		wg.Add(1)
+		go func(key types.NamespacedName, t target) {
+			defer wg.Done()
+			c.check(ctx, key, t)
+		}(key, t)
+	}
+	wg.Wait()
+}
+
+func (c *Checker) check(ctx context.Context, key types.NamespacedName, probed target) {
+	logger := c.getLogger(ctx).WithValues("namespace", key.Namespace, "name", key.Name)
+
+	primaryErr := c.config.Prober.Probe(ctx, probed.primary)
+	backupErr := c.config.Prober.Probe(ctx, probed.backup)
+
+	c.mutex.Lock()
+	t, ok := c.targets[key]
+	if !ok || t.primary != probed.primary || t.backup != probed.backup {
+		// The cluster was forgotten or its endpoints changed while
+		// probing.
+		c.mutex.Unlock()
+		return
+	}
+
+	setHealthy(key, endpointPrimary, primaryErr)
+	setHealthy(key, endpointBackup, backupErr)
+
+	if primaryErr != nil {
+		t.failures++
+		t.successes = 0
+	} else {
+		t.successes++
+		t.failures = 0
+	}
+
+	var eventType, reason, message string
+	switch {
+	case !t.failedOver && t.failures >= c.config.FailureThreshold:
+		if backupErr != nil {
+			logger.Info("Primary endpoint is unhealthy, but so is the backup endpoint. Not failing over", "primary", t.primary, "primaryError", primaryErr.Error(), "backup", t.backup, "backupError", backupErr.Error())
+			break
+		}
+		t.failedOver = true
+		failoversCounter.WithLabelValues(key.Namespace, key.Name).Inc()
+		eventType = corev1.EventTypeWarning
+		reason = EventReasonFailover
+		message = fmt.Sprintf("primary api endpoint %s failed %d probes: %s, failing over to backup endpoint %s", t.primary, t.failures, primaryErr, t.backup)
+	case t.failedOver && t.successes >= c.config.SuccessThreshold:
+		t.failedOver = false
+		eventType = corev1.EventTypeNormal
+		reason = EventReasonFailback
+		message = fmt.Sprintf("primary api endpoint %s passed %d probes, failing back from backup endpoint %s", t.primary, t.successes, t.backup)
+	}
+
+	cluster := t.cluster
+	failedOver := t.failedOver
+	c.mutex.Unlock()
+
+	if reason == "" {
+		return
+	}
+
+	logger.Info(message)
+	failedOverGauge.WithLabelValues(key.Namespace, key.Name).Set(boolToFloat(failedOver))
+	err := c.persist(ctx, cluster, failedOver, probed.backup)
+	if err != nil {
+		logger.Error(err, "Failed to persist failover")
+	}
+	if c.config.Recorder != nil {
+		c.config.Recorder.Event(cluster, eventType, reason, message)
+	}
+	if c.config.Events != nil {
+		select {
+		case c.config.Events <- event.GenericEvent{Object: cluster}:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// persist sets or removes the AnnotationFailedOver annotation of the CAPI
+// cluster. The cluster the checker holds may be outdated, so the annotation
+// is patched regardless of its current value.
+func (c *Checker) persist(ctx context.Context, cluster *capi.Cluster, failedOver bool, backup string) error {
+	if c.config.Client == nil {
+		return nil
+	}
+
+	var value interface{}
+	if failedOver {
+		value = backup
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				AnnotationFailedOver: value,
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = c.config.Client.Patch(ctx, cluster.DeepCopy(), client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Checker) forget(key types.NamespacedName) {
+	delete(c.targets, key)
+	endpointHealthyGauge.DeleteLabelValues(key.Namespace, key.Name, endpointPrimary)
+	endpointHealthyGauge.DeleteLabelValues(key.Namespace, key.Name, endpointBackup)
+	failedOverGauge.DeleteLabelValues(key.Namespace, key.Name)
+}
+
+func (c *Checker) getLogger(ctx context.Context) logr.Logger {
+	logger := log.FromContext(ctx)
+	return logger.WithName("api-health-checker")
+}
+
+func setHealthy(key types.NamespacedName, endpoint string, err error) {
+	endpointHealthyGauge.WithLabelValues(key.Namespace, key.Name, endpoint).Set(boolToFloat(err == nil))
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
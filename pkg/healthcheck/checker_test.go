@@ -0,0 +1,242 @@
+package healthcheck_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/healthcheck"
+	"github.com/giantswarm/dns-operator-gcp/pkg/healthcheck/healthcheckfakes"
+)
+
+var _ = Describe("Checker", func() {
+	const (
+		primary = "10.0.0.1:443"
+		backup  = "10.0.0.2:443"
+	)
+
+	var (
+		ctx context.Context
+
+		prober    *healthcheckfakes.FakeProber
+		recorder  *record.FakeRecorder
+		k8sClient client.Client
+		events    chan event.GenericEvent
+		checker   *healthcheck.Checker
+		cluster   *clusterview.Cluster
+
+		primaryHealthy bool
+		backupHealthy  bool
+	)
+
+	// check probes the endpoints and drains the event sent on fail over or
+	// back, so that the checker doesn't block.
+	check := func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			checker.Check(ctx)
+		}()
+
+		for {
+			select {
+			case <-events:
+			case <-done:
+				return
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		primaryHealthy = true
+		backupHealthy = true
+
+		prober = new(healthcheckfakes.FakeProber)
+		prober.ProbeStub = func(_ context.Context, address string) error {
+			if address == primary && !primaryHealthy || address == backup && !backupHealthy {
+				return errors.New("unhealthy")
+			}
+			return nil
+		}
+
+		cluster = &clusterview.Cluster{
+			Name:      "test-cluster",
+			Namespace: "test",
+			Cluster: &capi.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "test",
+				},
+			},
+		}
+
+		scheme := runtime.NewScheme()
+		Expect(capi.AddToScheme(scheme)).To(Succeed())
+		k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster.Cluster.DeepCopy()).Build()
+
+		recorder = record.NewFakeRecorder(10)
+		events = make(chan event.GenericEvent)
+		checker = healthcheck.New(healthcheck.Config{
+			Prober:           prober,
+			FailureThreshold: 2,
+			SuccessThreshold: 2,
+			Recorder:         recorder,
+			Events:           events,
+			Client:           k8sClient,
+		})
+		checker.Watch(cluster, primary, backup)
+	})
+
+	// getFailedOver returns the failover persisted on the CAPI cluster.
+	getFailedOver := func() string {
+		persisted := &capi.Cluster{}
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}, persisted)
+		Expect(err).NotTo(HaveOccurred())
+		return persisted.Annotations[healthcheck.AnnotationFailedOver]
+	}
+
+	It("probes both endpoints", func() {
+		check()
+
+		Expect(prober.ProbeCallCount()).To(Equal(2))
+		Expect(checker.UseBackup(cluster)).To(BeFalse())
+	})
+
+	When("the primary endpoint is unhealthy", func() {
+		BeforeEach(func() {
+			primaryHealthy = false
+		})
+
+		It("fails over after the failure threshold", func() {
+			check()
+			Expect(checker.UseBackup(cluster)).To(BeFalse())
+
+			check()
+			Expect(checker.UseBackup(cluster)).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring(healthcheck.EventReasonFailover)))
+		})
+
+		It("persists the failover on the cluster", func() {
+			check()
+			Expect(getFailedOver()).To(BeEmpty())
+
+			check()
+			Expect(getFailedOver()).To(Equal(backup))
+		})
+
+		It("sends the cluster to the events channel", func() {
+			check()
+
+			go checker.Check(ctx)
+			var e event.GenericEvent
+			Eventually(events).Should(Receive(&e))
+			Expect(e.Object.GetName()).To(Equal(cluster.Name))
+		})
+
+		When("the backup endpoint is unhealthy as well", func() {
+			BeforeEach(func() {
+				backupHealthy = false
+			})
+
+			It("does not fail over", func() {
+				check()
+				check()
+				Expect(checker.UseBackup(cluster)).To(BeFalse())
+				Expect(recorder.Events).NotTo(Receive())
+			})
+		})
+
+		When("the primary endpoint recovers", func() {
+			BeforeEach(func() {
+				check()
+				check()
+				Expect(checker.UseBackup(cluster)).To(BeTrue())
+				Expect(recorder.Events).To(Receive())
+
+				primaryHealthy = true
+			})
+
+			It("fails back after the success threshold", func() {
+				check()
+				Expect(checker.UseBackup(cluster)).To(BeTrue())
+
+				check()
+				Expect(checker.UseBackup(cluster)).To(BeFalse())
+				Expect(recorder.Events).To(Receive(ContainSubstring(healthcheck.EventReasonFailback)))
+				Expect(getFailedOver()).To(BeEmpty())
+			})
+
+			When("the primary endpoint flaps", func() {
+				It("stays on the backup endpoint", func() {
+					check()
+					primaryHealthy = false
+					check()
+					primaryHealthy = true
+					check()
+
+					Expect(checker.UseBackup(cluster)).To(BeTrue())
+				})
+			})
+		})
+	})
+
+	When("the cluster failed over before a restart", func() {
+		var restarted *healthcheck.Checker
+
+		BeforeEach(func() {
+			restarted = healthcheck.New(healthcheck.Config{
+				Prober:           prober,
+				FailureThreshold: 2,
+				SuccessThreshold: 2,
+			})
+		})
+
+		It("restores the failover", func() {
+			cluster.Cluster.Annotations = map[string]string{healthcheck.AnnotationFailedOver: backup}
+			restarted.Watch(cluster, primary, backup)
+
+			Expect(restarted.UseBackup(cluster)).To(BeTrue())
+		})
+
+		It("ignores failovers to another backup endpoint", func() {
+			cluster.Cluster.Annotations = map[string]string{healthcheck.AnnotationFailedOver: "10.0.0.3:443"}
+			restarted.Watch(cluster, primary, backup)
+
+			Expect(restarted.UseBackup(cluster)).To(BeFalse())
+		})
+	})
+
+	When("the endpoints change", func() {
+		It("resets the cluster to the primary endpoint", func() {
+			primaryHealthy = false
+			check()
+			check()
+			Expect(checker.UseBackup(cluster)).To(BeTrue())
+
+			checker.Watch(cluster, primary, "10.0.0.3:443")
+			Expect(checker.UseBackup(cluster)).To(BeFalse())
+		})
+	})
+
+	When("the cluster is forgotten", func() {
+		It("no longer probes the endpoints", func() {
+			checker.Forget(cluster)
+			check()
+
+			Expect(prober.ProbeCallCount()).To(Equal(0))
+			Expect(checker.UseBackup(cluster)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,169 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	ProbeTCP   = "tcp"
+	ProbeHTTPS = "https"
+)
+
+// TCPProber considers an endpoint healthy, when it accepts TCP connections.
+type TCPProber struct {
+	timeout time.Duration
+}
+
+func NewTCPProber(timeout time.Duration) *TCPProber {
+	return &TCPProber{
+		timeout: timeout,
+	}
+}
+
+func (p *TCPProber) Probe(ctx context.Context, address string) error {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return microerror.Mask(conn.Close())
+}
+
+// HTTPSProber considers an endpoint healthy, when the Kubernetes API server
+// behind it reports to be ready on /readyz. Endpoints are probed by their
+// address, which their serving certificate is not issued for, so the name in
+// the certificate is never verified. Without CA file, the certificate is not
+// verified at all. Without token file, the probe is anonymous and requires
+// the API server to allow anonymous requests to /readyz, which it does by
+// default. Otherwise the probe fails with 401 and the endpoint is considered
+// unhealthy.
+type HTTPSProber struct {
+	client    *http.Client
+	tokenFile string
+}
+
+type HTTPSProberConfig struct {
+	// Timeout is the timeout of a single probe.
+	Timeout time.Duration
+	// CAFile is the PEM encoded bundle of the CAs the serving certificates
+	// of the endpoints are verified against. Optional.
+	CAFile string
+	// TokenFile contains the bearer token the probes authenticate with. It
+	// is read for every probe, so that rotated tokens are picked up.
+	// Optional.
+	TokenFile string
+}
+
+func NewHTTPSProber(config HTTPSProberConfig) (*HTTPSProber, error) {
+	tlsConfig := &tls.Config{
+		// The certificate is verified against the CAs below, but without
+		// its name.
+		InsecureSkipVerify: true,
+	}
+	if config.CAFile != "" {
+		caBundle, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caBundle) {
+			return nil, microerror.Mask(fmt.Errorf("no certificates found in CA file %s", config.CAFile))
+		}
+		tlsConfig.VerifyPeerCertificate = verifyChain(roots)
+	}
+
+	return &HTTPSProber{
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		},
+		tokenFile: config.TokenFile,
+	}, nil
+}
+
+func (p *HTTPSProber) Probe(ctx context.Context, address string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/readyz", address), nil)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if p.tokenFile != "" {
+		token, err := os.ReadFile(p.tokenFile)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		request.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return microerror.Mask(fmt.Errorf("readyz returned %s", response.Status))
+	}
+
+	return nil
+}
+
+// verifyChain returns a function verifying that the certificate chain
+// presented by the endpoint is issued by one of the roots.
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return microerror.Mask(fmt.Errorf("endpoint presented no certificate"))
+		}
+
+		intermediates := x509.NewCertPool()
+		var leaf *x509.Certificate
+		for i, rawCert := range rawCerts {
+			cert, err := x509.ParseCertificate(rawCert)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+			if i == 0 {
+				leaf = cert
+				continue
+			}
+			intermediates.AddCert(cert)
+		}
+
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return microerror.Mask(err)
+	}
+}
+
+// NewProber returns the prober of the given type. The CA and token files of
+// the config only apply to the HTTPS prober.
+func NewProber(probeType string, config HTTPSProberConfig) (Prober, error) {
+	switch probeType {
+	case ProbeTCP:
+		return NewTCPProber(config.Timeout), nil
+	case ProbeHTTPS:
+		prober, err := NewHTTPSProber(config)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		return prober, nil
+	}
+
+	return nil, microerror.Mask(fmt.Errorf("unknown probe %q, must be %q or %q", probeType, ProbeTCP, ProbeHTTPS))
+}
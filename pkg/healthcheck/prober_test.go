@@ -0,0 +1,192 @@
+package healthcheck_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/healthcheck"
+)
+
+var _ = Describe("Prober", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Describe("HTTPSProber", func() {
+		var (
+			prober        *healthcheck.HTTPSProber
+			server        *httptest.Server
+			status        int
+			authorization string
+		)
+
+		BeforeEach(func() {
+			status = http.StatusOK
+			authorization = ""
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/readyz" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				authorization = r.Header.Get("Authorization")
+				w.WriteHeader(status)
+			}))
+
+			var err error
+			prober, err = healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		// writeFile writes the content to a file in a temporary directory and
+		// returns its path.
+		writeFile := func(name string, content []byte) string {
+			path := filepath.Join(GinkgoT().TempDir(), name)
+			Expect(os.WriteFile(path, content, 0600)).To(Succeed())
+			return path
+		}
+
+		// encodeCertificate returns the PEM encoded certificate.
+		encodeCertificate := func(cert *x509.Certificate) []byte {
+			return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+
+		It("succeeds when the endpoint is ready", func() {
+			Expect(prober.Probe(ctx, server.Listener.Addr().String())).To(Succeed())
+			Expect(authorization).To(BeEmpty())
+		})
+
+		When("a CA file is given", func() {
+			It("succeeds when the certificate is issued by the CA", func() {
+				caFile := writeFile("ca.crt", encodeCertificate(server.Certificate()))
+				prober, err := healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second, CAFile: caFile})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(prober.Probe(ctx, server.Listener.Addr().String())).To(Succeed())
+			})
+
+			It("returns an error when the certificate is issued by another CA", func() {
+				key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				template := &x509.Certificate{
+					SerialNumber:          big.NewInt(1),
+					Subject:               pkix.Name{CommonName: "other-ca"},
+					NotBefore:             time.Now().Add(-time.Hour),
+					NotAfter:              time.Now().Add(time.Hour),
+					IsCA:                  true,
+					KeyUsage:              x509.KeyUsageCertSign,
+					BasicConstraintsValid: true,
+				}
+				der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+				Expect(err).NotTo(HaveOccurred())
+				otherCA, err := x509.ParseCertificate(der)
+				Expect(err).NotTo(HaveOccurred())
+
+				caFile := writeFile("ca.crt", encodeCertificate(otherCA))
+				prober, err := healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second, CAFile: caFile})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(prober.Probe(ctx, server.Listener.Addr().String())).NotTo(Succeed())
+			})
+
+			It("returns an error when the file contains no certificate", func() {
+				caFile := writeFile("ca.crt", []byte("not a certificate"))
+				_, err := healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second, CAFile: caFile})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("a token file is given", func() {
+			It("authenticates with the token", func() {
+				tokenFile := writeFile("token", []byte("secret-token\n"))
+				prober, err := healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second, TokenFile: tokenFile})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(prober.Probe(ctx, server.Listener.Addr().String())).To(Succeed())
+				Expect(authorization).To(Equal("Bearer secret-token"))
+			})
+
+			It("returns an error when the token file is missing", func() {
+				tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+				prober, err := healthcheck.NewHTTPSProber(healthcheck.HTTPSProberConfig{Timeout: time.Second, TokenFile: tokenFile})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(prober.Probe(ctx, server.Listener.Addr().String())).NotTo(Succeed())
+			})
+		})
+
+		When("the endpoint is not ready", func() {
+			BeforeEach(func() {
+				status = http.StatusInternalServerError
+			})
+
+			It("returns an error", func() {
+				Expect(prober.Probe(ctx, server.Listener.Addr().String())).NotTo(Succeed())
+			})
+		})
+
+		When("the endpoint is down", func() {
+			It("returns an error", func() {
+				address := server.Listener.Addr().String()
+				server.Close()
+
+				Expect(prober.Probe(ctx, address)).NotTo(Succeed())
+			})
+		})
+	})
+
+	Describe("TCPProber", func() {
+		var (
+			prober   *healthcheck.TCPProber
+			listener net.Listener
+		)
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			prober = healthcheck.NewTCPProber(time.Second)
+		})
+
+		It("succeeds when the endpoint accepts connections", func() {
+			defer listener.Close()
+			Expect(prober.Probe(ctx, listener.Addr().String())).To(Succeed())
+		})
+
+		When("the endpoint is down", func() {
+			It("returns an error", func() {
+				address := listener.Addr().String()
+				listener.Close()
+
+				Expect(prober.Probe(ctx, address)).NotTo(Succeed())
+			})
+		})
+	})
+
+	Describe("NewProber", func() {
+		It("returns an error for unknown probes", func() {
+			_, err := healthcheck.NewProber("icmp", healthcheck.HTTPSProberConfig{Timeout: time.Second})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -12,6 +12,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
 )
 
@@ -20,21 +21,19 @@ var _ = Describe("MachineList", func() {
 		ctx      context.Context
 		machine  *capg.GCPMachine
 		bastions *k8sclient.Bastions
-		cluster  *capg.GCPCluster
+		cluster  *clusterview.Cluster
 	)
 
 	BeforeEach(func() {
 		ctx = context.Background()
 		bastions = k8sclient.NewBastions(k8sClient, controllers.FinalizerDNS)
-		cluster = &capg.GCPCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: namespace,
-			},
+		cluster = &clusterview.Cluster{
+			Name:      "test-cluster",
+			Namespace: namespace,
 		}
 	})
 
-	Describe("GetBastionIPList", func() {
+	Describe("GetBastions", func() {
 		BeforeEach(func() {
 			machine = &capg.GCPMachine{
 				ObjectMeta: metav1.ObjectMeta{
@@ -75,9 +74,21 @@ var _ = Describe("MachineList", func() {
 		})
 
 		It("gets the bastion machine", func() {
-			ipList, err := bastions.GetBastionIPList(ctx, cluster)
+			bastionList, err := bastions.GetBastions(ctx, cluster)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(ipList).To(Equal([]string{"1.2.3.4"}))
+			Expect(bastionList).To(Equal([]k8sclient.Bastion{
+				{Name: "test-cluster-bastion-1", Index: 1, IP: "1.2.3.4"},
+			}))
+		})
+
+		It("persists the bastion index on the machine", func() {
+			_, err := bastions.GetBastions(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualMachine := &capg.GCPMachine{}
+			nsName := types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}
+			Expect(k8sClient.Get(ctx, nsName, actualMachine)).To(Succeed())
+			Expect(actualMachine.Annotations).To(HaveKeyWithValue(k8sclient.AnnotationBastionIndex, "1"))
 		})
 
 		When("the bastion doesn't have an IP yet", func() {
@@ -92,12 +103,13 @@ var _ = Describe("MachineList", func() {
 				Expect(k8sClient.Status().Patch(ctx, patchedMachine, client.MergeFrom(machine))).To(Succeed())
 			})
 
-			It("returns an error", func() {
-				ipList, err := bastions.GetBastionIPList(ctx, cluster)
-				Expect(err).To(MatchError(And(
-					ContainSubstring("bastion IP is not yet available"),
-				)))
-				Expect(ipList).To(BeNil())
+			It("returns the bastion as pending", func() {
+				bastionList, err := bastions.GetBastions(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bastionList).To(Equal([]k8sclient.Bastion{
+					{Name: "test-cluster-bastion-1", Index: 1},
+				}))
+				Expect(bastionList[0].IsPending()).To(BeTrue())
 			})
 		})
 
@@ -132,25 +144,64 @@ var _ = Describe("MachineList", func() {
 				Expect(k8sClient.Delete(ctx, otherMachine)).To(Succeed())
 			})
 
-			It("return multiple bastions ip", func() {
-				ipList, err := bastions.GetBastionIPList(ctx, cluster)
+			It("return multiple bastions", func() {
+				bastionList, err := bastions.GetBastions(ctx, cluster)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(ipList).To(Equal([]string{"1.2.3.4", "1.2.3.5"}))
+				Expect(bastionList).To(ConsistOf(
+					k8sclient.Bastion{Name: "test-cluster-bastion-1", Index: 1, IP: "1.2.3.4"},
+					k8sclient.Bastion{Name: "test-cluster-bastion-2", Index: 2, IP: "1.2.3.5"},
+				))
+			})
+
+			When("the first bastion is removed", func() {
+				BeforeEach(func() {
+					_, err := bastions.GetBastions(ctx, cluster)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Delete(ctx, machine)).To(Succeed())
+					machine = &capg.GCPMachine{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "test-cluster-bastion-3",
+							Namespace: namespace,
+							Labels: map[string]string{
+								k8sclient.LabelBastionKey: k8sclient.BastionLabel("test-cluster"),
+							},
+						},
+					}
+					Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+					patchedMachine := machine.DeepCopy()
+					patchedMachine.Status = capg.GCPMachineStatus{
+						Addresses: []corev1.NodeAddress{
+							{
+								Type:    "ExternalIP",
+								Address: "1.2.3.6",
+							},
+						},
+					}
+					Expect(k8sClient.Status().Patch(ctx, patchedMachine, client.MergeFrom(machine))).To(Succeed())
+				})
+
+				It("keeps the index of the remaining bastion and reuses the free index", func() {
+					bastionList, err := bastions.GetBastions(ctx, cluster)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(bastionList).To(Equal([]k8sclient.Bastion{
+						{Name: "test-cluster-bastion-3", Index: 1, IP: "1.2.3.6"},
+						{Name: "test-cluster-bastion-2", Index: 2, IP: "1.2.3.5"},
+					}))
+				})
 			})
 		})
 
 		When("there is no bastion matching the label key", func() {
 			It("returns an error", func() {
 
-				otherCluster := &capg.GCPCluster{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "test-cluster-other",
-						Namespace: namespace,
-					},
+				otherCluster := &clusterview.Cluster{
+					Name:      "test-cluster-other",
+					Namespace: namespace,
 				}
-				ipList, err := bastions.GetBastionIPList(ctx, otherCluster)
+				bastionList, err := bastions.GetBastions(ctx, otherCluster)
 				Expect(err).To(BeNil())
-				Expect(ipList).To(BeNil())
+				Expect(bastionList).To(BeNil())
 			})
 		})
 
@@ -159,9 +210,27 @@ var _ = Describe("MachineList", func() {
 				canceledCtx, cancel := context.WithCancel(ctx)
 				cancel()
 
-				ip, err := bastions.GetBastionIPList(canceledCtx, cluster)
+				bastionList, err := bastions.GetBastions(canceledCtx, cluster)
 				Expect(err).To(HaveOccurred())
-				Expect(ip).To(BeZero())
+				Expect(bastionList).To(BeZero())
+			})
+		})
+	})
+
+	Describe("ClusterNameFromBastionLabel", func() {
+		It("returns the cluster name of a bastion label", func() {
+			clusterName, ok := k8sclient.ClusterNameFromBastionLabel(k8sclient.BastionLabel("test-cluster"))
+			Expect(ok).To(BeTrue())
+			Expect(clusterName).To(Equal("test-cluster"))
+		})
+
+		When("the label is not a bastion label", func() {
+			It("returns false", func() {
+				_, ok := k8sclient.ClusterNameFromBastionLabel("test-cluster-md-0")
+				Expect(ok).To(BeFalse())
+
+				_, ok = k8sclient.ClusterNameFromBastionLabel("-bastion")
+				Expect(ok).To(BeFalse())
 			})
 		})
 	})
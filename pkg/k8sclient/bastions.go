@@ -2,15 +2,40 @@ package k8sclient
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/giantswarm/microerror"
 	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+const (
+	LabelBastionKey = "cluster.x-k8s.io/deployment-name"
+
+	// AnnotationBastionIndex persists the index of the bastion record of a
+	// bastion machine, so that records keep pointing to the same machine
+	// regardless of the order in which machines are listed.
+	AnnotationBastionIndex = "dns-operator-gcp.giantswarm.io/bastion-index"
+
+	bastionLabelSuffix = "-bastion"
 )
 
-const LabelBastionKey = "cluster.x-k8s.io/deployment-name"
+// Bastion is a bastion machine of a cluster. The IP is empty as long as the
+// machine does not have an external address yet.
+type Bastion struct {
+	Name  string
+	Index int
+	IP    string
+}
+
+func (b Bastion) IsPending() bool {
+	return b.IP == ""
+}
 
 type Bastions struct {
 	client    client.Client
@@ -24,34 +49,109 @@ func NewBastions(client client.Client, finalizer string) *Bastions {
 	}
 }
 
-func (b *Bastions) GetBastionIPList(ctx context.Context, cluster *capg.GCPCluster) ([]string, error) {
+// GetBastions returns the bastions of the cluster ordered by their index.
+// Machines which do not have an index yet are assigned the lowest free one.
+// Machines which do not have an external address yet are returned as pending.
+func (b *Bastions) GetBastions(ctx context.Context, cluster *clusterview.Cluster) ([]Bastion, error) {
 	machineList, err := b.getBastionMachineList(ctx, cluster)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	var bastionPublicIPList []string
-
-	for _, machine := range machineList.Items {
-		if len(machine.Status.Addresses) == 0 {
-			return nil, microerror.Mask(errors.New("bastion IP is not yet available"))
+	machines := machineList.Items
+	sort.Slice(machines, func(i, j int) bool {
+		if !machines[i].CreationTimestamp.Equal(&machines[j].CreationTimestamp) {
+			return machines[i].CreationTimestamp.Before(&machines[j].CreationTimestamp)
 		}
+		return machines[i].Name < machines[j].Name
+	})
+
+	indexes, err := b.ensureIndexes(ctx, machines)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var bastions []Bastion
+
+	for _, machine := range machines {
 		if !machine.DeletionTimestamp.IsZero() {
 			continue
 		}
 
+		bastion := Bastion{
+			Name:  machine.Name,
+			Index: indexes[machine.Name],
+		}
 		for _, addr := range machine.Status.Addresses {
 			if addr.Type == "ExternalIP" {
-				bastionPublicIPList = append(bastionPublicIPList, addr.Address)
+				bastion.IP = addr.Address
 				break
 			}
 		}
+		bastions = append(bastions, bastion)
+	}
+
+	sort.Slice(bastions, func(i, j int) bool {
+		return bastions[i].Index < bastions[j].Index
+	})
+
+	return bastions, nil
+}
+
+// ensureIndexes returns the bastion index of every machine. Indexes
+// persisted on the machines are kept, unless they are duplicated. The other
+// machines are assigned the lowest free index, which is persisted on them.
+func (b *Bastions) ensureIndexes(ctx context.Context, machines []capg.GCPMachine) (map[string]int, error) {
+	indexes := map[string]int{}
+	used := map[int]bool{}
+
+	var unindexed []*capg.GCPMachine
+	for i := range machines {
+		machine := &machines[i]
+		index, err := strconv.Atoi(machine.Annotations[AnnotationBastionIndex])
+		if err != nil || index < 1 || used[index] {
+			unindexed = append(unindexed, machine)
+			continue
+		}
+
+		indexes[machine.Name] = index
+		used[index] = true
 	}
 
-	return bastionPublicIPList, nil
+	nextIndex := 1
+	for _, machine := range unindexed {
+		for used[nextIndex] {
+			nextIndex++
+		}
+
+		err := b.setIndex(ctx, machine, nextIndex)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		indexes[machine.Name] = nextIndex
+		used[nextIndex] = true
+	}
+
+	return indexes, nil
 }
 
-func (b *Bastions) getBastionMachineList(ctx context.Context, cluster *capg.GCPCluster) (*capg.GCPMachineList, error) {
+func (b *Bastions) setIndex(ctx context.Context, machine *capg.GCPMachine, index int) error {
+	originalMachine := machine.DeepCopy()
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+	machine.Annotations[AnnotationBastionIndex] = strconv.Itoa(index)
+
+	err := b.client.Patch(ctx, machine, client.MergeFrom(originalMachine))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (b *Bastions) getBastionMachineList(ctx context.Context, cluster *clusterview.Cluster) (*capg.GCPMachineList, error) {
 	machineList := &capg.GCPMachineList{}
 	err := b.client.List(
 		ctx,
@@ -67,5 +167,16 @@ func (b *Bastions) getBastionMachineList(ctx context.Context, cluster *capg.GCPC
 }
 
 func BastionLabel(clusterName string) string {
-	return fmt.Sprintf("%s-bastion", clusterName)
+	return fmt.Sprintf("%s%s", clusterName, bastionLabelSuffix)
+}
+
+// ClusterNameFromBastionLabel is the inverse of BastionLabel. It returns false
+// if the label is not a bastion label.
+func ClusterNameFromBastionLabel(label string) (string, bool) {
+	clusterName := strings.TrimSuffix(label, bastionLabelSuffix)
+	if clusterName == "" || clusterName == label {
+		return "", false
+	}
+
+	return clusterName, true
 }
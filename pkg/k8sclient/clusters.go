@@ -0,0 +1,186 @@
+package k8sclient
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/shard"
+)
+
+const (
+	KindGCPCluster        = "GCPCluster"
+	KindGCPManagedCluster = "GCPManagedCluster"
+)
+
+// The GKE types of CAPG are not part of the CAPG version the operator builds
+// against, so they are accessed as unstructured objects.
+var (
+	GCPManagedClusterGVK      = capg.GroupVersion.WithKind(KindGCPManagedCluster)
+	GCPManagedControlPlaneGVK = capg.GroupVersion.WithKind("GCPManagedControlPlane")
+)
+
+// NewGCPManagedClusterObject returns an empty unstructured GCPManagedCluster.
+func NewGCPManagedClusterObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GCPManagedClusterGVK)
+	return obj
+}
+
+// NewGCPManagedControlPlaneObject returns an empty unstructured
+// GCPManagedControlPlane.
+func NewGCPManagedControlPlaneObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GCPManagedControlPlaneGVK)
+	return obj
+}
+
+// Clusters gets CAPI clusters together with their infrastructure cluster as
+// clusterview.Cluster. The infrastructure cluster is read as unstructured
+// object, so that any infrastructure provider can be served.
+type Clusters struct {
+	client client.Client
+	// infrastructureKinds are the kinds of the infrastructure clusters
+	// served. Clusters of other kinds are ignored.
+	infrastructureKinds map[string]bool
+	// defaultProject is the GCP project of cluster zones, whose
+	// infrastructure cluster has no project.
+	defaultProject string
+	// shard selects the CAPI clusters served. Clusters of other shards are
+	// ignored. It is nil when all clusters are served.
+	shard *shard.Shard
+}
+
+func NewClusters(client client.Client, infrastructureKinds []string, defaultProject string, shard *shard.Shard) *Clusters {
+	kinds := map[string]bool{}
+	for _, kind := range infrastructureKinds {
+		kinds[kind] = true
+	}
+
+	return &Clusters{
+		client:              client,
+		infrastructureKinds: kinds,
+		defaultProject:      defaultProject,
+		shard:               shard,
+	}
+}
+
+// Get returns the view of the CAPI cluster. It is nil, when the cluster has
+// no infrastructure cluster of a served kind yet or belongs to another shard.
+func (c *Clusters) Get(ctx context.Context, namespacedName types.NamespacedName) (*clusterview.Cluster, error) {
+	cluster := &capi.Cluster{}
+	err := c.client.Get(ctx, namespacedName, cluster)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !c.shard.Contains(cluster) {
+		return nil, nil
+	}
+
+	return c.getView(ctx, cluster)
+}
+
+// List returns the views of all CAPI clusters of the shard with an
+// infrastructure cluster of a served kind.
+func (c *Clusters) List(ctx context.Context) ([]clusterview.Cluster, error) {
+	clusterList := &capi.ClusterList{}
+	err := c.client.List(ctx, clusterList)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var views []clusterview.Cluster
+	for i := range clusterList.Items {
+		if !c.shard.Contains(&clusterList.Items[i]) {
+			continue
+		}
+
+		view, err := c.getView(ctx, &clusterList.Items[i])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if view != nil {
+			views = append(views, *view)
+		}
+	}
+
+	return views, nil
+}
+
+func (c *Clusters) AddFinalizer(ctx context.Context, cluster *clusterview.Cluster, finalizer string) error {
+	originalInfrastructure := cluster.Infrastructure.DeepCopy()
+	controllerutil.AddFinalizer(cluster.Infrastructure, finalizer)
+	return c.client.Patch(ctx, cluster.Infrastructure, client.MergeFrom(originalInfrastructure))
+}
+
+func (c *Clusters) RemoveFinalizer(ctx context.Context, cluster *clusterview.Cluster, finalizer string) error {
+	originalInfrastructure := cluster.Infrastructure.DeepCopy()
+	controllerutil.RemoveFinalizer(cluster.Infrastructure, finalizer)
+	return c.client.Patch(ctx, cluster.Infrastructure, client.MergeFrom(originalInfrastructure))
+}
+
+// SetCondition sets the condition on the CAPI cluster. The patch helper only
+// patches the conditions which changed, so conditions owned by other
+// controllers are left untouched.
+func (c *Clusters) SetCondition(ctx context.Context, cluster *capi.Cluster, condition *capi.Condition) error {
+	patchHelper, err := patch.NewHelper(cluster, c.client)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	conditions.Set(cluster, condition)
+
+	err = patchHelper.Patch(ctx, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Clusters) getView(ctx context.Context, cluster *capi.Cluster) (*clusterview.Cluster, error) {
+	ref := cluster.Spec.InfrastructureRef
+	if ref == nil || !c.infrastructureKinds[ref.Kind] {
+		return nil, nil
+	}
+
+	infrastructure := &unstructured.Unstructured{}
+	infrastructure.SetAPIVersion(ref.APIVersion)
+	infrastructure.SetKind(ref.Kind)
+	err := c.client.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: ref.Name}, infrastructure)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Only GKE clusters keep the endpoint in their control plane.
+	var controlPlane *unstructured.Unstructured
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil && ref.Kind == GCPManagedControlPlaneGVK.Kind {
+		controlPlane = NewGCPManagedControlPlaneObject()
+		err = c.client.Get(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: ref.Name}, controlPlane)
+		if k8serrors.IsNotFound(err) {
+			controlPlane = nil
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	view, err := clusterview.New(cluster, infrastructure, controlPlane, c.defaultProject)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return view, nil
+}
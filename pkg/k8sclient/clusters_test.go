@@ -0,0 +1,426 @@
+package k8sclient_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/dns-operator-gcp/controllers"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+	"github.com/giantswarm/dns-operator-gcp/pkg/shard"
+)
+
+var _ = Describe("Clusters", func() {
+	var (
+		ctx context.Context
+
+		client *k8sclient.Clusters
+	)
+
+	createCluster := func(name string, infrastructureRef *corev1.ObjectReference) *capi.Cluster {
+		cluster := &capi.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: capi.ClusterSpec{
+				InfrastructureRef: infrastructureRef,
+			},
+		}
+		Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+		return cluster
+	}
+
+	createGCPCluster := func(name string) {
+		gcpCluster := &capg.GCPCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: capg.GCPClusterSpec{
+				Project: "test-project",
+				Region:  "europe-west1",
+				Network: capg.NetworkSpec{
+					Name: pointerTo("test-network"),
+				},
+				ControlPlaneEndpoint: capi.APIEndpoint{
+					Host: "1.2.3.4",
+					Port: 443,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, gcpCluster)).To(Succeed())
+	}
+
+	gcpClusterRef := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{
+			APIVersion: capg.GroupVersion.String(),
+			Kind:       k8sclient.KindGCPCluster,
+			Name:       name,
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = k8sclient.NewClusters(k8sClient, []string{k8sclient.KindGCPCluster, k8sclient.KindGCPManagedCluster}, "default-project", nil)
+	})
+
+	Describe("Get", func() {
+		BeforeEach(func() {
+			createGCPCluster("test-cluster-infra")
+			createCluster("test-cluster", gcpClusterRef("test-cluster-infra"))
+		})
+
+		It("gets the view of the cluster", func() {
+			view, err := client.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      "test-cluster",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(view).NotTo(BeNil())
+			Expect(view.Name).To(Equal("test-cluster-infra"))
+			Expect(view.Namespace).To(Equal(namespace))
+			Expect(view.Project).To(Equal("test-project"))
+			Expect(view.Region).To(Equal("europe-west1"))
+			Expect(view.Network).To(Equal("test-network"))
+			Expect(view.ControlPlaneEndpoint).To(Equal(capi.APIEndpoint{Host: "1.2.3.4", Port: 443}))
+			Expect(view.Cluster.Name).To(Equal("test-cluster"))
+			Expect(view.Infrastructure.GetKind()).To(Equal(k8sclient.KindGCPCluster))
+		})
+
+		When("the cluster does not exist", func() {
+			It("returns an error", func() {
+				_, err := client.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      "does-not-exist",
+				})
+				Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("the cluster has no infrastructure cluster yet", func() {
+			BeforeEach(func() {
+				createCluster("no-infrastructure", nil)
+				createCluster("missing-infrastructure", gcpClusterRef("does-not-exist"))
+			})
+
+			It("returns no view", func() {
+				view, err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "no-infrastructure"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(view).To(BeNil())
+
+				view, err = client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "missing-infrastructure"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(view).To(BeNil())
+			})
+		})
+
+		When("the infrastructure kind is not served", func() {
+			BeforeEach(func() {
+				client = k8sclient.NewClusters(k8sClient, []string{k8sclient.KindGCPManagedCluster}, "default-project", nil)
+			})
+
+			It("returns no view", func() {
+				view, err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-cluster"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(view).To(BeNil())
+			})
+		})
+
+		When("the cluster is a GKE cluster", func() {
+			BeforeEach(func() {
+				managedCluster := k8sclient.NewGCPManagedClusterObject()
+				managedCluster.SetName("test-gke")
+				managedCluster.SetNamespace(namespace)
+				Expect(unstructured.SetNestedField(managedCluster.Object, "europe-west1", "spec", "region")).To(Succeed())
+				Expect(k8sClient.Create(ctx, managedCluster)).To(Succeed())
+
+				controlPlane := k8sclient.NewGCPManagedControlPlaneObject()
+				controlPlane.SetName("test-gke-control-plane")
+				controlPlane.SetNamespace(namespace)
+				Expect(unstructured.SetNestedField(controlPlane.Object, map[string]interface{}{
+					"host": "5.6.7.8",
+					"port": int64(443),
+				}, "spec", "endpoint")).To(Succeed())
+				Expect(k8sClient.Create(ctx, controlPlane)).To(Succeed())
+
+				cluster := &capi.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-gke",
+						Namespace: namespace,
+					},
+					Spec: capi.ClusterSpec{
+						InfrastructureRef: &corev1.ObjectReference{
+							APIVersion: k8sclient.GCPManagedClusterGVK.GroupVersion().String(),
+							Kind:       k8sclient.KindGCPManagedCluster,
+							Name:       "test-gke",
+						},
+						ControlPlaneRef: &corev1.ObjectReference{
+							APIVersion: k8sclient.GCPManagedControlPlaneGVK.GroupVersion().String(),
+							Kind:       k8sclient.GCPManagedControlPlaneGVK.Kind,
+							Name:       "test-gke-control-plane",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+			})
+
+			It("uses the endpoint of the control plane and the default project", func() {
+				view, err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-gke"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(view).NotTo(BeNil())
+				Expect(view.Project).To(Equal("default-project"))
+				Expect(view.Region).To(Equal("europe-west1"))
+				Expect(view.ControlPlaneEndpoint).To(Equal(capi.APIEndpoint{Host: "5.6.7.8", Port: 443}))
+			})
+		})
+
+		When("the context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("returns an error", func() {
+				view, err := client.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      "test-cluster",
+				})
+				Expect(err).To(MatchError(ContainSubstring("context canceled")))
+				Expect(view).To(BeNil())
+			})
+		})
+	})
+
+	Describe("List", func() {
+		BeforeEach(func() {
+			for _, name := range []string{"test-cluster-1", "test-cluster-2"} {
+				createGCPCluster(name)
+				createCluster(name, gcpClusterRef(name))
+			}
+			createCluster("no-infrastructure", nil)
+		})
+
+		It("lists the clusters with an infrastructure cluster", func() {
+			clusters, err := client.List(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, cluster := range clusters {
+				if cluster.Namespace == namespace {
+					names = append(names, cluster.Name)
+				}
+			}
+			Expect(names).To(ConsistOf("test-cluster-1", "test-cluster-2"))
+		})
+
+		When("the clusters belong to another shard", func() {
+			BeforeEach(func() {
+				otherShard, err := shard.New("other", []string{"other-namespace"}, "")
+				Expect(err).NotTo(HaveOccurred())
+				client = k8sclient.NewClusters(k8sClient, []string{k8sclient.KindGCPCluster}, "default-project", otherShard)
+			})
+
+			It("does not list them", func() {
+				clusters, err := client.List(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				for _, cluster := range clusters {
+					Expect(cluster.Namespace).NotTo(Equal(namespace))
+				}
+			})
+
+			It("does not get them", func() {
+				view, err := client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-cluster-1"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(view).To(BeNil())
+			})
+		})
+
+		When("the context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("returns an error", func() {
+				clusters, err := client.List(ctx)
+				Expect(err).To(MatchError(ContainSubstring("context canceled")))
+				Expect(clusters).To(BeNil())
+			})
+		})
+	})
+
+	Describe("AddFinalizer", func() {
+		var view *clusterview.Cluster
+
+		BeforeEach(func() {
+			createGCPCluster("test-cluster")
+			createCluster("test-cluster", gcpClusterRef("test-cluster"))
+
+			var err error
+			view, err = client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-cluster"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("adds the finalizer to the infrastructure cluster", func() {
+			err := client.AddFinalizer(ctx, view, controllers.FinalizerDNS)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualCluster := &capg.GCPCluster{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "test-cluster", Namespace: namespace}, actualCluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(actualCluster.Finalizers).To(ContainElement(controllers.FinalizerDNS))
+		})
+
+		When("the finalizer already exists", func() {
+			BeforeEach(func() {
+				err := client.AddFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error", func() {
+				err := client.AddFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("returns an error", func() {
+				err := client.AddFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			})
+		})
+	})
+
+	Describe("RemoveFinalizer", func() {
+		var view *clusterview.Cluster
+
+		BeforeEach(func() {
+			createGCPCluster("test-cluster")
+			createCluster("test-cluster", gcpClusterRef("test-cluster"))
+
+			var err error
+			view, err = client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "test-cluster"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.AddFinalizer(ctx, view, controllers.FinalizerDNS)).To(Succeed())
+		})
+
+		It("removes the finalizer from the infrastructure cluster", func() {
+			err := client.RemoveFinalizer(ctx, view, controllers.FinalizerDNS)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualCluster := &capg.GCPCluster{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "test-cluster", Namespace: namespace}, actualCluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(actualCluster.Finalizers).NotTo(ContainElement(controllers.FinalizerDNS))
+		})
+
+		When("the finalizer doesn't exists", func() {
+			BeforeEach(func() {
+				err := client.RemoveFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("does not return an error", func() {
+				err := client.RemoveFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("returns an error", func() {
+				err := client.RemoveFinalizer(ctx, view, controllers.FinalizerDNS)
+				Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			})
+		})
+	})
+
+	Describe("SetCondition", func() {
+		var cluster *capi.Cluster
+
+		BeforeEach(func() {
+			cluster = createCluster("test-cluster", nil)
+		})
+
+		It("sets the condition on the cluster", func() {
+			condition := conditions.FalseCondition("DNSReady", "SomeReason", capi.ConditionSeverityInfo, "some message")
+			err := client.SetCondition(ctx, cluster, condition)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualCluster := &capi.Cluster{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, actualCluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualCondition := conditions.Get(actualCluster, "DNSReady")
+			Expect(actualCondition).NotTo(BeNil())
+			Expect(actualCondition.Status).To(BeEquivalentTo("False"))
+			Expect(actualCondition.Reason).To(Equal("SomeReason"))
+			Expect(actualCondition.Message).To(Equal("some message"))
+		})
+
+		When("the cluster has other conditions", func() {
+			BeforeEach(func() {
+				patchedCluster := cluster.DeepCopy()
+				conditions.MarkTrue(patchedCluster, capi.ReadyCondition)
+				Expect(k8sClient.Status().Patch(ctx, patchedCluster, runtimeclient.MergeFrom(cluster))).To(Succeed())
+				cluster = patchedCluster
+			})
+
+			It("keeps the other conditions", func() {
+				err := client.SetCondition(ctx, cluster, conditions.TrueCondition("DNSReady"))
+				Expect(err).NotTo(HaveOccurred())
+
+				actualCluster := &capi.Cluster{}
+				err = k8sClient.Get(ctx, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, actualCluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(conditions.IsTrue(actualCluster, "DNSReady")).To(BeTrue())
+				Expect(conditions.IsTrue(actualCluster, capi.ReadyCondition)).To(BeTrue())
+			})
+		})
+
+		When("the context is cancelled", func() {
+			BeforeEach(func() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			})
+
+			It("returns an error", func() {
+				err := client.SetCondition(ctx, cluster, conditions.TrueCondition("DNSReady"))
+				Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			})
+		})
+	})
+})
+
+func pointerTo(value string) *string {
+	return &value
+}
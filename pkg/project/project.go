@@ -0,0 +1,34 @@
+package project
+
+var (
+	buildTimestamp = "n/a"
+	description    = "The dns-operator-gcp manages the DNS records of CAPG clusters in Cloud DNS."
+	gitSHA         = "n/a"
+	name           = "dns-operator-gcp"
+	source         = "https://github.com/giantswarm/dns-operator-gcp"
+	version        = "0.6.1-dev"
+)
+
+func BuildTimestamp() string {
+	return buildTimestamp
+}
+
+func Description() string {
+	return description
+}
+
+func GitSHA() string {
+	return gitSHA
+}
+
+func Name() string {
+	return name
+}
+
+func Source() string {
+	return source
+}
+
+func Version() string {
+	return version
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"golang.org/x/time/rate"
+)
+
+const projectsPathSegment = "projects"
+
+// Transport limits the rate of requests sent to Cloud DNS with a token
+// bucket per GCP project, as Cloud DNS quotas are enforced per project.
+// Requests wait for a token, until their context is done.
+type Transport struct {
+	base  http.RoundTripper
+	limit rate.Limit
+	burst int
+
+	mutex    sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func NewTransport(base http.RoundTripper, requestsPerSecond float64, burst int) *Transport {
+	return &Transport{
+		base:     base,
+		limit:    rate.Limit(requestsPerSecond),
+		burst:    burst,
+		limiters: map[string]*rate.Limiter{},
+	}
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	limiter := t.getLimiter(projectFromPath(request.URL.Path))
+
+	err := limiter.Wait(request.Context())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return t.base.RoundTrip(request)
+}
+
+func (t *Transport) getLimiter(project string) *rate.Limiter {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	limiter, ok := t.limiters[project]
+	if !ok {
+		limiter = rate.NewLimiter(t.limit, t.burst)
+		t.limiters[project] = limiter
+	}
+
+	return limiter
+}
+
+// projectFromPath returns the project of Cloud DNS API paths, which look like
+// /dns/v1/projects/{project}/managedZones/{zone}. Requests without a project
+// share a single bucket.
+func projectFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == projectsPathSegment {
+			return segments[i+1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/ratelimit"
+)
+
+var _ = Describe("Transport", func() {
+	var (
+		server     *httptest.Server
+		httpClient *http.Client
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		// Allow a single request per project for the duration of the test
+		transport := ratelimit.NewTransport(http.DefaultTransport, 0.001, 1)
+		httpClient = &http.Client{Transport: transport}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func(path string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+		defer cancel()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		response, err := httpClient.Do(request)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	It("limits the requests per project", func() {
+		Expect(get("/dns/v1/projects/first/managedZones")).To(Succeed())
+		Expect(get("/dns/v1/projects/first/managedZones/zone/rrsets")).NotTo(Succeed())
+	})
+
+	It("does not limit requests of other projects", func() {
+		Expect(get("/dns/v1/projects/first/managedZones")).To(Succeed())
+		Expect(get("/dns/v1/projects/second/managedZones")).To(Succeed())
+	})
+
+	It("shares a bucket between requests without a project", func() {
+		Expect(get("/some/path")).To(Succeed())
+		Expect(get("/other/path")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,264 @@
+package registrar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-logr/logr"
+	clouddns "google.golang.org/api/dns/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+const (
+	// ACMEChallengeLabel is the first label of the TXT records of ACME DNS-01
+	// challenges.
+	ACMEChallengeLabel = "_acme-challenge"
+
+	acmeChallengeTTL = 60
+)
+
+//counterfeiter:generate . ClusterLister
+type ClusterLister interface {
+	List(context.Context) ([]clusterview.Cluster, error)
+}
+
+// ACMEChallengeRequest is a DNS-01 challenge of an ACME certificate order.
+type ACMEChallengeRequest struct {
+	// ID identifies the challenge in the audit trail.
+	ID string
+	// FQDN is the fully qualified name of the TXT record, e.g.
+	// _acme-challenge.test.example.com.
+	FQDN string
+	// Key is the value of the TXT record.
+	Key string
+	// Namespace is the namespace of the certificate. Challenges are only
+	// presented for the cluster in the same namespace, unless cross namespace
+	// challenges are allowed.
+	Namespace string
+}
+
+// ACMEChallenge presents and cleans up the TXT records of ACME DNS-01
+// challenges in the cluster zones, so that clusters can get certificates for
+// their domain, e.g. for *.<cluster>.<base domain>. Challenges are only
+// presented in zones the operator created or adopted for an existing
+// cluster.
+type ACMEChallenge struct {
+	baseDomain string
+	clusters   ClusterLister
+	// crossNamespace allows certificates to get challenges presented for a
+	// cluster in another namespace. Otherwise anyone allowed to create
+	// certificates in a namespace could get certificates for the domains of
+	// the clusters in all namespaces.
+	crossNamespace bool
+	// auditSink is optional. When it is set, the challenge records are
+	// recorded in it.
+	auditSink  audit.Sink
+	dnsService *clouddns.Service
+
+	// mutex serializes the read-modify-write of the TXT records, which
+	// contain the keys of all pending challenges of the same name, e.g. of
+	// the cluster domain and its wildcard.
+	mutex sync.Mutex
+}
+
+func NewACMEChallenge(baseDomain string, clusters ClusterLister, crossNamespace bool, auditSink audit.Sink, dnsService *clouddns.Service) *ACMEChallenge {
+	return &ACMEChallenge{
+		baseDomain:     baseDomain,
+		clusters:       clusters,
+		crossNamespace: crossNamespace,
+		auditSink:      auditSink,
+		dnsService:     dnsService,
+	}
+}
+
+// Present adds the key to the TXT record of the challenge.
+func (r *ACMEChallenge) Present(ctx context.Context, request ACMEChallengeRequest) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cluster, err := r.findCluster(ctx, request)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ctx = r.auditContext(ctx, cluster, request)
+	logger := r.getLogger(ctx, request)
+	logger.Info("Presenting challenge")
+
+	err = r.checkZone(ctx, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	value := strconv.Quote(request.Key)
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(cluster.Project, cluster.Name, request.FQDN, RecordTXT).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		record := &clouddns.ResourceRecordSet{
+			Name:    request.FQDN,
+			Type:    RecordTXT,
+			Ttl:     acmeChallengeTTL,
+			Rrdatas: []string{value},
+		}
+		return microerror.Mask(createRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, rrdata := range existingRecord.Rrdatas {
+		if rrdata == value {
+			logger.Info("Skipping. Challenge already presented")
+			return nil
+		}
+	}
+
+	record := &clouddns.ResourceRecordSet{
+		Name:    request.FQDN,
+		Type:    RecordTXT,
+		Ttl:     acmeChallengeTTL,
+		Rrdatas: append(append([]string{}, existingRecord.Rrdatas...), value),
+	}
+	return microerror.Mask(patchRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord, record))
+}
+
+// CleanUp removes the key from the TXT record of the challenge. The record is
+// deleted together with its last key.
+func (r *ACMEChallenge) CleanUp(ctx context.Context, request ACMEChallengeRequest) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cluster, err := r.findCluster(ctx, request)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ctx = r.auditContext(ctx, cluster, request)
+	logger := r.getLogger(ctx, request)
+	logger.Info("Cleaning up challenge")
+
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(cluster.Project, cluster.Name, request.FQDN, RecordTXT).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		logger.Info("Skipping. Challenge already cleaned up")
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	value := strconv.Quote(request.Key)
+	var rrdatas []string
+	for _, rrdata := range existingRecord.Rrdatas {
+		if rrdata != value {
+			rrdatas = append(rrdatas, rrdata)
+		}
+	}
+
+	if len(rrdatas) == len(existingRecord.Rrdatas) {
+		logger.Info("Skipping. Challenge already cleaned up")
+		return nil
+	}
+
+	if len(rrdatas) == 0 {
+		err = deleteRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord)
+		if hasHttpCode(err, http.StatusNotFound) {
+			return nil
+		}
+		return microerror.Mask(err)
+	}
+
+	record := &clouddns.ResourceRecordSet{
+		Name:    request.FQDN,
+		Type:    RecordTXT,
+		Ttl:     acmeChallengeTTL,
+		Rrdatas: rrdatas,
+	}
+	return microerror.Mask(patchRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord, record))
+}
+
+// findCluster returns the cluster, whose domain contains the challenge, in
+// the namespace of the certificate. When cross namespace challenges are
+// allowed, a cluster of the name in another namespace is returned, as long as
+// the name is unique.
+func (r *ACMEChallenge) findCluster(ctx context.Context, request ACMEChallengeRequest) (*clusterview.Cluster, error) {
+	fqdn := strings.ToLower(request.FQDN)
+	baseDomainSuffix := "." + strings.ToLower(r.baseDomain) + "."
+	if !strings.HasPrefix(fqdn, ACMEChallengeLabel+".") || !strings.HasSuffix(fqdn, baseDomainSuffix) {
+		return nil, microerror.Mask(fmt.Errorf("challenge %s is not in a cluster domain under %s", request.FQDN, r.baseDomain))
+	}
+
+	labels := strings.Split(strings.TrimSuffix(fqdn, baseDomainSuffix), ".")
+	if len(labels) < 2 {
+		return nil, microerror.Mask(fmt.Errorf("challenge %s is not in a cluster domain under %s", request.FQDN, r.baseDomain))
+	}
+	clusterName := labels[len(labels)-1]
+
+	clusters, err := r.clusters.List(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var candidates []clusterview.Cluster
+	for _, cluster := range clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+		if cluster.Namespace == request.Namespace {
+			return &cluster, nil
+		}
+		candidates = append(candidates, cluster)
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, microerror.Mask(fmt.Errorf("cluster %q of challenge %s does not exist", clusterName, request.FQDN))
+	case !r.crossNamespace:
+		return nil, microerror.Mask(fmt.Errorf("cluster %q of challenge %s does not exist in namespace %q of the certificate", clusterName, request.FQDN, request.Namespace))
+	case len(candidates) == 1:
+		return &candidates[0], nil
+	default:
+		return nil, microerror.Mask(fmt.Errorf("clusters named %q exist in multiple namespaces, request the certificate in the namespace of the cluster", clusterName))
+	}
+}
+
+// checkZone makes sure the challenge is only presented in the zone of the
+// cluster, which the operator created or adopted.
+func (r *ACMEChallenge) checkZone(ctx context.Context, cluster *clusterview.Cluster) error {
+	zone, err := r.dnsService.ManagedZones.Get(cluster.Project, cluster.Name).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	domain := fmt.Sprintf("%s.%s.", cluster.Name, r.baseDomain)
+	if !strings.EqualFold(zone.DnsName, domain) || (!IsOperatorZone(zone) && zone.Labels[ZoneLabelAdopted] != "true") {
+		message := fmt.Sprintf("zone %q for %s is not managed by the operator, expected a zone for %s", zone.Name, zone.DnsName, domain)
+		return microerror.Mask(NewConflictError(ReasonZoneConflict, message))
+	}
+
+	return nil
+}
+
+func (r *ACMEChallenge) auditContext(ctx context.Context, cluster *clusterview.Cluster, request ACMEChallengeRequest) context.Context {
+	if r.auditSink == nil {
+		return ctx
+	}
+
+	clusterName := fmt.Sprintf("%s/%s", cluster.Namespace, cluster.Name)
+	return audit.IntoContext(ctx, audit.NewRecorder(r.auditSink, clusterName, request.ID))
+}
+
+func (r *ACMEChallenge) getLogger(ctx context.Context, request ACMEChallengeRequest) logr.Logger {
+	logger := log.FromContext(ctx)
+	return logger.WithName("acme-challenge").WithValues("record", request.FQDN, "challenge", request.ID)
+}
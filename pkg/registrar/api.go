@@ -3,71 +3,154 @@ package registrar
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/giantswarm/microerror"
 	"github.com/go-logr/logr"
 	clouddns "google.golang.org/api/dns/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+const (
+	EndpointAPI = "api"
+
+	defaultAPIPort = 443
 )
 
-const EndpointAPI = "api"
+// HealthChecker probes the primary and backup endpoints of the api record and
+// decides which of them the record points to.
+//
+//counterfeiter:generate . HealthChecker
+type HealthChecker interface {
+	Watch(cluster *clusterview.Cluster, primary, backup string)
+	UseBackup(cluster *clusterview.Cluster) bool
+	Forget(cluster *clusterview.Cluster)
+}
 
 type API struct {
-	baseDomain string
-	dnsService *clouddns.Service
+	baseDomain    string
+	healthChecker HealthChecker
+	dnsService    *clouddns.Service
 }
 
-func NewAPI(baseDomain string, dnsService *clouddns.Service) *API {
+// NewAPI returns the api registrar. The health checker is optional, without
+// it backup endpoints are ignored.
+func NewAPI(baseDomain string, healthChecker HealthChecker, dnsService *clouddns.Service) *API {
 	return &API{
-		baseDomain: baseDomain,
-		dnsService: dnsService,
+		baseDomain:    baseDomain,
+		healthChecker: healthChecker,
+		dnsService:    dnsService,
 	}
 }
 
-func (r *API) Register(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *API) Name() string {
+	return NameAPI
+}
+
+func (r *API) Dependencies() []string {
+	return []string{NameZone}
+}
+
+// Register publishes the api record of the cluster. It points to the control
+// plane endpoint, unless the cluster declares a routing policy in the
+// annotation.APIRoutingPolicy annotation or the health checker failed over
+// to the backup endpoint of the annotation.APIBackupEndpoint annotation.
+func (r *API) Register(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
 	logger.Info("Registering record")
 	defer logger.Info("Done registering record")
 
-	if cluster.Spec.ControlPlaneEndpoint.Host == "" {
-		logger.Info("Skipping. Cluster does not have control plane endpoint yet")
-		return nil
+	apiDomain := fmt.Sprintf("%s.%s.%s.", EndpointAPI, cluster.Name, r.baseDomain)
+
+	policy, err := annotation.GetRoutingPolicy(cluster.Annotations, annotation.APIRoutingPolicy)
+	if err != nil {
+		return microerror.Mask(NewInvalidConfigError(ReasonInvalidRoutingPolicy, err.Error()))
 	}
 
-	apiDomain := fmt.Sprintf("%s.%s.%s.", EndpointAPI, cluster.Name, r.baseDomain)
+	backup := cluster.Annotations[annotation.APIBackupEndpoint]
+	if policy != nil && backup != "" {
+		message := fmt.Sprintf("annotations %s and %s can't be combined", annotation.APIRoutingPolicy, annotation.APIBackupEndpoint)
+		return microerror.Mask(NewInvalidConfigError(ReasonInvalidBackupEndpoint, message))
+	}
 
 	record := &clouddns.ResourceRecordSet{
 		Name: apiDomain,
-		Rrdatas: []string{
-			cluster.Spec.ControlPlaneEndpoint.Host,
-		},
 		Type: RecordA,
 	}
-	_, err := r.dnsService.ResourceRecordSets.Create(cluster.Spec.Project, cluster.Name, record).
+	switch {
+	case policy != nil:
+		record.RoutingPolicy = newRRSetRoutingPolicy(policy)
+	case cluster.ControlPlaneEndpoint.Host != "":
+		endpoint, err := r.selectEndpoint(logger, cluster, backup)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		record.Rrdatas = []string{endpoint}
+	default:
+		logger.Info("Skipping. Cluster does not have control plane endpoint yet")
+		return nil
+	}
+
+	err = createRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record)
+	if hasHttpCode(err, http.StatusConflict) {
+		return r.updateRecord(ctx, logger, cluster, record)
+	}
+	return microerror.Mask(err)
+}
+
+// updateRecord makes sure an existing record points to the current control
+// plane endpoint or routing policy. The record may be stale, e.g. when the
+// zone was retained from a previous cluster of the same name. Records in
+// adopted zones are not overwritten.
+func (r *API) updateRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, record *clouddns.ResourceRecordSet) error {
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(cluster.Project, cluster.Name, record.Name, RecordA).
 		Context(ctx).
 		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
 
-	if hasHttpCode(err, http.StatusConflict) {
+	if equalRecordData(existingRecord, record) {
 		logger.Info("Skipping. Record already exists")
 		return nil
 	}
+
+	err = checkRecordUpdate(ctx, r.dnsService, cluster, existingRecord, record)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// A record set either has data or a routing policy, so the one not
+	// used anymore has to be cleared when switching between them.
+	if record.RoutingPolicy != nil {
+		record.NullFields = []string{"Rrdatas"}
+	} else {
+		record.NullFields = []string{"RoutingPolicy"}
+	}
+
+	logger.Info("Record exists but is not up to date. Updating record", "data", recordData(record))
+	err = patchRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord, record)
 	return microerror.Mask(err)
 }
 
-func (r *API) Unregister(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *API) Unregister(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
 	logger.Info("Unregistering record")
 	defer logger.Info("Done unregistering record")
 
-	apiDomain := fmt.Sprintf("%s.%s.%s.", EndpointAPI, cluster.Name, r.baseDomain)
-	_, err := r.dnsService.ResourceRecordSets.Delete(cluster.Spec.Project, cluster.Name, apiDomain, RecordA).
-		Context(ctx).
-		Do()
+	if r.healthChecker != nil {
+		r.healthChecker.Forget(cluster)
+	}
 
+	apiDomain := fmt.Sprintf("%s.%s.%s.", EndpointAPI, cluster.Name, r.baseDomain)
+	err := deleteRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, &clouddns.ResourceRecordSet{Name: apiDomain, Type: RecordA})
 	if hasHttpCode(err, http.StatusNotFound) {
 		logger.Info("Skipping. Record already unregistered")
 		return nil
@@ -76,7 +159,92 @@ func (r *API) Unregister(ctx context.Context, cluster *capg.GCPCluster) error {
 	return microerror.Mask(err)
 }
 
+// selectEndpoint returns the address the api record points to. It is the
+// control plane endpoint, unless the health checker failed over to the
+// backup endpoint of the cluster.
+func (r *API) selectEndpoint(logger logr.Logger, cluster *clusterview.Cluster, backup string) (string, error) {
+	primaryHost := cluster.ControlPlaneEndpoint.Host
+	if r.healthChecker == nil {
+		if backup != "" {
+			logger.Info("Ignoring backup endpoint. API health checks are disabled")
+		}
+		return primaryHost, nil
+	}
+
+	if backup == "" {
+		r.healthChecker.Forget(cluster)
+		return primaryHost, nil
+	}
+
+	port := strconv.Itoa(defaultAPIPort)
+	if cluster.ControlPlaneEndpoint.Port != 0 {
+		port = strconv.Itoa(int(cluster.ControlPlaneEndpoint.Port))
+	}
+
+	backupHost, backupAddress, err := parseBackupEndpoint(backup, port)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	r.healthChecker.Watch(cluster, net.JoinHostPort(primaryHost, port), backupAddress)
+	if r.healthChecker.UseBackup(cluster) {
+		logger.Info("Primary endpoint is unhealthy. Pointing record to backup endpoint", "primary", primaryHost, "backup", backupHost)
+		return backupHost, nil
+	}
+
+	return primaryHost, nil
+}
+
+// parseBackupEndpoint returns the host and the address to probe of a backup
+// endpoint given as host or host:port.
+func parseBackupEndpoint(backup, defaultPort string) (string, string, error) {
+	host, port, err := net.SplitHostPort(backup)
+	if err != nil {
+		host, port = backup, defaultPort
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.To4() == nil {
+		message := fmt.Sprintf("annotation %s: %q is not an IPv4 address", annotation.APIBackupEndpoint, backup)
+		return "", "", NewInvalidConfigError(ReasonInvalidBackupEndpoint, message)
+	}
+
+	return host, net.JoinHostPort(host, port), nil
+}
+
 func (r *API) getLogger(ctx context.Context) logr.Logger {
 	logger := log.FromContext(ctx)
 	return logger.WithName("api-registrar")
 }
+
+// newRRSetRoutingPolicy converts the routing policy of the annotation to a
+// Cloud DNS routing policy. Geo policies have a single item per location, so
+// endpoints of the same location are merged.
+func newRRSetRoutingPolicy(policy *annotation.RoutingPolicy) *clouddns.RRSetRoutingPolicy {
+	if policy.Type == annotation.RoutingPolicyWeighted {
+		wrr := &clouddns.RRSetRoutingPolicyWrrPolicy{}
+		for _, endpoint := range policy.Endpoints {
+			wrr.Items = append(wrr.Items, &clouddns.RRSetRoutingPolicyWrrPolicyWrrPolicyItem{
+				Rrdatas: []string{endpoint.Address},
+				Weight:  endpoint.Weight,
+				// The weight must be sent, even if it is zero, to
+				// disable the endpoint.
+				ForceSendFields: []string{"Weight"},
+			})
+		}
+		return &clouddns.RRSetRoutingPolicy{Wrr: wrr}
+	}
+
+	geo := &clouddns.RRSetRoutingPolicyGeoPolicy{}
+	items := map[string]*clouddns.RRSetRoutingPolicyGeoPolicyGeoPolicyItem{}
+	for _, endpoint := range policy.Endpoints {
+		item, ok := items[endpoint.Location]
+		if !ok {
+			item = &clouddns.RRSetRoutingPolicyGeoPolicyGeoPolicyItem{Location: endpoint.Location}
+			items[endpoint.Location] = item
+			geo.Items = append(geo.Items, item)
+		}
+		item.Rrdatas = append(item.Rrdatas, endpoint.Address)
+	}
+	return &clouddns.RRSetRoutingPolicy{Geo: geo}
+}
@@ -0,0 +1,411 @@
+package registrar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-logr/logr"
+	clouddns "google.golang.org/api/dns/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+)
+
+const (
+	EndpointAPIInternal = "api-internal"
+
+	PrivateZoneDescription = "Private DNS zone for cluster, managed by GCP DNS operator."
+
+	ReasonNetworkPending = "NetworkPending"
+
+	privateZoneSuffix     = "-internal"
+	privateZoneVisibility = "private"
+
+	// maxZoneNameLength is the maximum length of Cloud DNS zone names.
+	maxZoneNameLength = 63
+	// zoneNameHashLength is the length of the hash, which replaces the end
+	// of cluster names too long for the name of the private zone.
+	zoneNameHashLength = 8
+
+	networkPendingRequeueAfter = time.Second * 30
+)
+
+// AddressResolver resolves the Compute Engine address of the internal load
+// balancer, which CAPG references by its self link.
+//
+//counterfeiter:generate . AddressResolver
+type AddressResolver interface {
+	ResolveAddress(ctx context.Context, selfLink string) (string, error)
+}
+
+// APIInternal publishes the api-internal record pointing to the internal load
+// balancer of the Kubernetes API, so that nodes and tooling inside the VPC
+// don't leave it to reach the API. The record is either published in the
+// public cluster zone or in a private zone for the cluster domain, which is
+// only visible in the network of the cluster.
+type APIInternal struct {
+	baseDomain string
+	// managementCluster identifies the management cluster running the
+	// operator in the labels of private zones. It is optional.
+	managementCluster string
+	// privateZone publishes the record in a private zone instead of the
+	// cluster zone.
+	privateZone     bool
+	addressResolver AddressResolver
+	dnsService      *clouddns.Service
+}
+
+func NewAPIInternal(baseDomain, managementCluster string, privateZone bool, addressResolver AddressResolver, dnsService *clouddns.Service) *APIInternal {
+	return &APIInternal{
+		baseDomain:        baseDomain,
+		managementCluster: managementCluster,
+		privateZone:       privateZone,
+		addressResolver:   addressResolver,
+		dnsService:        dnsService,
+	}
+}
+
+func (r *APIInternal) Name() string {
+	return NameAPIInternal
+}
+
+// Dependencies only contains the zone registrar, when the record is published
+// in the cluster zone. The private zone is managed by the registrar itself.
+func (r *APIInternal) Dependencies() []string {
+	if r.privateZone {
+		return nil
+	}
+	return []string{NameZone}
+}
+
+func (r *APIInternal) Register(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := r.getLogger(ctx)
+
+	logger.Info("Registering record")
+	defer logger.Info("Done registering record")
+
+	if cluster.InternalAPIAddress == "" {
+		logger.Info("Skipping. Cluster does not have an internal api endpoint")
+		return nil
+	}
+
+	address, err := r.resolveAddress(ctx, cluster.InternalAPIAddress)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	zoneName := cluster.Name
+	if r.privateZone {
+		zoneName = PrivateZoneName(cluster.Name)
+		err = r.ensurePrivateZone(ctx, logger, cluster)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	record := &clouddns.ResourceRecordSet{
+		Name:    r.getRecordName(cluster),
+		Rrdatas: []string{address},
+		Type:    RecordA,
+	}
+	err = createRecordSet(ctx, r.dnsService, cluster.Project, zoneName, record)
+	if hasHttpCode(err, http.StatusConflict) {
+		err = r.updateRecord(ctx, logger, cluster, zoneName, record)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The record published in the cluster zone before the cluster was
+	// switched to the private zone would keep resolving publicly.
+	if r.privateZone {
+		err = r.deleteRecord(ctx, logger, cluster, cluster.Name)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// Unregister removes the record from the cluster zone as well as the private
+// zone, so that no record is left behind when the zone of the record was
+// switched in the meantime.
+func (r *APIInternal) Unregister(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := r.getLogger(ctx)
+
+	logger.Info("Unregistering record")
+	defer logger.Info("Done unregistering record")
+
+	err := r.deleteRecord(ctx, logger, cluster, cluster.Name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	zone, err := r.getPrivateZone(ctx, logger, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if zone == nil {
+		return nil
+	}
+
+	err = r.deleteRecord(ctx, logger, cluster, zone.Name)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger.Info("Deleting private zone", "zone", zone.Name)
+	err = r.dnsService.ManagedZones.Delete(cluster.Project, zone.Name).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil
+	}
+	return microerror.Mask(err)
+}
+
+// Retain labels the private zone as retained, so that the sweeper doesn't
+// delete it once the cluster is gone.
+func (r *APIInternal) Retain(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := r.getLogger(ctx)
+
+	zone, err := r.getPrivateZone(ctx, logger, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if zone == nil {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for key, value := range zone.Labels {
+		labels[key] = value
+	}
+	labels[ZoneLabelRetained] = "true"
+
+	logger.Info("Retaining private zone", "zone", zone.Name)
+	_, err = r.dnsService.ManagedZones.Patch(cluster.Project, zone.Name, &clouddns.ManagedZone{Labels: labels}).
+		Context(ctx).
+		Do()
+	return microerror.Mask(err)
+}
+
+// UnregisterDelegation does nothing, as private zones are not delegated.
+func (r *APIInternal) UnregisterDelegation(ctx context.Context, cluster *clusterview.Cluster) error {
+	return nil
+}
+
+// updateRecord makes sure an existing record points to the internal load
+// balancer. Records in adopted cluster zones are not overwritten.
+func (r *APIInternal) updateRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, zoneName string, record *clouddns.ResourceRecordSet) error {
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(cluster.Project, zoneName, record.Name, RecordA).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if equalRrdatas(existingRecord.Rrdatas, record.Rrdatas) {
+		logger.Info("Skipping. Record already exists")
+		return nil
+	}
+
+	if !r.privateZone {
+		err = checkRecordUpdate(ctx, r.dnsService, cluster, existingRecord, record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	logger.Info("Record exists but is not up to date. Updating record", "ips", record.Rrdatas)
+	err = patchRecordSet(ctx, r.dnsService, cluster.Project, zoneName, existingRecord, record)
+	return microerror.Mask(err)
+}
+
+func (r *APIInternal) deleteRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, zoneName string) error {
+	err := deleteRecordSet(ctx, r.dnsService, cluster.Project, zoneName, &clouddns.ResourceRecordSet{Name: r.getRecordName(cluster), Type: RecordA})
+	if hasHttpCode(err, http.StatusNotFound) {
+		logger.Info("Skipping. Record already unregistered", "zone", zoneName)
+		return nil
+	}
+
+	return microerror.Mask(err)
+}
+
+// ensurePrivateZone creates the private zone of the cluster domain, which is
+// visible in the network of the cluster. Existing zones of the same name must
+// have been created by the operator.
+func (r *APIInternal) ensurePrivateZone(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster) error {
+	if cluster.Network == "" {
+		message := fmt.Sprintf("network of cluster %q is not yet available", cluster.Name)
+		return microerror.Mask(NewPendingError(ReasonNetworkPending, message, networkPendingRequeueAfter))
+	}
+
+	domain := fmt.Sprintf("%s.%s.", cluster.Name, r.baseDomain)
+	networkURL := getNetworkURL(cluster)
+
+	zone := &clouddns.ManagedZone{
+		Name:        PrivateZoneName(cluster.Name),
+		DnsName:     domain,
+		Description: PrivateZoneDescription,
+		Visibility:  privateZoneVisibility,
+		Labels:      getClusterLabels(cluster, r.managementCluster),
+		PrivateVisibilityConfig: &clouddns.ManagedZonePrivateVisibilityConfig{
+			Networks: []*clouddns.ManagedZonePrivateVisibilityConfigNetwork{
+				{NetworkUrl: networkURL},
+			},
+		},
+	}
+	zone.Labels[ZoneLabelManagedBy] = ZoneManagedBy
+	zone.Labels[ZoneLabelPrivate] = "true"
+
+	_, err := r.dnsService.ManagedZones.Create(cluster.Project, zone).
+		Context(ctx).
+		Do()
+	if !hasHttpCode(err, http.StatusConflict) {
+		return microerror.Mask(err)
+	}
+
+	existingZone, err := r.dnsService.ManagedZones.Get(cluster.Project, zone.Name).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !isPrivateZoneOf(existingZone, cluster) || !strings.EqualFold(existingZone.DnsName, domain) {
+		message := fmt.Sprintf("zone %q exists for %s with %s visibility and is not the private zone of the cluster, expected %s with %s visibility",
+			existingZone.Name, existingZone.DnsName, existingZone.Visibility, domain, privateZoneVisibility)
+		return microerror.Mask(NewConflictError(ReasonZoneConflict, message))
+	}
+
+	patch := &clouddns.ManagedZone{}
+
+	// The zone was retained for a previously deleted cluster of the same
+	// name, which the cluster takes over.
+	if _, ok := existingZone.Labels[ZoneLabelRetained]; ok {
+		patch.Labels = map[string]string{}
+		for key, value := range existingZone.Labels {
+			patch.Labels[key] = value
+		}
+		delete(patch.Labels, ZoneLabelRetained)
+		logger.Info("Removing retained label of existing private zone")
+	}
+
+	if !hasNetwork(existingZone, networkURL) {
+		patch.PrivateVisibilityConfig = zone.PrivateVisibilityConfig
+		logger.Info("Updating network of existing private zone", "network", networkURL)
+	}
+
+	if patch.Labels == nil && patch.PrivateVisibilityConfig == nil {
+		return nil
+	}
+
+	_, err = r.dnsService.ManagedZones.Patch(cluster.Project, zone.Name, patch).
+		Context(ctx).
+		Do()
+	return microerror.Mask(err)
+}
+
+// getPrivateZone returns the private zone of the cluster. It returns nil,
+// when the zone does not exist or was not created for the cluster.
+func (r *APIInternal) getPrivateZone(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster) (*clouddns.ManagedZone, error) {
+	zoneName := PrivateZoneName(cluster.Name)
+	zone, err := r.dnsService.ManagedZones.Get(cluster.Project, zoneName).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !isPrivateZoneOf(zone, cluster) {
+		logger.Info("Skipping. Zone is not the private zone of the cluster", "zone", zoneName)
+		return nil, nil
+	}
+
+	return zone, nil
+}
+
+// resolveAddress returns the IP address of the internal load balancer, which
+// is either given directly or as self link of a Compute Engine address.
+func (r *APIInternal) resolveAddress(ctx context.Context, address string) (string, error) {
+	if net.ParseIP(address) != nil {
+		return address, nil
+	}
+
+	if r.addressResolver == nil {
+		return "", microerror.Mask(fmt.Errorf("can't resolve internal api address %q without address resolver", address))
+	}
+
+	ip, err := r.addressResolver.ResolveAddress(ctx, address)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return ip, nil
+}
+
+func (r *APIInternal) getRecordName(cluster *clusterview.Cluster) string {
+	return fmt.Sprintf("%s.%s.%s.", EndpointAPIInternal, cluster.Name, r.baseDomain)
+}
+
+func (r *APIInternal) getLogger(ctx context.Context) logr.Logger {
+	logger := log.FromContext(ctx)
+	return logger.WithName("api-internal-registrar")
+}
+
+// PrivateZoneName returns the name of the private zone of the cluster. Cluster
+// names too long for the suffix are shortened and end with a hash of the full
+// name instead, so that the zone names of clusters sharing a prefix differ.
+func PrivateZoneName(clusterName string) string {
+	name := clusterName + privateZoneSuffix
+	if len(name) <= maxZoneNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(clusterName))
+	prefix := clusterName[:maxZoneNameLength-len(privateZoneSuffix)-zoneNameHashLength-1]
+	return fmt.Sprintf("%s-%s%s", prefix, hex.EncodeToString(sum[:])[:zoneNameHashLength], privateZoneSuffix)
+}
+
+// isPrivateZoneOf returns whether the operator created the zone as private
+// zone of the cluster. The name of the private zone can also be the name of
+// the zone of another cluster, e.g. the private zone of cluster foo and the
+// zone of cluster foo-internal, so the zone is identified by its labels.
+func isPrivateZoneOf(zone *clouddns.ManagedZone, cluster *clusterview.Cluster) bool {
+	return IsOperatorZone(zone) &&
+		zone.Labels[ZoneLabelPrivate] == "true" &&
+		zone.Labels[ZoneLabelClusterName] == SanitizeLabelValue(cluster.Name) &&
+		zone.Labels[ZoneLabelClusterNamespace] == SanitizeLabelValue(cluster.Namespace) &&
+		strings.EqualFold(zone.Visibility, privateZoneVisibility)
+}
+
+func hasNetwork(zone *clouddns.ManagedZone, networkURL string) bool {
+	if zone.PrivateVisibilityConfig == nil {
+		return false
+	}
+
+	for _, network := range zone.PrivateVisibilityConfig.Networks {
+		if network.NetworkUrl == networkURL {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getNetworkURL returns the URL of the network of the cluster, which lives in
+// the project of the cluster.
+func getNetworkURL(cluster *clusterview.Cluster) string {
+	return fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", cluster.Project, cluster.Network)
+}
@@ -4,18 +4,37 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/giantswarm/microerror"
 	"github.com/go-logr/logr"
 	clouddns "google.golang.org/api/dns/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+)
+
+const (
+	// EndpointBastions is a round robin record containing the IPs of all
+	// bastions of the cluster.
+	EndpointBastions = "bastion"
+
+	ReasonBastionsPending = "BastionsPending"
+
+	bastionsPendingRequeueAfter = time.Second * 30
 )
 
+// bastionLabelRegexp matches the label of the bastion records relative to the
+// cluster domain, e.g. bastion or bastion1.
+var bastionLabelRegexp = regexp.MustCompile(`^bastion\d*$`)
+
 //counterfeiter:generate . BastionsClient
 type BastionsClient interface {
-	GetBastionIPList(ctx context.Context, cluster *capg.GCPCluster) ([]string, error)
+	GetBastions(ctx context.Context, cluster *clusterview.Cluster) ([]k8sclient.Bastion, error)
 }
 
 type Bastion struct {
@@ -32,55 +51,110 @@ func NewBastion(baseDomain string, bastionsClient BastionsClient, dnsService *cl
 	}
 }
 
-func (r *Bastion) Register(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *Bastion) Name() string {
+	return NameBastion
+}
+
+func (r *Bastion) Dependencies() []string {
+	return []string{NameZone}
+}
+
+// Register reconciles the bastion records of the cluster zone, so that there
+// is exactly one record for every bastion, named after the index persisted on
+// the bastion machine, and a round robin record for all bastions. Records of
+// bastions which no longer exist are removed, as their IPs may be handed out
+// to someone else. Bastions which do not have an IP yet are skipped and
+// reported with a PendingError once the ready ones are registered.
+func (r *Bastion) Register(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
-	bastionIPList, err := r.bastionsClient.GetBastionIPList(ctx, cluster)
+	bastions, err := r.bastionsClient.GetBastions(ctx, cluster)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	if len(bastionIPList) == 0 {
-		logger.Info("No bastion resource found, skipping DNS record creation")
-		return nil
+	if len(bastions) == 0 {
+		logger.Info("No bastion resource found")
+	}
+
+	existingRecords, err := r.listBastionRecords(ctx, cluster)
+	if err != nil {
+		return microerror.Mask(err)
 	}
-	for i, bastionIP := range bastionIPList {
-		bastionDomain := fmt.Sprintf("%s.%s.%s.", EndpointBastion(i+1), cluster.Name, r.baseDomain)
-		logger := logger.WithValues("record", bastionDomain)
-		logger.Info("Registering record")
 
+	desiredRecords := map[string]bool{}
+	var bastionIPList []string
+	var pendingBastions []string
+	for _, bastion := range bastions {
+		if bastion.IsPending() {
+			logger.Info("Skipping. Bastion IP is not yet available", "machine", bastion.Name)
+			pendingBastions = append(pendingBastions, bastion.Name)
+			continue
+		}
+
+		bastionDomain := fmt.Sprintf("%s.%s.%s.", EndpointBastion(bastion.Index), cluster.Name, r.baseDomain)
+		desiredRecords[bastionDomain] = true
+		bastionIPList = append(bastionIPList, bastion.IP)
+
+		logger := logger.WithValues("record", bastionDomain, "machine", bastion.Name)
 		record := &clouddns.ResourceRecordSet{
 			Name: bastionDomain,
 			Rrdatas: []string{
-				bastionIP,
+				bastion.IP,
 			},
 			Type: RecordA,
 		}
-		_, err = r.dnsService.ResourceRecordSets.Create(cluster.Spec.Project, cluster.Name, record).
-			Context(ctx).
-			Do()
-
-		if hasHttpCode(err, http.StatusConflict) {
-			err = r.updateBastionRecordIfNotUptoDate(ctx, cluster, record, logger)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-		} else if err != nil {
+
+		err = r.ensureRecord(ctx, logger, cluster, existingRecords[bastionDomain], record)
+		if err != nil {
 			return microerror.Mask(err)
 		}
-		logger.Info("Done Registering record", "ip", bastionIP)
+	}
+
+	if len(bastionIPList) > 0 {
+		bastionsDomain := fmt.Sprintf("%s.%s.%s.", EndpointBastions, cluster.Name, r.baseDomain)
+		desiredRecords[bastionsDomain] = true
+
+		logger := logger.WithValues("record", bastionsDomain)
+		sort.Strings(bastionIPList)
+		record := &clouddns.ResourceRecordSet{
+			Name:    bastionsDomain,
+			Rrdatas: bastionIPList,
+			Type:    RecordA,
+		}
+
+		err = r.ensureRecord(ctx, logger, cluster, existingRecords[bastionsDomain], record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	for name, record := range existingRecords {
+		if desiredRecords[name] {
+			continue
+		}
+
+		logger := logger.WithValues("record", name)
+		logger.Info("Unregistering stale record")
+
+		err = r.deleteRecord(ctx, logger, cluster, record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if len(pendingBastions) > 0 {
+		message := fmt.Sprintf("bastion IP is not yet available for: %s", strings.Join(pendingBastions, ", "))
+		return microerror.Mask(NewPendingError(ReasonBastionsPending, message, bastionsPendingRequeueAfter))
 	}
 
 	return nil
 }
 
-func (r *Bastion) Unregister(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *Bastion) Unregister(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
-	recordList, err := r.dnsService.ResourceRecordSets.List(cluster.Spec.Project, cluster.Name).
-		Context(ctx).
-		Do()
-
+	records, err := r.listBastionRecords(ctx, cluster)
 	if hasHttpCode(err, http.StatusNotFound) {
 		logger.Info("Skipping. Zone already unregistered")
 		return nil
@@ -88,56 +162,88 @@ func (r *Bastion) Unregister(ctx context.Context, cluster *capg.GCPCluster) erro
 		return microerror.Mask(err)
 	}
 
-	for _, record := range recordList.Rrsets {
-		// remove all bastion dns records
-		if strings.HasPrefix(record.Name, "bastion") {
-			logger := logger.WithValues("record", record.Name)
-			logger.Info("Unregistering record")
-
-			_, err = r.dnsService.ResourceRecordSets.Delete(cluster.Spec.Project, cluster.Name, record.Name, RecordA).
-				Context(ctx).
-				Do()
+	for _, record := range records {
+		logger := logger.WithValues("record", record.Name)
+		logger.Info("Unregistering record")
 
-			if hasHttpCode(err, http.StatusNotFound) {
-				logger.Info("Skipping. Record already unregistered")
-				continue
-			}
-			if err != nil {
-				return microerror.Mask(err)
-			}
-			logger.Info("Done unregistering record")
+		err = r.deleteRecord(ctx, logger, cluster, record)
+		if err != nil {
+			return microerror.Mask(err)
 		}
 	}
 	return nil
 }
 
-func (r *Bastion) updateBastionRecordIfNotUptoDate(ctx context.Context, cluster *capg.GCPCluster, bastionRecord *clouddns.ResourceRecordSet, logger logr.Logger) error {
-	bastionIP := bastionRecord.Rrdatas[0]
-	// record exists, check if the IP matches
-	rr, err := r.dnsService.ResourceRecordSets.Get(cluster.Spec.Project, cluster.Name, bastionRecord.Name, RecordA). //nolint:govet
-																Context(ctx).
-																Do()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	if len(rr.Rrdatas) > 0 && rr.Rrdatas[0] != bastionIP {
-		logger.Info("Bastion record exists but its not up to date. Updating record")
+func (r *Bastion) ensureRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, existingRecord, record *clouddns.ResourceRecordSet) error {
+	if existingRecord == nil {
+		logger.Info("Registering record")
 
-		_, err = r.dnsService.ResourceRecordSets.Patch(cluster.Spec.Project, cluster.Name, bastionRecord.Name, RecordA, bastionRecord).
-			Context(ctx).
-			Do()
+		err := createRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record)
 		if err != nil {
 			return microerror.Mask(err)
 		}
-		logger.Info("Updated Bastion record", "ip", bastionIP)
-	} else {
+
+		logger.Info("Done Registering record", "ips", record.Rrdatas)
+		return nil
+	}
+
+	if equalRrdatas(existingRecord.Rrdatas, record.Rrdatas) {
 		logger.Info("Skipping. Record already exists and is up to date.")
+		return nil
+	}
+
+	err := checkRecordUpdate(ctx, r.dnsService, cluster, existingRecord, record)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger.Info("Bastion record exists but its not up to date. Updating record")
+
+	err = patchRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord, record)
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
+	logger.Info("Updated Bastion record", "ips", record.Rrdatas)
 	return nil
 }
 
+func (r *Bastion) deleteRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, record *clouddns.ResourceRecordSet) error {
+	err := deleteRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record)
+	if hasHttpCode(err, http.StatusNotFound) {
+		logger.Info("Skipping. Record already unregistered")
+		return nil
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger.Info("Done unregistering record")
+	return nil
+}
+
+// listBastionRecords returns the bastion A records of the cluster zone
+// indexed by their name.
+func (r *Bastion) listBastionRecords(ctx context.Context, cluster *clusterview.Cluster) (map[string]*clouddns.ResourceRecordSet, error) {
+	domain := fmt.Sprintf("%s.%s.", cluster.Name, r.baseDomain)
+
+	records := map[string]*clouddns.ResourceRecordSet{}
+	err := r.dnsService.ResourceRecordSets.List(cluster.Project, cluster.Name).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			for _, record := range page.Rrsets {
+				if record.Type == RecordA && isBastionName(domain, record.Name) {
+					records[record.Name] = record
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
 func (r *Bastion) getLogger(ctx context.Context) logr.Logger {
 	logger := log.FromContext(ctx)
 	return logger.WithName("bastion-registrar")
@@ -146,3 +252,11 @@ func (r *Bastion) getLogger(ctx context.Context) logr.Logger {
 func EndpointBastion(index int) string {
 	return fmt.Sprintf("bastion%d", index)
 }
+
+// isBastionName returns whether the name is the name of a bastion record in
+// the zone of the domain. Records further down the domain, e.g.
+// bastion.foo.<domain>, are not.
+func isBastionName(domain, name string) bool {
+	label := strings.TrimSuffix(name, "."+domain)
+	return label != name && bastionLabelRegexp.MatchString(label)
+}
@@ -0,0 +1,127 @@
+package registrar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ReasonForeignRecords = "ForeignRecords"
+	ReasonZoneConflict   = "ZoneConflict"
+	ReasonRecordConflict = "RecordConflict"
+
+	ReasonInvalidRoutingPolicy  = "InvalidRoutingPolicy"
+	ReasonInvalidBackupEndpoint = "InvalidBackupEndpoint"
+
+	foreignRecordsRequeueAfter = time.Minute * 5
+)
+
+// PendingError is returned by registrars when a registration could only be
+// done partially, because some of the resources the records are derived from
+// are not ready yet. It is not a failure: the other registrars keep running
+// and the cluster is reconciled again after RequeueAfter.
+type PendingError struct {
+	reason       string
+	message      string
+	requeueAfter time.Duration
+}
+
+func NewPendingError(reason, message string, requeueAfter time.Duration) *PendingError {
+	return &PendingError{
+		reason:       reason,
+		message:      message,
+		requeueAfter: requeueAfter,
+	}
+}
+
+func (e *PendingError) Error() string {
+	return e.message
+}
+
+// ConditionReason is the reason reported in the cluster conditions.
+func (e *PendingError) ConditionReason() string {
+	return e.reason
+}
+
+func (e *PendingError) RequeueAfter() time.Duration {
+	return e.requeueAfter
+}
+
+// ForeignRecordsError is returned when a cluster zone can't be deleted,
+// because it contains records which were not created by the operator. The
+// deletion is retried, so that it continues once the records are removed.
+type ForeignRecordsError struct {
+	zone    string
+	records []string
+}
+
+func NewForeignRecordsError(zone string, records []string) *ForeignRecordsError {
+	return &ForeignRecordsError{
+		zone:    zone,
+		records: records,
+	}
+}
+
+func (e *ForeignRecordsError) Error() string {
+	return fmt.Sprintf("zone %q contains records not managed by the operator: %s", e.zone, strings.Join(e.records, ", "))
+}
+
+// Records returns the name and type of the records blocking the deletion.
+func (e *ForeignRecordsError) Records() []string {
+	return e.records
+}
+
+func (e *ForeignRecordsError) ConditionReason() string {
+	return ReasonForeignRecords
+}
+
+func (e *ForeignRecordsError) RequeueAfter() time.Duration {
+	return foreignRecordsRequeueAfter
+}
+
+// ConflictError is returned when an existing zone or record conflicts with
+// the one the registrar wants to create and can't be adopted. It needs to be
+// resolved by hand, so registrars depending on the failed one are skipped.
+type ConflictError struct {
+	reason  string
+	message string
+}
+
+func NewConflictError(reason, message string) *ConflictError {
+	return &ConflictError{
+		reason:  reason,
+		message: message,
+	}
+}
+
+func (e *ConflictError) Error() string {
+	return e.message
+}
+
+func (e *ConflictError) ConditionReason() string {
+	return e.reason
+}
+
+// InvalidConfigError is returned when the cluster configures a registrar
+// with invalid values, e.g. through an annotation. Like a ConflictError it
+// needs to be fixed by hand.
+type InvalidConfigError struct {
+	reason  string
+	message string
+}
+
+func NewInvalidConfigError(reason, message string) *InvalidConfigError {
+	return &InvalidConfigError{
+		reason:  reason,
+		message: message,
+	}
+}
+
+func (e *InvalidConfigError) Error() string {
+	return e.message
+}
+
+func (e *InvalidConfigError) ConditionReason() string {
+	return e.reason
+}
@@ -0,0 +1,102 @@
+package registrar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	clouddns "google.golang.org/api/dns/v1"
+)
+
+// ImportedRecordsLabel is the label of the TXT record listing the record
+// sets imported into a cluster zone from a zone file. Imported records are
+// owned by the operator like the records of the registrars, so they don't
+// block the deletion of the zone.
+const ImportedRecordsLabel = "_dns-operator-gcp-imported"
+
+const importedRecordsTTL = 300
+
+// ImportedRecords is the set of record sets imported into the zone of a
+// cluster domain, keyed by their name and type.
+type ImportedRecords map[string]bool
+
+// ParseImportedRecords returns the imported record sets listed by the TXT
+// record among the records of the zone.
+func ParseImportedRecords(domain string, records []*clouddns.ResourceRecordSet) ImportedRecords {
+	imported := ImportedRecords{}
+	for _, record := range records {
+		if record.Name != ImportedRecordsName(domain) || record.Type != RecordTXT {
+			continue
+		}
+
+		for _, rrdata := range record.Rrdatas {
+			fields := strings.Fields(strings.Trim(rrdata, `"`))
+			if len(fields) != 2 {
+				continue
+			}
+
+			name := domain
+			if fields[0] != "@" {
+				name = fields[0] + "." + domain
+			}
+			imported[importedRecordKey(name, fields[1])] = true
+		}
+	}
+
+	return imported
+}
+
+// ImportedRecordsName returns the name of the TXT record listing the
+// imported record sets of the cluster domain.
+func ImportedRecordsName(domain string) string {
+	return ImportedRecordsLabel + "." + domain
+}
+
+// Contains returns whether the record set was imported.
+func (i ImportedRecords) Contains(record *clouddns.ResourceRecordSet) bool {
+	return i[importedRecordKey(record.Name, record.Type)]
+}
+
+// Add marks the record set as imported.
+func (i ImportedRecords) Add(record *clouddns.ResourceRecordSet) {
+	i[importedRecordKey(record.Name, record.Type)] = true
+}
+
+// Record returns the TXT record listing the imported record sets. Names are
+// relative to the domain, so that each entry fits into a single string.
+func (i ImportedRecords) Record(domain string) *clouddns.ResourceRecordSet {
+	var rrdatas []string
+	for key := range i {
+		name, recordType, _ := strings.Cut(key, " ")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, domain), ".")
+		if name == "" {
+			name = "@"
+		}
+		rrdatas = append(rrdatas, strconv.Quote(name+" "+recordType))
+	}
+	sort.Strings(rrdatas)
+
+	return &clouddns.ResourceRecordSet{
+		Name:    ImportedRecordsName(domain),
+		Type:    RecordTXT,
+		Ttl:     importedRecordsTTL,
+		Rrdatas: rrdatas,
+	}
+}
+
+// IsOperatorName returns whether records of the name are created by the
+// registrars, regardless of their type, so that they must not be imported.
+func IsOperatorName(domain, name string) bool {
+	name = strings.ToLower(name)
+	for _, recordType := range []string{RecordA, RecordCNAME, RecordTXT} {
+		if isOperatorRecord(domain, &clouddns.ResourceRecordSet{Name: name, Type: recordType}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func importedRecordKey(name, recordType string) string {
+	return strings.ToLower(name) + " " + recordType
+}
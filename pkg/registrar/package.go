@@ -1,17 +1,37 @@
 package registrar
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/giantswarm/microerror"
+	clouddns "google.golang.org/api/dns/v1"
 	"google.golang.org/api/googleapi"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
+// Names of the registrars, used to declare the dependencies between them.
+const (
+	NameZone        = "zone"
+	NameAPI         = "api"
+	NameAPIInternal = "api-internal"
+	NameBastion     = "bastion"
+	NameWildcard    = "wildcard"
+)
+
 const (
 	RecordNS    = "NS"
+	RecordSOA   = "SOA"
 	RecordA     = "A"
 	RecordCNAME = "CNAME"
+	RecordTXT   = "TXT"
 )
 
 func hasHttpCode(err error, statusCode int) bool {
@@ -24,3 +44,190 @@ func hasHttpCode(err error, statusCode int) bool {
 
 	return false
 }
+
+// isOperatorRecord returns whether the record is one of the records the
+// registrars create in the cluster zone, or the TXT record listing the
+// records imported from a zone file.
+func isOperatorRecord(domain string, record *clouddns.ResourceRecordSet) bool {
+	if !strings.HasSuffix(record.Name, "."+domain) {
+		return false
+	}
+	name := strings.TrimSuffix(record.Name, "."+domain)
+
+	switch {
+	case name == EndpointAPI && record.Type == RecordA:
+		return true
+	case name == EndpointAPIInternal && record.Type == RecordA:
+		return true
+	case bastionLabelRegexp.MatchString(name) && record.Type == RecordA:
+		return true
+	case name == EndpointWildcard && record.Type == RecordCNAME:
+		return true
+	case strings.HasPrefix(name+".", ACMEChallengeLabel+".") && record.Type == RecordTXT:
+		return true
+	case name == ImportedRecordsLabel && record.Type == RecordTXT:
+		return true
+	}
+
+	return false
+}
+
+// ForeignRecords returns the records of the zone of the domain, which were
+// neither created by the operator nor imported from a zone file, e.g. records
+// created by hand. The SOA and NS records at the apex of the zone are
+// ignored.
+func ForeignRecords(domain string, records []*clouddns.ResourceRecordSet) []*clouddns.ResourceRecordSet {
+	imported := ParseImportedRecords(domain, records)
+
+	var foreignRecords []*clouddns.ResourceRecordSet
+	for _, record := range records {
+		if record.Name == domain && (record.Type == RecordSOA || record.Type == RecordNS) {
+			continue
+		}
+		if !isOperatorRecord(domain, record) && !imported.Contains(record) {
+			foreignRecords = append(foreignRecords, record)
+		}
+	}
+
+	return foreignRecords
+}
+
+const (
+	cloudDNSNameServerSuffix = ".googledomains.com."
+
+	maxLabelValueLength = 63
+)
+
+var invalidLabelValueRegexp = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// SanitizeLabelValue turns the value into a valid Cloud DNS label value,
+// which may only contain lowercase letters, digits, underscores and dashes
+// and is at most 63 characters long.
+func SanitizeLabelValue(value string) string {
+	sanitized := invalidLabelValueRegexp.ReplaceAllString(strings.ToLower(value), "-")
+	if len(sanitized) > maxLabelValueLength {
+		sanitized = sanitized[:maxLabelValueLength]
+	}
+
+	return sanitized
+}
+
+// IsOperatorZone returns whether the zone was created by the operator. Zones
+// created before they were labeled are recognized by their description.
+func IsOperatorZone(zone *clouddns.ManagedZone) bool {
+	return zone.Labels[ZoneLabelManagedBy] == ZoneManagedBy || zone.Description == ZoneDescription
+}
+
+// isCloudDNSDelegation returns whether the NS record delegates to Cloud DNS
+// name servers.
+func isCloudDNSDelegation(record *clouddns.ResourceRecordSet) bool {
+	if len(record.Rrdatas) == 0 {
+		return false
+	}
+
+	for _, nameServer := range record.Rrdatas {
+		if !strings.HasSuffix(nameServer, cloudDNSNameServerSuffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkRecordUpdate returns a ConflictError, when an existing record differs
+// from the expected one and must not be updated, because it is in a zone the
+// operator adopted.
+func checkRecordUpdate(ctx context.Context, dnsService *clouddns.Service, cluster *clusterview.Cluster, existingRecord, record *clouddns.ResourceRecordSet) error {
+	zone, err := dnsService.ManagedZones.Get(cluster.Project, cluster.Name).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if zone.Labels[ZoneLabelAdopted] != "true" {
+		return nil
+	}
+
+	message := fmt.Sprintf("record %s %s exists in adopted zone %q with %v, expected %v",
+		record.Name, record.Type, zone.Name, recordData(existingRecord), recordData(record))
+	return microerror.Mask(NewConflictError(ReasonRecordConflict, message))
+}
+
+func equalLabels(actual, expected map[string]string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	for key, value := range expected {
+		actualValue, ok := actual[key]
+		if !ok || actualValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalRrdatas returns whether both record sets contain the same data
+// regardless of the order.
+func equalRrdatas(actual, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	sortedActual := append([]string{}, actual...)
+	sortedExpected := append([]string{}, expected...)
+	sort.Strings(sortedActual)
+	sort.Strings(sortedExpected)
+
+	for i := range sortedActual {
+		if sortedActual[i] != sortedExpected[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalRecordData returns whether both record sets resolve to the same data,
+// either directly or through the same routing policy.
+func equalRecordData(actual, expected *clouddns.ResourceRecordSet) bool {
+	if actual.RoutingPolicy == nil || expected.RoutingPolicy == nil {
+		return actual.RoutingPolicy == nil && expected.RoutingPolicy == nil && equalRrdatas(actual.Rrdatas, expected.Rrdatas)
+	}
+
+	return equalRrdatas(routingPolicyItems(actual.RoutingPolicy), routingPolicyItems(expected.RoutingPolicy))
+}
+
+// recordData describes the data of a record set for messages.
+func recordData(record *clouddns.ResourceRecordSet) []string {
+	if record.RoutingPolicy != nil {
+		return routingPolicyItems(record.RoutingPolicy)
+	}
+	return record.Rrdatas
+}
+
+// routingPolicyItems returns the items of a routing policy in a canonical
+// form, so that policies can be compared regardless of the order of their
+// items and data.
+func routingPolicyItems(policy *clouddns.RRSetRoutingPolicy) []string {
+	var items []string
+	if policy.Wrr != nil {
+		for _, item := range policy.Wrr.Items {
+			rrdatas := append([]string{}, item.Rrdatas...)
+			sort.Strings(rrdatas)
+			items = append(items, fmt.Sprintf("weight=%g:%s", item.Weight, strings.Join(rrdatas, ",")))
+		}
+	}
+	if policy.Geo != nil {
+		for _, item := range policy.Geo.Items {
+			rrdatas := append([]string{}, item.Rrdatas...)
+			sort.Strings(rrdatas)
+			items = append(items, fmt.Sprintf("location=%s:%s", item.Location, strings.Join(rrdatas, ",")))
+		}
+	}
+	sort.Strings(items)
+
+	return items
+}
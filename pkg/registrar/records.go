@@ -0,0 +1,85 @@
+package registrar
+
+import (
+	"context"
+
+	"github.com/giantswarm/microerror"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+)
+
+// The registrars mutate record sets through these functions, which record
+// every mutation in the audit trail of the context.
+
+func createRecordSet(ctx context.Context, dnsService *clouddns.Service, project, zone string, record *clouddns.ResourceRecordSet) error {
+	_, err := dnsService.ResourceRecordSets.Create(project, zone, record).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	audit.FromContext(ctx).Record(ctx, audit.Entry{
+		Action:  audit.ActionCreate,
+		Project: project,
+		Zone:    zone,
+		Name:    record.Name,
+		Type:    record.Type,
+		After:   recordData(record),
+	})
+	return nil
+}
+
+func patchRecordSet(ctx context.Context, dnsService *clouddns.Service, project, zone string, existingRecord, record *clouddns.ResourceRecordSet) error {
+	_, err := dnsService.ResourceRecordSets.Patch(project, zone, record.Name, record.Type, record).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	audit.FromContext(ctx).Record(ctx, audit.Entry{
+		Action:  audit.ActionUpdate,
+		Project: project,
+		Zone:    zone,
+		Name:    record.Name,
+		Type:    record.Type,
+		Before:  recordData(existingRecord),
+		After:   recordData(record),
+	})
+	return nil
+}
+
+// deleteRecordSet deletes the record set of the name and type of the record.
+// When the record has no data and the deletion is audited, the record set is
+// read first, so that the trail contains the deleted data.
+func deleteRecordSet(ctx context.Context, dnsService *clouddns.Service, project, zone string, record *clouddns.ResourceRecordSet) error {
+	recorder := audit.FromContext(ctx)
+	if recorder != nil && len(recordData(record)) == 0 {
+		existingRecord, err := dnsService.ResourceRecordSets.Get(project, zone, record.Name, record.Type).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		record = existingRecord
+	}
+
+	_, err := dnsService.ResourceRecordSets.Delete(project, zone, record.Name, record.Type).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	recorder.Record(ctx, audit.Entry{
+		Action:  audit.ActionDelete,
+		Project: project,
+		Zone:    zone,
+		Name:    record.Name,
+		Type:    record.Type,
+		Before:  recordData(record),
+	})
+	return nil
+}
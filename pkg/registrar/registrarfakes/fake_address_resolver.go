@@ -0,0 +1,119 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package registrarfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+type FakeAddressResolver struct {
+	ResolveAddressStub        func(context.Context, string) (string, error)
+	resolveAddressMutex       sync.RWMutex
+	resolveAddressArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	resolveAddressReturns struct {
+		result1 string
+		result2 error
+	}
+	resolveAddressReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeAddressResolver) ResolveAddress(arg1 context.Context, arg2 string) (string, error) {
+	fake.resolveAddressMutex.Lock()
+	ret, specificReturn := fake.resolveAddressReturnsOnCall[len(fake.resolveAddressArgsForCall)]
+	fake.resolveAddressArgsForCall = append(fake.resolveAddressArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ResolveAddressStub
+	fakeReturns := fake.resolveAddressReturns
+	fake.recordInvocation("ResolveAddress", []interface{}{arg1, arg2})
+	fake.resolveAddressMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAddressResolver) ResolveAddressCallCount() int {
+	fake.resolveAddressMutex.RLock()
+	defer fake.resolveAddressMutex.RUnlock()
+	return len(fake.resolveAddressArgsForCall)
+}
+
+func (fake *FakeAddressResolver) ResolveAddressCalls(stub func(context.Context, string) (string, error)) {
+	fake.resolveAddressMutex.Lock()
+	defer fake.resolveAddressMutex.Unlock()
+	fake.ResolveAddressStub = stub
+}
+
+func (fake *FakeAddressResolver) ResolveAddressArgsForCall(i int) (context.Context, string) {
+	fake.resolveAddressMutex.RLock()
+	defer fake.resolveAddressMutex.RUnlock()
+	argsForCall := fake.resolveAddressArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAddressResolver) ResolveAddressReturns(result1 string, result2 error) {
+	fake.resolveAddressMutex.Lock()
+	defer fake.resolveAddressMutex.Unlock()
+	fake.ResolveAddressStub = nil
+	fake.resolveAddressReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAddressResolver) ResolveAddressReturnsOnCall(i int, result1 string, result2 error) {
+	fake.resolveAddressMutex.Lock()
+	defer fake.resolveAddressMutex.Unlock()
+	fake.ResolveAddressStub = nil
+	if fake.resolveAddressReturnsOnCall == nil {
+		fake.resolveAddressReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.resolveAddressReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAddressResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.resolveAddressMutex.RLock()
+	defer fake.resolveAddressMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeAddressResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ registrar.AddressResolver = new(FakeAddressResolver)
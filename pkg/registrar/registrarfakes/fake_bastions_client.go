@@ -5,41 +5,41 @@ import (
 	"context"
 	"sync"
 
-	"sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
-
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
 )
 
 type FakeBastionsClient struct {
-	GetBastionIPListStub        func(context.Context, *v1beta1.GCPCluster) ([]string, error)
-	getBastionIPListMutex       sync.RWMutex
-	getBastionIPListArgsForCall []struct {
+	GetBastionsStub        func(context.Context, *clusterview.Cluster) ([]k8sclient.Bastion, error)
+	getBastionsMutex       sync.RWMutex
+	getBastionsArgsForCall []struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}
-	getBastionIPListReturns struct {
-		result1 []string
+	getBastionsReturns struct {
+		result1 []k8sclient.Bastion
 		result2 error
 	}
-	getBastionIPListReturnsOnCall map[int]struct {
-		result1 []string
+	getBastionsReturnsOnCall map[int]struct {
+		result1 []k8sclient.Bastion
 		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeBastionsClient) GetBastionIPList(arg1 context.Context, arg2 *v1beta1.GCPCluster) ([]string, error) {
-	fake.getBastionIPListMutex.Lock()
-	ret, specificReturn := fake.getBastionIPListReturnsOnCall[len(fake.getBastionIPListArgsForCall)]
-	fake.getBastionIPListArgsForCall = append(fake.getBastionIPListArgsForCall, struct {
+func (fake *FakeBastionsClient) GetBastions(arg1 context.Context, arg2 *clusterview.Cluster) ([]k8sclient.Bastion, error) {
+	fake.getBastionsMutex.Lock()
+	ret, specificReturn := fake.getBastionsReturnsOnCall[len(fake.getBastionsArgsForCall)]
+	fake.getBastionsArgsForCall = append(fake.getBastionsArgsForCall, struct {
 		arg1 context.Context
-		arg2 *v1beta1.GCPCluster
+		arg2 *clusterview.Cluster
 	}{arg1, arg2})
-	stub := fake.GetBastionIPListStub
-	fakeReturns := fake.getBastionIPListReturns
-	fake.recordInvocation("GetBastionIPList", []interface{}{arg1, arg2})
-	fake.getBastionIPListMutex.Unlock()
+	stub := fake.GetBastionsStub
+	fakeReturns := fake.getBastionsReturns
+	fake.recordInvocation("GetBastions", []interface{}{arg1, arg2})
+	fake.getBastionsMutex.Unlock()
 	if stub != nil {
 		return stub(arg1, arg2)
 	}
@@ -49,47 +49,47 @@ func (fake *FakeBastionsClient) GetBastionIPList(arg1 context.Context, arg2 *v1b
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeBastionsClient) GetBastionIPListCallCount() int {
-	fake.getBastionIPListMutex.RLock()
-	defer fake.getBastionIPListMutex.RUnlock()
-	return len(fake.getBastionIPListArgsForCall)
+func (fake *FakeBastionsClient) GetBastionsCallCount() int {
+	fake.getBastionsMutex.RLock()
+	defer fake.getBastionsMutex.RUnlock()
+	return len(fake.getBastionsArgsForCall)
 }
 
-func (fake *FakeBastionsClient) GetBastionIPListCalls(stub func(context.Context, *v1beta1.GCPCluster) ([]string, error)) {
-	fake.getBastionIPListMutex.Lock()
-	defer fake.getBastionIPListMutex.Unlock()
-	fake.GetBastionIPListStub = stub
+func (fake *FakeBastionsClient) GetBastionsCalls(stub func(context.Context, *clusterview.Cluster) ([]k8sclient.Bastion, error)) {
+	fake.getBastionsMutex.Lock()
+	defer fake.getBastionsMutex.Unlock()
+	fake.GetBastionsStub = stub
 }
 
-func (fake *FakeBastionsClient) GetBastionIPListArgsForCall(i int) (context.Context, *v1beta1.GCPCluster) {
-	fake.getBastionIPListMutex.RLock()
-	defer fake.getBastionIPListMutex.RUnlock()
-	argsForCall := fake.getBastionIPListArgsForCall[i]
+func (fake *FakeBastionsClient) GetBastionsArgsForCall(i int) (context.Context, *clusterview.Cluster) {
+	fake.getBastionsMutex.RLock()
+	defer fake.getBastionsMutex.RUnlock()
+	argsForCall := fake.getBastionsArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2
 }
 
-func (fake *FakeBastionsClient) GetBastionIPListReturns(result1 []string, result2 error) {
-	fake.getBastionIPListMutex.Lock()
-	defer fake.getBastionIPListMutex.Unlock()
-	fake.GetBastionIPListStub = nil
-	fake.getBastionIPListReturns = struct {
-		result1 []string
+func (fake *FakeBastionsClient) GetBastionsReturns(result1 []k8sclient.Bastion, result2 error) {
+	fake.getBastionsMutex.Lock()
+	defer fake.getBastionsMutex.Unlock()
+	fake.GetBastionsStub = nil
+	fake.getBastionsReturns = struct {
+		result1 []k8sclient.Bastion
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeBastionsClient) GetBastionIPListReturnsOnCall(i int, result1 []string, result2 error) {
-	fake.getBastionIPListMutex.Lock()
-	defer fake.getBastionIPListMutex.Unlock()
-	fake.GetBastionIPListStub = nil
-	if fake.getBastionIPListReturnsOnCall == nil {
-		fake.getBastionIPListReturnsOnCall = make(map[int]struct {
-			result1 []string
+func (fake *FakeBastionsClient) GetBastionsReturnsOnCall(i int, result1 []k8sclient.Bastion, result2 error) {
+	fake.getBastionsMutex.Lock()
+	defer fake.getBastionsMutex.Unlock()
+	fake.GetBastionsStub = nil
+	if fake.getBastionsReturnsOnCall == nil {
+		fake.getBastionsReturnsOnCall = make(map[int]struct {
+			result1 []k8sclient.Bastion
 			result2 error
 		})
 	}
-	fake.getBastionIPListReturnsOnCall[i] = struct {
-		result1 []string
+	fake.getBastionsReturnsOnCall[i] = struct {
+		result1 []k8sclient.Bastion
 		result2 error
 	}{result1, result2}
 }
@@ -97,8 +97,8 @@ func (fake *FakeBastionsClient) GetBastionIPListReturnsOnCall(i int, result1 []s
 func (fake *FakeBastionsClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getBastionIPListMutex.RLock()
-	defer fake.getBastionIPListMutex.RUnlock()
+	fake.getBastionsMutex.RLock()
+	defer fake.getBastionsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,194 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package registrarfakes
+
+import (
+	"sync"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+type FakeHealthChecker struct {
+	ForgetStub        func(*clusterview.Cluster)
+	forgetMutex       sync.RWMutex
+	forgetArgsForCall []struct {
+		arg1 *clusterview.Cluster
+	}
+	UseBackupStub        func(*clusterview.Cluster) bool
+	useBackupMutex       sync.RWMutex
+	useBackupArgsForCall []struct {
+		arg1 *clusterview.Cluster
+	}
+	useBackupReturns struct {
+		result1 bool
+	}
+	useBackupReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	WatchStub        func(*clusterview.Cluster, string, string)
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		arg1 *clusterview.Cluster
+		arg2 string
+		arg3 string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthChecker) Forget(arg1 *clusterview.Cluster) {
+	fake.forgetMutex.Lock()
+	fake.forgetArgsForCall = append(fake.forgetArgsForCall, struct {
+		arg1 *clusterview.Cluster
+	}{arg1})
+	stub := fake.ForgetStub
+	fake.recordInvocation("Forget", []interface{}{arg1})
+	fake.forgetMutex.Unlock()
+	if stub != nil {
+		fake.ForgetStub(arg1)
+	}
+}
+
+func (fake *FakeHealthChecker) ForgetCallCount() int {
+	fake.forgetMutex.RLock()
+	defer fake.forgetMutex.RUnlock()
+	return len(fake.forgetArgsForCall)
+}
+
+func (fake *FakeHealthChecker) ForgetCalls(stub func(*clusterview.Cluster)) {
+	fake.forgetMutex.Lock()
+	defer fake.forgetMutex.Unlock()
+	fake.ForgetStub = stub
+}
+
+func (fake *FakeHealthChecker) ForgetArgsForCall(i int) *clusterview.Cluster {
+	fake.forgetMutex.RLock()
+	defer fake.forgetMutex.RUnlock()
+	argsForCall := fake.forgetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecker) UseBackup(arg1 *clusterview.Cluster) bool {
+	fake.useBackupMutex.Lock()
+	ret, specificReturn := fake.useBackupReturnsOnCall[len(fake.useBackupArgsForCall)]
+	fake.useBackupArgsForCall = append(fake.useBackupArgsForCall, struct {
+		arg1 *clusterview.Cluster
+	}{arg1})
+	stub := fake.UseBackupStub
+	fakeReturns := fake.useBackupReturns
+	fake.recordInvocation("UseBackup", []interface{}{arg1})
+	fake.useBackupMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeHealthChecker) UseBackupCallCount() int {
+	fake.useBackupMutex.RLock()
+	defer fake.useBackupMutex.RUnlock()
+	return len(fake.useBackupArgsForCall)
+}
+
+func (fake *FakeHealthChecker) UseBackupCalls(stub func(*clusterview.Cluster) bool) {
+	fake.useBackupMutex.Lock()
+	defer fake.useBackupMutex.Unlock()
+	fake.UseBackupStub = stub
+}
+
+func (fake *FakeHealthChecker) UseBackupArgsForCall(i int) *clusterview.Cluster {
+	fake.useBackupMutex.RLock()
+	defer fake.useBackupMutex.RUnlock()
+	argsForCall := fake.useBackupArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthChecker) UseBackupReturns(result1 bool) {
+	fake.useBackupMutex.Lock()
+	defer fake.useBackupMutex.Unlock()
+	fake.UseBackupStub = nil
+	fake.useBackupReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) UseBackupReturnsOnCall(i int, result1 bool) {
+	fake.useBackupMutex.Lock()
+	defer fake.useBackupMutex.Unlock()
+	fake.UseBackupStub = nil
+	if fake.useBackupReturnsOnCall == nil {
+		fake.useBackupReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.useBackupReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeHealthChecker) Watch(arg1 *clusterview.Cluster, arg2 string, arg3 string) {
+	fake.watchMutex.Lock()
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		arg1 *clusterview.Cluster
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.WatchStub
+	fake.recordInvocation("Watch", []interface{}{arg1, arg2, arg3})
+	fake.watchMutex.Unlock()
+	if stub != nil {
+		fake.WatchStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeHealthChecker) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeHealthChecker) WatchCalls(stub func(*clusterview.Cluster, string, string)) {
+	fake.watchMutex.Lock()
+	defer fake.watchMutex.Unlock()
+	fake.WatchStub = stub
+}
+
+func (fake *FakeHealthChecker) WatchArgsForCall(i int) (*clusterview.Cluster, string, string) {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	argsForCall := fake.watchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeHealthChecker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.forgetMutex.RLock()
+	defer fake.forgetMutex.RUnlock()
+	fake.useBackupMutex.RLock()
+	defer fake.useBackupMutex.RUnlock()
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthChecker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ registrar.HealthChecker = new(FakeHealthChecker)
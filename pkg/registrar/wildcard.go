@@ -8,8 +8,9 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/go-logr/logr"
 	clouddns "google.golang.org/api/dns/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 )
 
 const (
@@ -29,7 +30,15 @@ func NewWildcard(baseDomain string, dnsService *clouddns.Service) *Wildcard {
 	}
 }
 
-func (r *Wildcard) Register(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *Wildcard) Name() string {
+	return NameWildcard
+}
+
+func (r *Wildcard) Dependencies() []string {
+	return []string{NameZone}
+}
+
+func (r *Wildcard) Register(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
 	logger.Info("Registering record")
@@ -45,29 +54,47 @@ func (r *Wildcard) Register(ctx context.Context, cluster *capg.GCPCluster) error
 		},
 		Type: RecordCNAME,
 	}
-	_, err := r.dnsService.ResourceRecordSets.Create(cluster.Spec.Project, cluster.Name, record).
+	err := createRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record)
+	if hasHttpCode(err, http.StatusConflict) {
+		return r.updateRecord(ctx, logger, cluster, record)
+	}
+
+	return microerror.Mask(err)
+}
+
+// updateRecord makes sure an existing record points to the ingress record.
+// Records in adopted zones are not overwritten.
+func (r *Wildcard) updateRecord(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, record *clouddns.ResourceRecordSet) error {
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(cluster.Project, cluster.Name, record.Name, RecordCNAME).
 		Context(ctx).
 		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
 
-	if hasHttpCode(err, http.StatusConflict) {
+	if equalRrdatas(existingRecord.Rrdatas, record.Rrdatas) {
 		logger.Info("Skipping. Record already exists")
 		return nil
 	}
 
+	err = checkRecordUpdate(ctx, r.dnsService, cluster, existingRecord, record)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	logger.Info("Record exists but is not up to date. Updating record", "target", record.Rrdatas)
+	err = patchRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, existingRecord, record)
 	return microerror.Mask(err)
 }
 
-func (r *Wildcard) Unregister(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *Wildcard) Unregister(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
 	logger.Info("Unregistering record")
 	defer logger.Info("Done unregistering record")
 
 	wildcardDomain := fmt.Sprintf("%s.%s.%s.", EndpointWildcard, cluster.Name, r.baseDomain)
-	_, err := r.dnsService.ResourceRecordSets.Delete(cluster.Spec.Project, cluster.Name, wildcardDomain, RecordCNAME).
-		Context(ctx).
-		Do()
-
+	err := deleteRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, &clouddns.ResourceRecordSet{Name: wildcardDomain, Type: RecordCNAME})
 	if hasHttpCode(err, http.StatusNotFound) {
 		logger.Info("Skipping. Record already unregistered")
 		return nil
@@ -4,12 +4,46 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/giantswarm/microerror"
 	"github.com/go-logr/logr"
 	clouddns "google.golang.org/api/dns/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/project"
+)
+
+const (
+	ZoneDescription = "DNS zone for cluster, managed by GCP DNS operator."
+
+	// ZoneLabelManagedBy marks zones created by the operator.
+	ZoneLabelManagedBy = "managed-by"
+	ZoneManagedBy      = "dns-operator-gcp"
+
+	// Labels identifying the cluster a zone belongs to.
+	ZoneLabelClusterName       = "cluster-name"
+	ZoneLabelClusterNamespace  = "cluster-namespace"
+	ZoneLabelManagementCluster = "management-cluster"
+	ZoneLabelOperatorVersion   = "operator-version"
+	// ZoneLabelAdopted marks zones which existed before the operator
+	// registered the cluster. Records in adopted zones, which differ from
+	// the expected ones, are not overwritten.
+	ZoneLabelAdopted = "dns-operator-gcp-adopted"
+
+	zoneVisibility = "public"
+
+	// ZoneLabelRetained marks zones of deleted clusters, which were retained
+	// on purpose, so they are not considered orphaned. The label is removed
+	// when a new cluster of the same name adopts the zone.
+	ZoneLabelRetained = "dns-operator-gcp-retained"
+	// ZoneLabelPrivate marks the private zones of clusters. The name of the
+	// private zone of a cluster can also be the name of the zone of another
+	// cluster, so private zones are identified by their labels instead.
+	ZoneLabelPrivate = "dns-operator-gcp-private"
 )
 
 type Zone struct {
@@ -18,18 +52,40 @@ type Zone struct {
 	baseDomain       string
 	parentDNSZone    string
 	parentGCPProject string
+	// managementCluster identifies the management cluster running the
+	// operator in the zone labels. It is optional.
+	managementCluster string
+
+	// cloudLogging is the default for logging the queries of cluster zones,
+	// when the cluster does not set the annotation.CloudLogging annotation.
+	cloudLogging bool
+	// forceDeletion is the default for deleting zones containing records
+	// not managed by the operator, when the cluster does not set the
+	// annotation.ForceZoneDeletion annotation.
+	forceDeletion bool
 }
 
-func NewZone(baseDomain, parentDNSZone, parentGCPProject string, dnsService *clouddns.Service) *Zone {
+func NewZone(baseDomain, parentDNSZone, parentGCPProject, managementCluster string, cloudLogging, forceDeletion bool, dnsService *clouddns.Service) *Zone {
 	return &Zone{
-		baseDomain:       baseDomain,
-		parentDNSZone:    parentDNSZone,
-		parentGCPProject: parentGCPProject,
-		dnsService:       dnsService,
+		baseDomain:        baseDomain,
+		parentDNSZone:     parentDNSZone,
+		parentGCPProject:  parentGCPProject,
+		managementCluster: managementCluster,
+		cloudLogging:      cloudLogging,
+		forceDeletion:     forceDeletion,
+		dnsService:        dnsService,
 	}
 }
 
-func (r *Zone) Register(ctx context.Context, cluster *capg.GCPCluster) error {
+func (r *Zone) Name() string {
+	return NameZone
+}
+
+func (r *Zone) Dependencies() []string {
+	return nil
+}
+
+func (r *Zone) Register(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
 	logger.Info("Registering record")
@@ -45,66 +101,318 @@ func (r *Zone) Register(ctx context.Context, cluster *capg.GCPCluster) error {
 	return r.registerNSInParentZone(ctx, logger, domain, zone)
 }
 
-func (r *Zone) Unregister(ctx context.Context, cluster *capg.GCPCluster) error {
+// Unregister removes the delegation from the parent zone and deletes the
+// cluster zone. Cloud DNS refuses to delete zones containing records, so all
+// records are deleted first. Records which were neither created by the
+// operator nor imported from a zone file, e.g. created by hand, block the
+// deletion, unless the deletion is forced, to avoid deleting records someone
+// still relies on.
+// Zones which the operator did not create, but adopted, are released
+// instead: their operator labels are removed, while the zone, its remaining
+// records and its delegation are kept. Zones of another management cluster
+// are left alone.
+func (r *Zone) Unregister(ctx context.Context, cluster *clusterview.Cluster) error {
 	logger := r.getLogger(ctx)
 
-	logger.Info("Registering record")
-	defer logger.Info("Done registering record")
+	logger.Info("Unregistering zone")
+	defer logger.Info("Done unregistering zone")
 
 	domain := r.getClusterDomain(cluster)
 
-	_, err := r.dnsService.ResourceRecordSets.Delete(r.parentGCPProject, r.parentDNSZone, domain, RecordNS).
+	zone, err := r.getClusterZone(ctx, logger, cluster)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if zone != nil {
+		if r.hasOtherManagementCluster(zone) {
+			logger.Info("Skipping. Zone belongs to another management cluster", "managementCluster", zone.Labels[ZoneLabelManagementCluster])
+			return nil
+		}
+		if !IsOperatorZone(zone) {
+			return r.releaseZone(ctx, logger, cluster, zone)
+		}
+	}
+
+	zoneExists := zone != nil
+
+	var records []*clouddns.ResourceRecordSet
+	if zoneExists {
+		records, err = r.listRecords(ctx, cluster)
+		if hasHttpCode(err, http.StatusNotFound) {
+			zoneExists = false
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var foreignRecords []string
+	for _, record := range ForeignRecords(domain, records) {
+		foreignRecords = append(foreignRecords, fmt.Sprintf("%s %s", record.Name, record.Type))
+	}
+
+	if len(foreignRecords) > 0 {
+		if !r.isDeletionForced(cluster) {
+			logger.Info("Skipping. Zone contains records not managed by the operator", "records", foreignRecords)
+			return microerror.Mask(NewForeignRecordsError(cluster.Name, foreignRecords))
+		}
+		logger.Info("Forcing deletion of records not managed by the operator", "records", foreignRecords)
+	}
+
+	err = r.unregisterNSInParentZone(ctx, logger, domain)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !zoneExists {
+		logger.Info("Zone already deleted")
+		return nil
+	}
+
+	// The TXT record listing the imported records is deleted last, so that
+	// the remaining imported records don't block a retry after a failure.
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[j].Name == ImportedRecordsName(domain) && records[i].Name != ImportedRecordsName(domain)
+	})
+	for _, record := range records {
+		logger.Info("Deleting record", "record", record.Name, "type", record.Type)
+
+		err = deleteRecordSet(ctx, r.dnsService, cluster.Project, cluster.Name, record)
+		if err != nil && !hasHttpCode(err, http.StatusNotFound) {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = r.dnsService.ManagedZones.Delete(cluster.Project, cluster.Name).
 		Context(ctx).
 		Do()
 
-	if err != nil && !hasHttpCode(err, http.StatusNotFound) {
-		logger.Info("Skipping. Record already unregistered")
+	if hasHttpCode(err, http.StatusNotFound) {
+		logger.Info("Zone already deleted")
+		return nil
+	}
+	return microerror.Mask(err)
+}
+
+// UnregisterDelegation removes the delegation from the parent zone, but keeps
+// the cluster zone and its records.
+func (r *Zone) UnregisterDelegation(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := r.getLogger(ctx)
+
+	logger.Info("Unregistering delegation")
+	defer logger.Info("Done unregistering delegation")
+
+	err := r.unregisterNSInParentZone(ctx, logger, r.getClusterDomain(cluster))
+	return microerror.Mask(err)
+}
+
+// Retain labels the cluster zone as retained, so that the sweeper doesn't
+// delete it once the cluster is gone.
+func (r *Zone) Retain(ctx context.Context, cluster *clusterview.Cluster) error {
+	logger := r.getLogger(ctx)
+
+	zone, err := r.getManagedZone(ctx, cluster)
+	if hasHttpCode(err, http.StatusNotFound) {
+		logger.Info("Skipping. Zone does not exist")
+		return nil
+	} else if err != nil {
 		return microerror.Mask(err)
 	}
 
-	err = r.dnsService.ManagedZones.Delete(cluster.Spec.Project, cluster.Name).
+	if !strings.EqualFold(zone.DnsName, r.getClusterDomain(cluster)) {
+		logger.Info("Skipping. Zone belongs to another domain", "domain", zone.DnsName)
+		return nil
+	}
+
+	labels := map[string]string{}
+	for key, value := range zone.Labels {
+		labels[key] = value
+	}
+	labels[ZoneLabelRetained] = "true"
+
+	logger.Info("Retaining zone")
+	_, err = r.dnsService.ManagedZones.Patch(cluster.Project, cluster.Name, &clouddns.ManagedZone{Labels: labels}).
 		Context(ctx).
 		Do()
+	return microerror.Mask(err)
+}
 
+// reconcileExistingZone makes sure an existing zone can be used for the
+// cluster. Zones which were not created by the operator are labeled as
+// adopted, the retained label of zones of previously deleted clusters is
+// removed and the cluster labels and query logging are updated. Zones of
+// another management cluster are never taken over.
+func (r *Zone) reconcileExistingZone(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, domain string, zone *clouddns.ManagedZone) error {
+	if !strings.EqualFold(zone.DnsName, domain) || !strings.EqualFold(zone.Visibility, zoneVisibility) {
+		message := fmt.Sprintf("zone %q exists for %s with %s visibility, expected %s with %s visibility",
+			zone.Name, zone.DnsName, zone.Visibility, domain, zoneVisibility)
+		return microerror.Mask(NewConflictError(ReasonZoneConflict, message))
+	}
+
+	if r.hasOtherManagementCluster(zone) {
+		message := fmt.Sprintf("zone %q is managed by management cluster %q", zone.Name, zone.Labels[ZoneLabelManagementCluster])
+		return microerror.Mask(NewConflictError(ReasonZoneConflict, message))
+	}
+
+	labels := map[string]string{}
+	for key, value := range zone.Labels {
+		labels[key] = value
+	}
+
+	delete(labels, ZoneLabelRetained)
+	if IsOperatorZone(zone) {
+		labels[ZoneLabelManagedBy] = ZoneManagedBy
+	} else {
+		labels[ZoneLabelAdopted] = "true"
+	}
+
+	for key, value := range getClusterLabels(cluster, r.managementCluster) {
+		labels[key] = value
+	}
+
+	patch := &clouddns.ManagedZone{}
+	if !equalLabels(labels, zone.Labels) {
+		logger.Info("Updating labels of existing zone", "labels", labels)
+		patch.Labels = labels
+	}
+
+	cloudLogging := r.isCloudLoggingEnabled(cluster)
+	if zone.CloudLoggingConfig == nil || zone.CloudLoggingConfig.EnableLogging != cloudLogging {
+		logger.Info("Updating query logging of existing zone", "enabled", cloudLogging)
+		patch.CloudLoggingConfig = &clouddns.ManagedZoneCloudLoggingConfig{
+			EnableLogging:   cloudLogging,
+			ForceSendFields: []string{"EnableLogging"},
+		}
+	}
+
+	if patch.Labels == nil && patch.CloudLoggingConfig == nil {
+		return nil
+	}
+
+	_, err := r.dnsService.ManagedZones.Patch(cluster.Project, cluster.Name, patch).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if patch.Labels != nil {
+		zone.Labels = labels
+	}
+	return nil
+}
+
+func (r *Zone) unregisterNSInParentZone(ctx context.Context, logger logr.Logger, domain string) error {
+	err := deleteRecordSet(ctx, r.dnsService, r.parentGCPProject, r.parentDNSZone, &clouddns.ResourceRecordSet{Name: domain, Type: RecordNS})
 	if hasHttpCode(err, http.StatusNotFound) {
-		logger.Info("Zone already deleted")
+		logger.Info("Skipping. Record already unregistered")
 		return nil
 	}
+
 	return microerror.Mask(err)
 }
 
+// listRecords returns the records of the cluster zone, apart from the SOA and
+// NS records at the apex, which are managed by Cloud DNS.
+func (r *Zone) listRecords(ctx context.Context, cluster *clusterview.Cluster) ([]*clouddns.ResourceRecordSet, error) {
+	domain := r.getClusterDomain(cluster)
+
+	var records []*clouddns.ResourceRecordSet
+	err := r.dnsService.ResourceRecordSets.List(cluster.Project, cluster.Name).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			for _, record := range page.Rrsets {
+				if record.Name == domain && (record.Type == RecordSOA || record.Type == RecordNS) {
+					continue
+				}
+				records = append(records, record)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
+func (r *Zone) isCloudLoggingEnabled(cluster *clusterview.Cluster) bool {
+	return annotation.GetBool(cluster.Annotations, annotation.CloudLogging, r.cloudLogging)
+}
+
+func (r *Zone) isDeletionForced(cluster *clusterview.Cluster) bool {
+	return annotation.GetBool(cluster.Annotations, annotation.ForceZoneDeletion, r.forceDeletion)
+}
+
 func (r *Zone) registerNSInParentZone(ctx context.Context, logger logr.Logger, domain string, zone *clouddns.ManagedZone) error {
 	nsRecord := &clouddns.ResourceRecordSet{
 		Name:    domain,
 		Rrdatas: zone.NameServers,
 		Type:    RecordNS,
 	}
-	_, err := r.dnsService.ResourceRecordSets.Create(r.parentGCPProject, r.parentDNSZone, nsRecord).
+	err := createRecordSet(ctx, r.dnsService, r.parentGCPProject, r.parentDNSZone, nsRecord)
+	if hasHttpCode(err, http.StatusConflict) {
+		return r.updateNSInParentZone(ctx, logger, nsRecord)
+	}
+
+	return microerror.Mask(err)
+}
+
+// updateNSInParentZone updates an existing delegation, which points to other
+// Cloud DNS name servers, e.g. because the cluster zone was recreated.
+// Delegations to name servers outside of Cloud DNS were not created by the
+// operator and are not overwritten.
+func (r *Zone) updateNSInParentZone(ctx context.Context, logger logr.Logger, nsRecord *clouddns.ResourceRecordSet) error {
+	existingRecord, err := r.dnsService.ResourceRecordSets.Get(r.parentGCPProject, r.parentDNSZone, nsRecord.Name, RecordNS).
 		Context(ctx).
 		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
 
-	if hasHttpCode(err, http.StatusConflict) {
+	if equalRrdatas(existingRecord.Rrdatas, nsRecord.Rrdatas) {
 		logger.Info("Skipping. Record already exists")
 		return nil
 	}
 
+	if !isCloudDNSDelegation(existingRecord) {
+		message := fmt.Sprintf("parent zone delegates %s to %v, expected %v", nsRecord.Name, existingRecord.Rrdatas, nsRecord.Rrdatas)
+		return microerror.Mask(NewConflictError(ReasonZoneConflict, message))
+	}
+
+	logger.Info("Delegation exists but is not up to date. Updating record", "nameServers", nsRecord.Rrdatas)
+	err = patchRecordSet(ctx, r.dnsService, r.parentGCPProject, r.parentDNSZone, existingRecord, nsRecord)
 	return microerror.Mask(err)
 }
 
-func (r *Zone) createManagedZone(ctx context.Context, logger logr.Logger, domain string, cluster *capg.GCPCluster) (*clouddns.ManagedZone, error) {
+func (r *Zone) createManagedZone(ctx context.Context, logger logr.Logger, domain string, cluster *clusterview.Cluster) (*clouddns.ManagedZone, error) {
 	zone := &clouddns.ManagedZone{
 		Name:        cluster.Name,
 		DnsName:     domain,
-		Description: "DNS zone for cluster, managed by GCP DNS operator.",
-		Visibility:  "public",
+		Description: ZoneDescription,
+		Visibility:  zoneVisibility,
+		Labels:      getClusterLabels(cluster, r.managementCluster),
+		CloudLoggingConfig: &clouddns.ManagedZoneCloudLoggingConfig{
+			EnableLogging: r.isCloudLoggingEnabled(cluster),
+		},
 	}
-	zone, err := r.dnsService.ManagedZones.Create(cluster.Spec.Project, zone).
+	zone.Labels[ZoneLabelManagedBy] = ZoneManagedBy
+	zone, err := r.dnsService.ManagedZones.Create(cluster.Project, zone).
 		Context(ctx).
 		Do()
 
 	if hasHttpCode(err, http.StatusConflict) {
 		logger.Info("Getting existing zone")
-		return r.getManagedZone(ctx, cluster)
+		zone, err = r.getManagedZone(ctx, cluster)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		err = r.reconcileExistingZone(ctx, logger, cluster, domain, zone)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return zone, nil
 	}
 
 	if err != nil {
@@ -114,13 +422,84 @@ func (r *Zone) createManagedZone(ctx context.Context, logger logr.Logger, domain
 	return zone, err
 }
 
-func (r *Zone) getManagedZone(ctx context.Context, cluster *capg.GCPCluster) (*clouddns.ManagedZone, error) {
-	return r.dnsService.ManagedZones.Get(cluster.Spec.Project, cluster.Name).
+// getClusterZone returns the zone of the cluster, or nil if it doesn't exist.
+// A zone of the same name for another domain, e.g. the private zone of
+// another cluster, is not the zone of the cluster and is left alone.
+func (r *Zone) getClusterZone(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster) (*clouddns.ManagedZone, error) {
+	zone, err := r.getManagedZone(ctx, cluster)
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !strings.EqualFold(zone.DnsName, r.getClusterDomain(cluster)) {
+		logger.Info("Skipping zone of another domain", "zone", zone.Name, "domain", zone.DnsName)
+		return nil, nil
+	}
+
+	return zone, nil
+}
+
+// hasOtherManagementCluster returns whether the zone is labeled with another
+// management cluster than the one running the operator.
+func (r *Zone) hasOtherManagementCluster(zone *clouddns.ManagedZone) bool {
+	zoneManagementCluster, ok := zone.Labels[ZoneLabelManagementCluster]
+	return ok && zoneManagementCluster != SanitizeLabelValue(r.managementCluster)
+}
+
+// releaseZone removes the labels the operator added to an adopted zone, so
+// that it is no longer associated with the deleted cluster.
+func (r *Zone) releaseZone(ctx context.Context, logger logr.Logger, cluster *clusterview.Cluster, zone *clouddns.ManagedZone) error {
+	labels := map[string]string{}
+	for key, value := range zone.Labels {
+		labels[key] = value
+	}
+	for key := range getClusterLabels(cluster, r.managementCluster) {
+		delete(labels, key)
+	}
+	delete(labels, ZoneLabelManagementCluster)
+	delete(labels, ZoneLabelAdopted)
+	delete(labels, ZoneLabelRetained)
+
+	if equalLabels(labels, zone.Labels) {
+		logger.Info("Skipping. Adopted zone already released")
+		return nil
+	}
+
+	logger.Info("Releasing adopted zone, keeping it and its delegation", "labels", labels)
+	_, err := r.dnsService.ManagedZones.Patch(cluster.Project, cluster.Name, &clouddns.ManagedZone{
+		Labels:          labels,
+		ForceSendFields: []string{"Labels"},
+	}).
 		Context(ctx).
 		Do()
+	return microerror.Mask(err)
+}
+
+func (r *Zone) getManagedZone(ctx context.Context, cluster *clusterview.Cluster) (*clouddns.ManagedZone, error) {
+	return r.dnsService.ManagedZones.Get(cluster.Project, cluster.Name).
+		Context(ctx).
+		Do()
+}
+
+// getClusterLabels returns the labels identifying the cluster and the
+// operator managing it.
+func getClusterLabels(cluster *clusterview.Cluster, managementCluster string) map[string]string {
+	labels := map[string]string{
+		ZoneLabelClusterName:      SanitizeLabelValue(cluster.Name),
+		ZoneLabelClusterNamespace: SanitizeLabelValue(cluster.Namespace),
+		ZoneLabelOperatorVersion:  SanitizeLabelValue(project.Version()),
+	}
+
+	if managementCluster != "" {
+		labels[ZoneLabelManagementCluster] = SanitizeLabelValue(managementCluster)
+	}
+
+	return labels
 }
 
-func (r *Zone) getClusterDomain(cluster *capg.GCPCluster) string {
+func (r *Zone) getClusterDomain(cluster *clusterview.Cluster) string {
 	return fmt.Sprintf("%s.%s.", cluster.Name, r.baseDomain)
 }
 
@@ -0,0 +1,145 @@
+// Package shard splits the clusters of a management cluster between multiple
+// deployments of the operator. Each deployment serves the clusters of its
+// shard, selected by namespace and labels of the CAPI cluster, and runs its
+// own leader election, so that the shards are reconciled independently.
+package shard
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard selects the clusters served by the operator. The nil Shard contains
+// all clusters.
+type Shard struct {
+	name string
+	// namespaces is nil, when the shard contains the clusters of all
+	// namespaces.
+	namespaces map[string]bool
+	selector   labels.Selector
+}
+
+// New returns the shard of the given name, containing the clusters in one of
+// the namespaces, which match the label selector. Without namespaces and
+// selector, the shard contains all clusters. It is nil when no name is
+// given, as unnamed shards would share the leader election of the unsharded
+// operator.
+func New(name string, namespaces []string, selector string) (*Shard, error) {
+	if name == "" {
+		if len(namespaces) > 0 || selector != "" {
+			return nil, microerror.Mask(fmt.Errorf("shard with namespaces or label selector requires a name"))
+		}
+		return nil, nil
+	}
+
+	if messages := validation.IsDNS1123Label(name); len(messages) > 0 {
+		return nil, microerror.Mask(fmt.Errorf("invalid shard name %q: %s", name, messages[0]))
+	}
+
+	parsedSelector, err := labels.Parse(selector)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var namespaceSet map[string]bool
+	if len(namespaces) > 0 {
+		namespaceSet = map[string]bool{}
+		for _, namespace := range namespaces {
+			namespaceSet[namespace] = true
+		}
+	}
+
+	return &Shard{
+		name:       name,
+		namespaces: namespaceSet,
+		selector:   parsedSelector,
+	}, nil
+}
+
+// Name returns the name of the shard, which is empty for the nil Shard.
+func (s *Shard) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+// LeaderElectionID returns the leader election ID of the shard, which is
+// derived from the leader election ID of the unsharded operator.
+func (s *Shard) LeaderElectionID(id string) string {
+	if s == nil {
+		return id
+	}
+	return fmt.Sprintf("%s-%s", s.name, id)
+}
+
+// Contains returns whether the CAPI cluster belongs to the shard.
+func (s *Shard) Contains(cluster client.Object) bool {
+	if s == nil {
+		return true
+	}
+	if s.namespaces != nil && !s.namespaces[cluster.GetNamespace()] {
+		return false
+	}
+	return s.selector.Matches(labels.Set(cluster.GetLabels()))
+}
+
+// Predicate filters events of CAPI clusters down to the clusters of the
+// shard.
+func (s *Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(s.Contains)
+}
+
+// NamespacePredicate filters events of objects down to the namespaces of the
+// shard, e.g. of the infrastructure clusters and machines of its clusters.
+func (s *Shard) NamespacePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s == nil || s.namespaces == nil || s.namespaces[obj.GetNamespace()]
+	})
+}
+
+// NewCache returns the constructor of the manager cache, which restricts the
+// cache to the namespaces of the shard and the cached clusters to its label
+// selector. Objects of other shards are neither watched nor readable through
+// the cached client.
+func (s *Shard) NewCache(cluster client.Object) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		if s == nil {
+			return cache.New(config, opts)
+		}
+
+		if !s.selector.Empty() {
+			opts.SelectorsByObject = cache.SelectorsByObject{
+				cluster: {Label: s.selector},
+			}
+		}
+		if s.namespaces == nil {
+			return cache.New(config, opts)
+		}
+
+		var namespaces []string
+		for namespace := range s.namespaces {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+
+		return cache.MultiNamespacedCacheBuilder(namespaces)(config, opts)
+	}
+}
+
+// Selective returns whether the shard selects clusters by namespace or label,
+// so that it contains only part of the clusters.
+func (s *Shard) Selective() bool {
+	if s == nil {
+		return false
+	}
+	return s.namespaces != nil || !s.selector.Empty()
+}
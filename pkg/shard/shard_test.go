@@ -0,0 +1,132 @@
+package shard_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/shard"
+)
+
+var _ = Describe("Shard", func() {
+	newCluster := func(namespace string, labels map[string]string) *capi.Cluster {
+		return &capi.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: namespace,
+				Labels:    labels,
+			},
+		}
+	}
+
+	Describe("New", func() {
+		It("returns the nil shard without a name", func() {
+			s, err := shard.New("", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s).To(BeNil())
+			Expect(s.Contains(newCluster("org-a", nil))).To(BeTrue())
+			Expect(s.LeaderElectionID("c6d2deb7.giantswarm.io")).To(Equal("c6d2deb7.giantswarm.io"))
+		})
+
+		It("requires a name for namespaces", func() {
+			_, err := shard.New("", []string{"org-a"}, "")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("requires a name for a label selector", func() {
+			_, err := shard.New("", nil, "shard=a")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects invalid names", func() {
+			_, err := shard.New("Shard.A", nil, "")
+			Expect(err).To(MatchError(ContainSubstring("invalid shard name")))
+		})
+
+		It("rejects invalid label selectors", func() {
+			_, err := shard.New("a", nil, "shard in a")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("LeaderElectionID", func() {
+		It("prefixes the id with the shard name", func() {
+			s, err := shard.New("a", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.LeaderElectionID("c6d2deb7.giantswarm.io")).To(Equal("a-c6d2deb7.giantswarm.io"))
+		})
+	})
+
+	Describe("Contains", func() {
+		It("contains all clusters without namespaces and selector", func() {
+			s, err := shard.New("a", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Contains(newCluster("org-a", nil))).To(BeTrue())
+		})
+
+		It("contains the clusters of its namespaces", func() {
+			s, err := shard.New("a", []string{"org-a", "org-b"}, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Contains(newCluster("org-a", nil))).To(BeTrue())
+			Expect(s.Contains(newCluster("org-b", nil))).To(BeTrue())
+			Expect(s.Contains(newCluster("org-c", nil))).To(BeFalse())
+		})
+
+		It("contains the clusters matching its selector", func() {
+			s, err := shard.New("a", nil, "shard=a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Contains(newCluster("org-a", map[string]string{"shard": "a"}))).To(BeTrue())
+			Expect(s.Contains(newCluster("org-a", map[string]string{"shard": "b"}))).To(BeFalse())
+			Expect(s.Contains(newCluster("org-a", nil))).To(BeFalse())
+		})
+
+		It("contains the clusters of its namespaces matching its selector", func() {
+			s, err := shard.New("a", []string{"org-a"}, "!shard")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Contains(newCluster("org-a", nil))).To(BeTrue())
+			Expect(s.Contains(newCluster("org-a", map[string]string{"shard": "b"}))).To(BeFalse())
+			Expect(s.Contains(newCluster("org-b", nil))).To(BeFalse())
+		})
+	})
+
+	Describe("Selective", func() {
+		It("is not selective without namespaces and selector", func() {
+			var nilShard *shard.Shard
+			Expect(nilShard.Selective()).To(BeFalse())
+
+			s, err := shard.New("a", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Selective()).To(BeFalse())
+		})
+
+		It("is selective with namespaces or a selector", func() {
+			s, err := shard.New("a", []string{"org-a"}, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Selective()).To(BeTrue())
+
+			s, err = shard.New("a", nil, "shard=a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Selective()).To(BeTrue())
+		})
+	})
+
+	Describe("NamespacePredicate", func() {
+		It("accepts objects of all namespaces without namespaces", func() {
+			var nilShard *shard.Shard
+			Expect(nilShard.NamespacePredicate().Generic(event.GenericEvent{Object: newCluster("org-a", nil)})).To(BeTrue())
+
+			s, err := shard.New("a", nil, "shard=a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.NamespacePredicate().Generic(event.GenericEvent{Object: newCluster("org-a", nil)})).To(BeTrue())
+		})
+
+		It("accepts objects of its namespaces regardless of their labels", func() {
+			s, err := shard.New("a", []string{"org-a"}, "shard=a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.NamespacePredicate().Generic(event.GenericEvent{Object: newCluster("org-a", nil)})).To(BeTrue())
+			Expect(s.NamespacePredicate().Generic(event.GenericEvent{Object: newCluster("org-b", nil)})).To(BeFalse())
+		})
+	})
+})
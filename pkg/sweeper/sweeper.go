@@ -0,0 +1,548 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+
+const cloudDNSNameServerSuffix = ".googledomains.com."
+
+var orphansGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "dns_operator_gcp",
+		Subsystem: "sweeper",
+		Name:      "orphans",
+		Help:      "Number of orphaned cluster zones and delegations found by the last sweep.",
+	},
+	[]string{"kind"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphansGauge)
+}
+
+//counterfeiter:generate . ClusterLister
+type ClusterLister interface {
+	List(context.Context) ([]clusterview.Cluster, error)
+}
+
+type Config struct {
+	BaseDomain       string
+	ParentDNSZone    string
+	ParentGCPProject string
+	// ManagementCluster is the name of the management cluster running the
+	// operator. Zones labeled with another management cluster are not
+	// swept.
+	ManagementCluster string
+	// Projects are swept in addition to the parent project and the projects
+	// of the existing clusters, so that zones are also found in projects
+	// whose clusters are all gone.
+	Projects []string
+
+	// Interval is the time between two sweeps.
+	Interval time.Duration
+	// GracePeriod is the time an orphan has to be seen continuously before
+	// it is deleted.
+	GracePeriod time.Duration
+	// ReportOnly disables deletion. Orphans are only logged and exported as
+	// metrics.
+	ReportOnly bool
+	// ForceDeletion deletes orphaned zones containing records, which were
+	// neither created by the operator nor imported from a zone file. Such
+	// zones and their delegations are only reported otherwise.
+	ForceDeletion bool
+	// AuditSink is optional. When it is set, the records deleted by the
+	// sweeper are recorded in it.
+	AuditSink audit.Sink
+}
+
+// zoneDeletion is an orphaned zone, which is deleted at the end of the sweep.
+type zoneDeletion struct {
+	project string
+	zone    *clouddns.ManagedZone
+	records []*clouddns.ResourceRecordSet
+}
+
+// Sweeper periodically looks for cluster zones and parent zone delegations
+// created by the operator, whose cluster no longer exists. This happens when
+// an infrastructure cluster is force deleted by removing the finalizer
+// manually.
+// Zones retained by the deletion policy of their cluster, and their
+// delegations, are kept. So are zones containing records not managed by the
+// operator, unless the deletion is forced.
+// Delegations are only deleted together with their orphaned zone. Delegations
+// of zones the sweeper doesn't see, e.g. of another management cluster
+// sharing the parent zone or in a project which isn't swept, are reported and
+// kept.
+type Sweeper struct {
+	clusters   ClusterLister
+	dnsService *clouddns.Service
+	config     Config
+
+	mutex     sync.Mutex
+	firstSeen map[string]time.Time
+	now       func() time.Time
+}
+
+func New(config Config, clusters ClusterLister, dnsService *clouddns.Service) *Sweeper {
+	return &Sweeper{
+		clusters:   clusters,
+		dnsService: dnsService,
+		config:     config,
+		firstSeen:  map[string]time.Time{},
+		now:        time.Now,
+	}
+}
+
+// Start runs the sweeper until the context is cancelled. It implements
+// manager.Runnable.
+func (s *Sweeper) Start(ctx context.Context) error {
+	logger := s.getLogger(ctx)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.Sweep(ctx)
+		if err != nil {
+			logger.Error(err, "Failed to sweep orphaned zones")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes sure only the leader sweeps.
+func (s *Sweeper) NeedLeaderElection() bool {
+	return true
+}
+
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	logger := s.getLogger(ctx)
+
+	if s.config.AuditSink != nil {
+		auditID := string(uuid.NewUUID())
+		logger = logger.WithValues("auditID", auditID)
+		ctx = audit.IntoContext(ctx, audit.NewRecorder(s.config.AuditSink, "", auditID))
+	}
+
+	logger.Info("Sweeping orphaned zones")
+	defer logger.Info("Done sweeping orphaned zones")
+
+	clusters, err := s.clusters.List(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	clusterDomains := map[string]bool{}
+	clusterZones := map[string]bool{}
+	clusterLabels := map[string]bool{}
+	projects := []string{s.config.ParentGCPProject}
+	for _, project := range s.config.Projects {
+		projects = appendUnique(projects, project)
+	}
+	for _, cluster := range clusters {
+		clusterDomains[s.getClusterDomain(cluster.Name)] = true
+		clusterZones[zoneKey(cluster.Project, cluster.Name)] = true
+		clusterLabels[labelsKey(registrar.SanitizeLabelValue(cluster.Namespace), registrar.SanitizeLabelValue(cluster.Name))] = true
+		projects = appendUnique(projects, cluster.Project)
+	}
+
+	seen := map[string]bool{}
+	// keptDomains are the domains of the zones, which are kept although
+	// their cluster is gone. Their delegations are kept as well.
+	keptDomains := map[string]bool{}
+	// orphanedNameServers are the name servers of the orphaned zones by
+	// their domain. Only delegations to these name servers are orphaned.
+	orphanedNameServers := map[string][]string{}
+	var deletions []zoneDeletion
+
+	orphanedZones := 0
+	for _, project := range projects {
+		zones, err := s.listOperatorZones(ctx, project)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, zone := range zones {
+			key := zoneKey(project, zone.Name)
+			if s.hasCluster(zone, clusterZones, clusterLabels, key) {
+				continue
+			}
+			if _, ok := zone.Labels[registrar.ZoneLabelRetained]; ok {
+				keptDomains[zone.DnsName] = true
+				continue
+			}
+
+			orphanedZones++
+			seen[key] = true
+			logger := logger.WithValues("project", project, "zone", zone.Name)
+
+			records, err := s.listRecords(ctx, project, zone)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			foreignRecords := registrar.ForeignRecords(zone.DnsName, records)
+			if len(foreignRecords) > 0 && !s.config.ForceDeletion {
+				keptDomains[zone.DnsName] = true
+				logger.Info("Found orphaned zone containing records not managed by the operator, not deleting it", "records", recordNames(foreignRecords))
+				continue
+			}
+			orphanedNameServers[zone.DnsName] = zone.NameServers
+
+			if !s.gracePeriodExpired(key) {
+				logger.Info("Found orphaned zone, waiting for grace period to expire")
+				continue
+			}
+			if s.config.ReportOnly {
+				logger.Info("Found orphaned zone, not deleting in report only mode")
+				continue
+			}
+
+			deletions = append(deletions, zoneDeletion{
+				project: project,
+				zone:    zone,
+				records: records,
+			})
+		}
+	}
+
+	records, err := s.listOperatorDelegations(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	orphanedDelegations := 0
+	for _, record := range records {
+		if clusterDomains[record.Name] || keptDomains[record.Name] {
+			continue
+		}
+
+		logger := logger.WithValues("record", record.Name)
+		nameServers, ok := orphanedNameServers[record.Name]
+		if !ok || !equalNameServers(nameServers, record.Rrdatas) {
+			// The zone may belong to another management cluster sharing
+			// the parent zone or live in a project which isn't swept.
+			logger.Info("Found delegation without cluster to a zone which was not swept, not deleting it", "nameServers", record.Rrdatas)
+			continue
+		}
+
+		orphanedDelegations++
+		key := delegationKey(record.Name)
+		seen[key] = true
+		if !s.gracePeriodExpired(key) {
+			logger.Info("Found orphaned delegation, waiting for grace period to expire")
+			continue
+		}
+		if s.config.ReportOnly {
+			logger.Info("Found orphaned delegation, not deleting in report only mode")
+			continue
+		}
+
+		logger.Info("Deleting orphaned delegation")
+		err = s.deleteRecord(ctx, s.config.ParentGCPProject, s.config.ParentDNSZone, record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Zones are deleted after their delegations, so that a delegation is
+	// never left behind without the zone, which identifies it as orphaned.
+	for _, deletion := range deletions {
+		logger := logger.WithValues("project", deletion.project, "zone", deletion.zone.Name)
+		err = s.deleteZone(ctx, logger, deletion.project, deletion.zone, deletion.records)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	s.forgetResolved(seen)
+	orphansGauge.WithLabelValues("zone").Set(float64(orphanedZones))
+	orphansGauge.WithLabelValues("delegation").Set(float64(orphanedDelegations))
+
+	return nil
+}
+
+// hasCluster returns whether the cluster of the zone still exists. Zones are
+// matched to their cluster by their labels. Zones created before they were
+// labeled are matched by their project and name.
+func (s *Sweeper) hasCluster(zone *clouddns.ManagedZone, clusterZones, clusterLabels map[string]bool, key string) bool {
+	name, hasName := zone.Labels[registrar.ZoneLabelClusterName]
+	namespace, hasNamespace := zone.Labels[registrar.ZoneLabelClusterNamespace]
+	if hasName && hasNamespace {
+		return clusterLabels[labelsKey(namespace, name)]
+	}
+
+	return clusterZones[key]
+}
+
+// listOperatorZones returns the zones in the project, which were created by
+// the zone registrar of this management cluster for a cluster under the base
+// domain. Zones are identified by their labels, or their description if they
+// were created before they were labeled. Adopted zones were not created by
+// the operator and are never swept.
+func (s *Sweeper) listOperatorZones(ctx context.Context, project string) ([]*clouddns.ManagedZone, error) {
+	managementCluster := registrar.SanitizeLabelValue(s.config.ManagementCluster)
+
+	var zones []*clouddns.ManagedZone
+	err := s.dnsService.ManagedZones.List(project).
+		Pages(ctx, func(page *clouddns.ManagedZonesListResponse) error {
+			for _, zone := range page.ManagedZones {
+				managedBy := zone.Labels[registrar.ZoneLabelManagedBy] == registrar.ZoneManagedBy
+				if !managedBy && zone.Description != registrar.ZoneDescription {
+					continue
+				}
+				if !s.isClusterDomain(zone.DnsName) {
+					continue
+				}
+
+				zoneManagementCluster, ok := zone.Labels[registrar.ZoneLabelManagementCluster]
+				if ok && zoneManagementCluster != managementCluster {
+					continue
+				}
+
+				zones = append(zones, zone)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return zones, nil
+}
+
+// listOperatorDelegations returns the NS records in the parent zone, which
+// delegate a cluster domain to Cloud DNS name servers.
+func (s *Sweeper) listOperatorDelegations(ctx context.Context) ([]*clouddns.ResourceRecordSet, error) {
+	var records []*clouddns.ResourceRecordSet
+	err := s.dnsService.ResourceRecordSets.List(s.config.ParentGCPProject, s.config.ParentDNSZone).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			for _, record := range page.Rrsets {
+				if record.Type == registrar.RecordNS && s.isClusterDomain(record.Name) && isCloudDNSDelegation(record) {
+					records = append(records, record)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
+func (s *Sweeper) listRecords(ctx context.Context, project string, zone *clouddns.ManagedZone) ([]*clouddns.ResourceRecordSet, error) {
+	var records []*clouddns.ResourceRecordSet
+	err := s.dnsService.ResourceRecordSets.List(project, zone.Name).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			records = append(records, page.Rrsets...)
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
+func (s *Sweeper) deleteZone(ctx context.Context, logger logr.Logger, project string, zone *clouddns.ManagedZone, records []*clouddns.ResourceRecordSet) error {
+	logger.Info("Deleting orphaned zone")
+
+	// Cloud DNS refuses to delete zones which still contain records, apart
+	// from the SOA and NS records at the apex of the zone. The TXT record
+	// listing the imported records is deleted last, so that the remaining
+	// imported records are still recognized after a failure.
+	importedRecordsName := registrar.ImportedRecordsName(zone.DnsName)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[j].Name == importedRecordsName && records[i].Name != importedRecordsName
+	})
+	for _, record := range records {
+		if record.Name == zone.DnsName && (record.Type == registrar.RecordSOA || record.Type == registrar.RecordNS) {
+			continue
+		}
+
+		logger.Info("Deleting record of orphaned zone", "record", record.Name, "type", record.Type)
+		err := s.deleteRecord(ctx, project, zone.Name, record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err := s.dnsService.ManagedZones.Delete(project, zone.Name).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil
+	}
+
+	return microerror.Mask(err)
+}
+
+func (s *Sweeper) deleteRecord(ctx context.Context, project, zone string, record *clouddns.ResourceRecordSet) error {
+	_, err := s.dnsService.ResourceRecordSets.Delete(project, zone, record.Name, record.Type).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	audit.FromContext(ctx).Record(ctx, audit.Entry{
+		Action:  audit.ActionDelete,
+		Project: project,
+		Zone:    zone,
+		Name:    record.Name,
+		Type:    record.Type,
+		Before:  record.Rrdatas,
+	})
+	return nil
+}
+
+func (s *Sweeper) gracePeriodExpired(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	firstSeen, ok := s.firstSeen[key]
+	if !ok {
+		s.firstSeen[key] = now
+		firstSeen = now
+	}
+
+	return now.Sub(firstSeen) >= s.config.GracePeriod
+}
+
+// forgetResolved drops the orphans which were not seen in the last sweep, so
+// that their grace period starts over if they show up again.
+func (s *Sweeper) forgetResolved(seen map[string]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key := range s.firstSeen {
+		if !seen[key] {
+			delete(s.firstSeen, key)
+		}
+	}
+}
+
+func (s *Sweeper) getClusterDomain(clusterName string) string {
+	return fmt.Sprintf("%s.%s.", clusterName, s.config.BaseDomain)
+}
+
+func (s *Sweeper) isClusterDomain(domain string) bool {
+	suffix := fmt.Sprintf(".%s.", s.config.BaseDomain)
+	return strings.HasSuffix(domain, suffix) && domain != suffix[1:]
+}
+
+func (s *Sweeper) getLogger(ctx context.Context) logr.Logger {
+	logger := log.FromContext(ctx)
+	return logger.WithName("sweeper")
+}
+
+func isCloudDNSDelegation(record *clouddns.ResourceRecordSet) bool {
+	if len(record.Rrdatas) == 0 {
+		return false
+	}
+
+	for _, nameServer := range record.Rrdatas {
+		if !strings.HasSuffix(nameServer, cloudDNSNameServerSuffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalNameServers returns whether both lists contain the same name servers,
+// regardless of their order and trailing dots.
+func equalNameServers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	nameServers := map[string]bool{}
+	for _, nameServer := range a {
+		nameServers[strings.TrimSuffix(nameServer, ".")] = true
+	}
+	for _, nameServer := range b {
+		if !nameServers[strings.TrimSuffix(nameServer, ".")] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func recordNames(records []*clouddns.ResourceRecordSet) []string {
+	var names []string
+	for _, record := range records {
+		names = append(names, fmt.Sprintf("%s %s", record.Name, record.Type))
+	}
+
+	return names
+}
+
+func zoneKey(project, zone string) string {
+	return fmt.Sprintf("zone/%s/%s", project, zone)
+}
+
+func labelsKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+func delegationKey(domain string) string {
+	return fmt.Sprintf("delegation/%s", domain)
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+func hasHttpCode(err error, statusCode int) bool {
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		if googleErr.Code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,150 @@
+package sweeper_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/sweeper"
+	"github.com/giantswarm/dns-operator-gcp/pkg/sweeper/sweeperfakes"
+)
+
+var _ = Describe("Sweeper", func() {
+	const (
+		parentProject = "parent-project"
+		parentZone    = "parent"
+	)
+
+	var (
+		ctx context.Context
+
+		server   *httptest.Server
+		service  *clouddns.Service
+		clusters *sweeperfakes.FakeClusterLister
+
+		zones       []*clouddns.ManagedZone
+		delegations []*clouddns.ResourceRecordSet
+		deleted     []string
+	)
+
+	newZone := func(name, managementCluster, nameServer string) *clouddns.ManagedZone {
+		return &clouddns.ManagedZone{
+			Name:        name,
+			DnsName:     name + ".example.com.",
+			NameServers: []string{nameServer},
+			Labels: map[string]string{
+				registrar.ZoneLabelManagedBy:         registrar.ZoneManagedBy,
+				registrar.ZoneLabelManagementCluster: managementCluster,
+				registrar.ZoneLabelClusterName:       name,
+				registrar.ZoneLabelClusterNamespace:  "org-test",
+			},
+		}
+	}
+
+	newDelegation := func(name, nameServer string) *clouddns.ResourceRecordSet {
+		return &clouddns.ResourceRecordSet{
+			Name:    name + ".example.com.",
+			Type:    registrar.RecordNS,
+			Ttl:     300,
+			Rrdatas: []string{nameServer},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		deleted = nil
+
+		zones = []*clouddns.ManagedZone{
+			newZone("orphan", "test-management-cluster", "ns-cloud-a1.googledomains.com."),
+			newZone("other", "other-management-cluster", "ns-cloud-b1.googledomains.com."),
+		}
+		delegations = []*clouddns.ResourceRecordSet{
+			newDelegation("orphan", "ns-cloud-a1.googledomains.com."),
+			newDelegation("other", "ns-cloud-b1.googledomains.com."),
+			newDelegation("unswept", "ns-cloud-c1.googledomains.com."),
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			path := strings.TrimPrefix(r.URL.Path, "/dns/v1")
+			switch {
+			case r.Method == http.MethodDelete:
+				deleted = append(deleted, path)
+				_, _ = w.Write([]byte(`{}`))
+			case path == "/projects/parent-project/managedZones":
+				Expect(json.NewEncoder(w).Encode(&clouddns.ManagedZonesListResponse{ManagedZones: zones})).To(Succeed())
+			case path == "/projects/parent-project/managedZones/parent/rrsets":
+				Expect(json.NewEncoder(w).Encode(&clouddns.ResourceRecordSetsListResponse{Rrsets: delegations})).To(Succeed())
+			case strings.HasSuffix(path, "/rrsets"):
+				Expect(json.NewEncoder(w).Encode(&clouddns.ResourceRecordSetsListResponse{})).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+			}
+		}))
+
+		var err error
+		service, err = clouddns.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).NotTo(HaveOccurred())
+
+		clusters = new(sweeperfakes.FakeClusterLister)
+		clusters.ListReturns([]clusterview.Cluster{}, nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	sweep := func() error {
+		return sweeper.New(sweeper.Config{
+			BaseDomain:        "example.com",
+			ParentDNSZone:     parentZone,
+			ParentGCPProject:  parentProject,
+			ManagementCluster: "test-management-cluster",
+		}, clusters, service).Sweep(ctx)
+	}
+
+	It("deletes the delegation before the orphaned zone", func() {
+		Expect(sweep()).To(Succeed())
+
+		Expect(deleted).To(Equal([]string{
+			"/projects/parent-project/managedZones/parent/rrsets/orphan.example.com./NS",
+			"/projects/parent-project/managedZones/orphan",
+		}))
+	})
+
+	It("keeps the zone and delegation of another management cluster sharing the parent zone", func() {
+		Expect(sweep()).To(Succeed())
+
+		Expect(deleted).NotTo(ContainElement(ContainSubstring("other")))
+	})
+
+	It("keeps delegations of zones which were not swept", func() {
+		Expect(sweep()).To(Succeed())
+
+		Expect(deleted).NotTo(ContainElement(ContainSubstring("unswept")))
+	})
+
+	When("the delegation points to the name servers of another zone", func() {
+		BeforeEach(func() {
+			delegations[0].Rrdatas = []string{"ns-cloud-d1.googledomains.com."}
+		})
+
+		It("keeps the delegation", func() {
+			Expect(sweep()).To(Succeed())
+
+			Expect(deleted).To(Equal([]string{
+				"/projects/parent-project/managedZones/orphan",
+			}))
+		})
+	})
+})
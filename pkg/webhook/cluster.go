@@ -0,0 +1,337 @@
+// Package webhook rejects clusters, whose DNS can't be managed by the
+// operator, when they are created instead of failing deep in the registrars.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+const (
+	// maxDomainLength is the maximum length of a domain name without the
+	// trailing dot.
+	maxDomainLength = 253
+)
+
+type Config struct {
+	BaseDomain string
+	// DefaultProject is the project of cluster zones, whose cluster has no
+	// project.
+	DefaultProject string
+	// Registrars are the names of the registrars, which can be disabled with
+	// the annotation.DisabledRegistrars annotation.
+	Registrars []string
+	// EnabledRegistrars are the names of the registrars enabled for all
+	// clusters with the --registrars flag. The annotation can only disable
+	// these, registrars disabled for all clusters can't be enabled per
+	// cluster.
+	EnabledRegistrars []string
+	// InfrastructureKinds are the kinds of the infrastructure clusters whose
+	// clusters get DNS records.
+	InfrastructureKinds []string
+}
+
+// ClusterValidator validates the name of infrastructure clusters, which the
+// cluster zone is named after, and the operator annotations of
+// infrastructure clusters and CAPI clusters. GCPClusters and
+// GCPManagedClusters are validated by their own webhooks, the names of
+// infrastructure clusters of other providers when their CAPI cluster is
+// created.
+type ClusterValidator struct {
+	config     Config
+	dnsService *clouddns.Service
+}
+
+func NewClusterValidator(config Config, dnsService *clouddns.Service) *ClusterValidator {
+	return &ClusterValidator{
+		config:     config,
+		dnsService: dnsService,
+	}
+}
+
+// SetupWithManager registers the validating webhooks of GCPClusters and CAPI
+// clusters with the webhook server of the manager.
+func (v *ClusterValidator) SetupWithManager(mgr ctrl.Manager) error {
+	for _, kind := range v.config.InfrastructureKinds {
+		var obj client.Object
+		switch kind {
+		case k8sclient.KindGCPCluster:
+			obj = &capg.GCPCluster{}
+		case k8sclient.KindGCPManagedCluster:
+			obj = k8sclient.NewGCPManagedClusterObject()
+		default:
+			continue
+		}
+
+		err := ctrl.NewWebhookManagedBy(mgr).
+			For(obj).
+			WithValidator(v).
+			Complete()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err := ctrl.NewWebhookManagedBy(mgr).
+		For(&capi.Cluster{}).
+		WithValidator(v).
+		Complete()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (v *ClusterValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	switch cluster := obj.(type) {
+	case *capi.Cluster:
+		errs := v.validateAnnotations(cluster.Annotations)
+		if ref := cluster.Spec.InfrastructureRef; ref != nil && v.isServed(ref.Kind) {
+			errs = append(errs, v.validateName(field.NewPath("spec", "infrastructureRef", "name"), ref.Name)...)
+		}
+		return toInvalidError(cluster, errs)
+	case client.Object:
+		errs := v.validateName(field.NewPath("metadata", "name"), cluster.GetName())
+		errs = append(errs, v.validateAnnotations(cluster.GetAnnotations())...)
+		if len(errs) == 0 {
+			errs = append(errs, v.validateZone(ctx, cluster)...)
+		}
+		return toInvalidError(cluster, errs)
+	}
+
+	return nil
+}
+
+// ValidateUpdate only validates the annotations whose value changed, as the
+// name can't change and the zone already belongs to the cluster. Clusters
+// with invalid annotations, which were set before, e.g. before the webhook
+// was installed, can still be updated. Updates deleting the cluster or
+// removing finalizers are always allowed, so that deletions never get stuck.
+func (v *ClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	oldCluster, ok := oldObj.(client.Object)
+	if !ok {
+		return nil
+	}
+	cluster, ok := newObj.(client.Object)
+	if !ok {
+		return nil
+	}
+
+	if !cluster.GetDeletionTimestamp().IsZero() || len(cluster.GetFinalizers()) < len(oldCluster.GetFinalizers()) {
+		return nil
+	}
+
+	changed := changedAnnotations(oldCluster.GetAnnotations(), cluster.GetAnnotations())
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var errs field.ErrorList
+	path := field.NewPath("metadata", "annotations")
+	for _, err := range v.validateAnnotations(cluster.GetAnnotations()) {
+		for key := range changed {
+			if err.Field == path.Key(key).String() {
+				errs = append(errs, err)
+				break
+			}
+		}
+	}
+
+	return toInvalidError(cluster, errs)
+}
+
+func (v *ClusterValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+// validateName checks that the name of the infrastructure cluster can be
+// used as the label of the cluster domain and as name of the Cloud DNS zone.
+func (v *ClusterValidator) validateName(path *field.Path, name string) field.ErrorList {
+	var errs field.ErrorList
+	for _, message := range validation.IsDNS1123Label(name) {
+		errs = append(errs, field.Invalid(path, name, message))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if name[0] < 'a' || name[0] > 'z' {
+		errs = append(errs, field.Invalid(path, name, "must start with a letter to be used as Cloud DNS zone name"))
+	}
+
+	domain := v.getClusterDomain(name)
+	if len(domain) > maxDomainLength {
+		message := fmt.Sprintf("cluster domain %s must be no more than %d characters", domain, maxDomainLength)
+		errs = append(errs, field.Invalid(path, name, message))
+	}
+
+	return errs
+}
+
+func (v *ClusterValidator) validateAnnotations(annotations map[string]string) field.ErrorList {
+	path := field.NewPath("metadata", "annotations")
+
+	errs := annotation.Validate(annotations, path)
+
+	known := map[string]bool{}
+	for _, name := range v.config.Registrars {
+		known[name] = true
+	}
+	enabled := map[string]bool{}
+	for _, name := range v.config.EnabledRegistrars {
+		enabled[name] = true
+	}
+	for _, name := range annotation.GetList(annotations, annotation.DisabledRegistrars) {
+		if !known[name] {
+			message := fmt.Sprintf("unknown registrar %q, must be one of %s", name, strings.Join(v.config.Registrars, ", "))
+			errs = append(errs, field.Invalid(path.Key(annotation.DisabledRegistrars), annotations[annotation.DisabledRegistrars], message))
+		} else if v.config.EnabledRegistrars != nil && !enabled[name] {
+			message := fmt.Sprintf("registrar %q is disabled for all clusters with --registrars and can't be enabled or disabled per cluster", name)
+			errs = append(errs, field.Invalid(path.Key(annotation.DisabledRegistrars), annotations[annotation.DisabledRegistrars], message))
+		}
+	}
+
+	return errs
+}
+
+// validateZone checks that an existing zone of the same name can be used for
+// the cluster, the same way the zone registrar does: it must be a public zone
+// for the cluster domain and must not belong to a cluster of the same name in
+// another namespace. Zones can't be checked while Cloud DNS is unavailable,
+// which must not block creating clusters, so the check is skipped then.
+func (v *ClusterValidator) validateZone(ctx context.Context, cluster client.Object) field.ErrorList {
+	logger := log.FromContext(ctx)
+	path := field.NewPath("metadata", "name")
+
+	project := getProject(cluster)
+	if value := cluster.GetAnnotations()[annotation.GCPProject]; value != "" {
+		project = value
+	}
+	if project == "" {
+		project = v.config.DefaultProject
+	}
+	if project == "" {
+		return nil
+	}
+
+	zone, err := v.dnsService.ManagedZones.Get(project, cluster.GetName()).
+		Context(ctx).
+		Do()
+	if hasHttpCode(err, http.StatusNotFound) {
+		return nil
+	} else if err != nil {
+		logger.Error(err, "Skipping zone validation. Failed to get zone", "project", project, "zone", cluster.GetName())
+		return nil
+	}
+
+	domain := v.getClusterDomain(cluster.GetName()) + "."
+	if !strings.EqualFold(zone.DnsName, domain) || !strings.EqualFold(zone.Visibility, "public") {
+		message := fmt.Sprintf("zone %q exists in project %s for %s with %s visibility, expected %s with public visibility",
+			zone.Name, project, zone.DnsName, zone.Visibility, domain)
+		return field.ErrorList{field.Invalid(path, cluster.GetName(), message)}
+	}
+
+	_, retained := zone.Labels[registrar.ZoneLabelRetained]
+	namespace, ok := zone.Labels[registrar.ZoneLabelClusterNamespace]
+	if !retained && ok && namespace != registrar.SanitizeLabelValue(cluster.GetNamespace()) {
+		message := fmt.Sprintf("zone %q in project %s belongs to a cluster in namespace %s", zone.Name, project, namespace)
+		return field.ErrorList{field.Invalid(path, cluster.GetName(), message)}
+	}
+
+	return nil
+}
+
+func (v *ClusterValidator) getClusterDomain(name string) string {
+	return fmt.Sprintf("%s.%s", name, v.config.BaseDomain)
+}
+
+func (v *ClusterValidator) isServed(kind string) bool {
+	for _, served := range v.config.InfrastructureKinds {
+		if served == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getProject returns spec.project of the infrastructure cluster.
+func getProject(cluster client.Object) string {
+	switch cluster := cluster.(type) {
+	case *capg.GCPCluster:
+		return cluster.Spec.Project
+	case *unstructured.Unstructured:
+		project, _, _ := unstructured.NestedString(cluster.Object, "spec", "project")
+		return project
+	}
+
+	return ""
+}
+
+// changedAnnotations returns the keys of the annotations, which were added or
+// whose value changed. The backup endpoint of the api record counts as
+// changed with the routing policy, as both can't be combined.
+func changedAnnotations(oldAnnotations, newAnnotations map[string]string) map[string]bool {
+	changed := map[string]bool{}
+	for key, value := range newAnnotations {
+		if oldValue, ok := oldAnnotations[key]; !ok || oldValue != value {
+			changed[key] = true
+		}
+	}
+	if changed[annotation.APIRoutingPolicy] {
+		changed[annotation.APIBackupEndpoint] = true
+	}
+
+	return changed
+}
+
+// toInvalidError returns the errors as Invalid status error, which the API
+// server reports with all messages.
+func toInvalidError(obj client.Object, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	groupKind := capi.GroupVersion.WithKind("Cluster").GroupKind()
+	switch obj := obj.(type) {
+	case *capg.GCPCluster:
+		groupKind = capg.GroupVersion.WithKind(k8sclient.KindGCPCluster).GroupKind()
+	case *unstructured.Unstructured:
+		groupKind = obj.GroupVersionKind().GroupKind()
+	}
+
+	return apierrors.NewInvalid(groupKind, obj.GetName(), errs)
+}
+
+func hasHttpCode(err error, statusCode int) bool {
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		if googleErr.Code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,352 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/webhook"
+)
+
+var _ = Describe("ClusterValidator", func() {
+	var (
+		ctx context.Context
+
+		server *httptest.Server
+		// zone is returned by the fake Cloud DNS API, which returns 404 when
+		// it is nil.
+		zone      *clouddns.ManagedZone
+		zoneCalls int
+
+		validator  *webhook.ClusterValidator
+		gcpCluster *capg.GCPCluster
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		zone = nil
+		zoneCalls = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			zoneCalls++
+			w.Header().Set("Content-Type", "application/json")
+			if zone == nil {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+				return
+			}
+			Expect(json.NewEncoder(w).Encode(zone)).To(Succeed())
+		}))
+
+		service, err := clouddns.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).NotTo(HaveOccurred())
+
+		validator = webhook.NewClusterValidator(webhook.Config{
+			BaseDomain:          "example.com",
+			Registrars:          []string{registrar.NameZone, registrar.NameAPI, registrar.NameBastion},
+			InfrastructureKinds: []string{k8sclient.KindGCPCluster, k8sclient.KindGCPManagedCluster},
+		}, service)
+
+		gcpCluster = &capg.GCPCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-cluster",
+				Namespace:   "test",
+				Annotations: map[string]string{},
+			},
+			Spec: capg.GCPClusterSpec{
+				Project: "test-project",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	expectInvalid := func(err error, substring string) {
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(substring))
+	}
+
+	Describe("ValidateCreate", func() {
+		It("accepts valid clusters", func() {
+			Expect(validator.ValidateCreate(ctx, gcpCluster)).To(Succeed())
+			Expect(zoneCalls).To(Equal(1))
+		})
+
+		DescribeTable("rejects names which can't be used for the cluster zone",
+			func(name, message string) {
+				gcpCluster.Name = name
+				expectInvalid(validator.ValidateCreate(ctx, gcpCluster), message)
+				Expect(zoneCalls).To(BeZero())
+			},
+			Entry("upper case", "Test-Cluster", "a lowercase RFC 1123 label"),
+			Entry("dots", "test.cluster", "a lowercase RFC 1123 label"),
+			Entry("too long label", strings.Repeat("a", 64), "must be no more than 63 characters"),
+			Entry("leading digit", "1-cluster", "must start with a letter"),
+		)
+
+		When("the cluster domain is too long", func() {
+			BeforeEach(func() {
+				validator = webhook.NewClusterValidator(webhook.Config{
+					BaseDomain: strings.Repeat(strings.Repeat("a", 60)+".", 4) + "com",
+				}, nil)
+			})
+
+			It("rejects the cluster", func() {
+				expectInvalid(validator.ValidateCreate(ctx, gcpCluster), "must be no more than 253 characters")
+			})
+		})
+
+		When("the annotations are invalid", func() {
+			BeforeEach(func() {
+				gcpCluster.Annotations = map[string]string{
+					annotation.CloudLogging:        "yes",
+					annotation.DeletionPolicy:      "Keep",
+					annotation.APIRoutingPolicy:    `{"type":"round-robin"}`,
+					annotation.APIBackupEndpoint:   "backup.example.com",
+					annotation.APIInternalEndpoint: "10.0.0",
+					annotation.GCPProject:          "Project",
+					annotation.DisabledRegistrars:  "bastion,ingress",
+				}
+			})
+
+			It("rejects the cluster with all errors", func() {
+				err := validator.ValidateCreate(ctx, gcpCluster)
+				expectInvalid(err, annotation.CloudLogging)
+				expectInvalid(err, annotation.DeletionPolicy)
+				expectInvalid(err, annotation.APIRoutingPolicy)
+				expectInvalid(err, annotation.APIBackupEndpoint)
+				expectInvalid(err, annotation.APIInternalEndpoint)
+				expectInvalid(err, annotation.GCPProject)
+				expectInvalid(err, `unknown registrar "ingress"`)
+				Expect(zoneCalls).To(BeZero())
+			})
+		})
+
+		When("a registrar disabled for all clusters is disabled per cluster", func() {
+			BeforeEach(func() {
+				validator = webhook.NewClusterValidator(webhook.Config{
+					BaseDomain:        "example.com",
+					Registrars:        []string{registrar.NameZone, registrar.NameAPI, registrar.NameBastion},
+					EnabledRegistrars: []string{registrar.NameZone, registrar.NameAPI},
+				}, nil)
+				gcpCluster.Annotations[annotation.DisabledRegistrars] = registrar.NameBastion
+				gcpCluster.Spec.Project = ""
+			})
+
+			It("rejects the cluster, as the registrar can't be enabled per cluster", func() {
+				expectInvalid(validator.ValidateCreate(ctx, gcpCluster), `registrar "bastion" is disabled for all clusters`)
+			})
+
+			It("accepts disabling registrars enabled for all clusters", func() {
+				gcpCluster.Annotations[annotation.DisabledRegistrars] = registrar.NameAPI
+				Expect(validator.ValidateCreate(ctx, gcpCluster)).To(Succeed())
+			})
+		})
+
+		When("a routing policy is combined with a backup endpoint", func() {
+			BeforeEach(func() {
+				gcpCluster.Annotations = map[string]string{
+					annotation.APIRoutingPolicy:  `{"type":"weighted","endpoints":[{"address":"10.0.0.1","weight":1}]}`,
+					annotation.APIBackupEndpoint: "10.0.0.2:6443",
+				}
+			})
+
+			It("rejects the cluster", func() {
+				expectInvalid(validator.ValidateCreate(ctx, gcpCluster), "can't be combined")
+			})
+		})
+
+		When("a zone of the same name exists", func() {
+			BeforeEach(func() {
+				zone = &clouddns.ManagedZone{
+					Name:       "test-cluster",
+					DnsName:    "test-cluster.example.com.",
+					Visibility: "public",
+				}
+			})
+
+			It("accepts the cluster, which adopts the zone", func() {
+				Expect(validator.ValidateCreate(ctx, gcpCluster)).To(Succeed())
+			})
+
+			When("the zone is for another domain", func() {
+				BeforeEach(func() {
+					zone.DnsName = "other.example.com."
+				})
+
+				It("rejects the cluster", func() {
+					expectInvalid(validator.ValidateCreate(ctx, gcpCluster), `zone "test-cluster" exists in project test-project for other.example.com.`)
+				})
+			})
+
+			When("the zone belongs to a cluster in another namespace", func() {
+				BeforeEach(func() {
+					zone.Labels = map[string]string{
+						registrar.ZoneLabelClusterName:      "test-cluster",
+						registrar.ZoneLabelClusterNamespace: "other",
+					}
+				})
+
+				It("rejects the cluster", func() {
+					expectInvalid(validator.ValidateCreate(ctx, gcpCluster), "belongs to a cluster in namespace other")
+				})
+
+				When("the zone is retained", func() {
+					BeforeEach(func() {
+						zone.Labels[registrar.ZoneLabelRetained] = "true"
+					})
+
+					It("accepts the cluster", func() {
+						Expect(validator.ValidateCreate(ctx, gcpCluster)).To(Succeed())
+					})
+				})
+			})
+		})
+
+		When("the cluster is a CAPI cluster", func() {
+			var cluster *capi.Cluster
+
+			BeforeEach(func() {
+				cluster = &capi.Cluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "1-cluster",
+						Namespace: "test",
+						Annotations: map[string]string{
+							annotation.ForceZoneDeletion: "maybe",
+						},
+					},
+				}
+			})
+
+			It("only validates the annotations", func() {
+				err := validator.ValidateCreate(ctx, cluster)
+				expectInvalid(err, annotation.ForceZoneDeletion)
+				Expect(err.Error()).NotTo(ContainSubstring("must start with a letter"))
+				Expect(zoneCalls).To(BeZero())
+			})
+
+			When("it references an infrastructure cluster of a served kind", func() {
+				BeforeEach(func() {
+					cluster.Annotations = nil
+					cluster.Spec.InfrastructureRef = &corev1.ObjectReference{
+						Kind: k8sclient.KindGCPManagedCluster,
+						Name: "1-gke",
+					}
+				})
+
+				It("validates the name of the infrastructure cluster", func() {
+					expectInvalid(validator.ValidateCreate(ctx, cluster), "spec.infrastructureRef.name")
+				})
+
+				When("the kind is not served", func() {
+					BeforeEach(func() {
+						cluster.Spec.InfrastructureRef.Kind = "AWSCluster"
+					})
+
+					It("accepts the cluster", func() {
+						Expect(validator.ValidateCreate(ctx, cluster)).To(Succeed())
+					})
+				})
+			})
+		})
+
+		When("the cluster is a GCPManagedCluster", func() {
+			var managedCluster *unstructured.Unstructured
+
+			BeforeEach(func() {
+				managedCluster = k8sclient.NewGCPManagedClusterObject()
+				managedCluster.SetName("test-cluster")
+				managedCluster.SetNamespace("test")
+				Expect(unstructured.SetNestedField(managedCluster.Object, "test-project", "spec", "project")).To(Succeed())
+
+				zone = &clouddns.ManagedZone{
+					Name:       "test-cluster",
+					DnsName:    "other.example.com.",
+					Visibility: "public",
+				}
+			})
+
+			It("validates the name and the zone in the project of the cluster", func() {
+				expectInvalid(validator.ValidateCreate(ctx, managedCluster), `zone "test-cluster" exists in project test-project`)
+
+				managedCluster.SetName("Test-Cluster")
+				expectInvalid(validator.ValidateCreate(ctx, managedCluster), "a lowercase RFC 1123 label")
+			})
+		})
+	})
+
+	Describe("ValidateUpdate", func() {
+		It("rejects invalid annotations", func() {
+			newCluster := gcpCluster.DeepCopy()
+			newCluster.Annotations[annotation.DeletionPolicy] = "Keep"
+			expectInvalid(validator.ValidateUpdate(ctx, gcpCluster, newCluster), annotation.DeletionPolicy)
+		})
+
+		When("the cluster already has invalid annotations", func() {
+			BeforeEach(func() {
+				gcpCluster.Annotations[annotation.DeletionPolicy] = "Keep"
+				gcpCluster.Finalizers = []string{"dns-operator-gcp.giantswarm.io/finalizer"}
+			})
+
+			It("accepts updates not changing them", func() {
+				newCluster := gcpCluster.DeepCopy()
+				newCluster.Labels = map[string]string{"test": "label"}
+				newCluster.Annotations[annotation.CloudLogging] = "true"
+				Expect(validator.ValidateUpdate(ctx, gcpCluster, newCluster)).To(Succeed())
+			})
+
+			It("only reports the changed annotations", func() {
+				newCluster := gcpCluster.DeepCopy()
+				newCluster.Annotations[annotation.CloudLogging] = "yes"
+				err := validator.ValidateUpdate(ctx, gcpCluster, newCluster)
+				expectInvalid(err, annotation.CloudLogging)
+				Expect(err.Error()).NotTo(ContainSubstring(annotation.DeletionPolicy))
+			})
+
+			It("accepts removing finalizers", func() {
+				newCluster := gcpCluster.DeepCopy()
+				newCluster.Finalizers = nil
+				newCluster.Annotations[annotation.CloudLogging] = "yes"
+				Expect(validator.ValidateUpdate(ctx, gcpCluster, newCluster)).To(Succeed())
+			})
+
+			It("accepts deleting the cluster", func() {
+				newCluster := gcpCluster.DeepCopy()
+				now := metav1.Now()
+				newCluster.DeletionTimestamp = &now
+				newCluster.Annotations[annotation.CloudLogging] = "yes"
+				Expect(validator.ValidateUpdate(ctx, gcpCluster, newCluster)).To(Succeed())
+			})
+		})
+
+		It("rejects a routing policy added to an existing backup endpoint", func() {
+			gcpCluster.Annotations[annotation.APIBackupEndpoint] = "10.0.0.2:6443"
+			newCluster := gcpCluster.DeepCopy()
+			newCluster.Annotations[annotation.APIRoutingPolicy] = `{"type":"weighted","endpoints":[{"address":"10.0.0.1","weight":1}]}`
+			expectInvalid(validator.ValidateUpdate(ctx, gcpCluster, newCluster), "can't be combined")
+		})
+
+		It("does not check the zone", func() {
+			Expect(validator.ValidateUpdate(ctx, gcpCluster, gcpCluster.DeepCopy())).To(Succeed())
+			Expect(zoneCalls).To(BeZero())
+		})
+	})
+})
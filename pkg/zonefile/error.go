@@ -0,0 +1,27 @@
+package zonefile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictsError is returned when a plan with conflicts is applied with the
+// ConflictFail policy. No records are changed.
+type ConflictsError struct {
+	conflicts []string
+}
+
+func NewConflictsError(conflicts []string) *ConflictsError {
+	return &ConflictsError{
+		conflicts: conflicts,
+	}
+}
+
+func (e *ConflictsError) Error() string {
+	return fmt.Sprintf("zone file conflicts with existing records: %s", strings.Join(e.conflicts, ", "))
+}
+
+// Conflicts returns the conflicting records with the reason of the conflict.
+func (e *ConflictsError) Conflicts() []string {
+	return e.conflicts
+}
@@ -0,0 +1,138 @@
+// Package zonefile converts between Cloud DNS zones and RFC 1035 master
+// files, so that cluster zones can be backed up, handed over and migrated.
+package zonefile
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+// Export writes all record sets of the zone as master file, including the
+// records created by the registrars and records created by others. Only
+// zones created or adopted by the operator are exported.
+func Export(ctx context.Context, dnsService *clouddns.Service, project, zoneName string, writer io.Writer) error {
+	zone, err := getOperatorZone(ctx, dnsService, project, zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	records, err := listRecords(ctx, dnsService, project, zoneName)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return Write(writer, zone, records)
+}
+
+// Write writes the record sets of the zone as master file. The SOA and NS
+// records at the apex come first, followed by the other record sets ordered
+// by name and type. Record sets with a routing policy can't be expressed in
+// a master file and are written as comments, like the TXT record listing the
+// imported records, which is maintained by the import.
+func Write(writer io.Writer, zone *clouddns.ManagedZone, records []*clouddns.ResourceRecordSet) error {
+	records = append([]*clouddns.ResourceRecordSet{}, records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return recordOrder(zone, records[i]) < recordOrder(zone, records[j])
+	})
+
+	buffered := bufio.NewWriter(writer)
+	fmt.Fprintf(buffered, "; Cloud DNS zone %s\n", zone.Name)
+	fmt.Fprintf(buffered, "$ORIGIN %s\n", zone.DnsName)
+
+	importedRecordsName := registrar.ImportedRecordsName(strings.ToLower(zone.DnsName))
+	for _, record := range records {
+		if record.RoutingPolicy != nil {
+			fmt.Fprintf(buffered, "; %s %d IN %s has a routing policy, which can't be exported\n", record.Name, record.Ttl, record.Type)
+			continue
+		}
+		if strings.EqualFold(record.Name, importedRecordsName) && record.Type == registrar.RecordTXT {
+			fmt.Fprintf(buffered, "; %s %d IN %s lists the imported records and is maintained by the import\n", record.Name, record.Ttl, record.Type)
+			continue
+		}
+
+		rrs, err := toRRs(record)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		for _, rr := range rrs {
+			fmt.Fprintln(buffered, rr.String())
+		}
+	}
+
+	return microerror.Mask(buffered.Flush())
+}
+
+// toRRs parses the data of the record set as resource records.
+func toRRs(record *clouddns.ResourceRecordSet) ([]dns.RR, error) {
+	var rrs []dns.RR
+	for _, rrdata := range record.Rrdatas {
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", record.Name, record.Ttl, record.Type, rrdata))
+		if err != nil {
+			return nil, microerror.Mask(fmt.Errorf("invalid data %q of record %s %s: %w", rrdata, record.Name, record.Type, err))
+		}
+		rrs = append(rrs, rr)
+	}
+
+	return rrs, nil
+}
+
+// recordOrder returns the key ordering the record sets of the master file.
+func recordOrder(zone *clouddns.ManagedZone, record *clouddns.ResourceRecordSet) string {
+	apex := strings.EqualFold(record.Name, zone.DnsName)
+	switch {
+	case apex && record.Type == "SOA":
+		return "0"
+	case apex && record.Type == "NS":
+		return "1"
+	case apex:
+		return "2 " + record.Type
+	}
+
+	// Names are ordered by their labels from the apex, so that records of
+	// the same subdomain are written together.
+	labels := dns.SplitDomainName(strings.ToLower(record.Name))
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return "3 " + strings.Join(labels, " ") + " " + record.Type
+}
+
+// getOperatorZone returns the zone, when it was created or adopted by the
+// operator.
+func getOperatorZone(ctx context.Context, dnsService *clouddns.Service, project, zoneName string) (*clouddns.ManagedZone, error) {
+	zone, err := dnsService.ManagedZones.Get(project, zoneName).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if !registrar.IsOperatorZone(zone) && zone.Labels[registrar.ZoneLabelAdopted] != "true" {
+		return nil, microerror.Mask(fmt.Errorf("zone %q was neither created nor adopted by the operator", zoneName))
+	}
+
+	return zone, nil
+}
+
+func listRecords(ctx context.Context, dnsService *clouddns.Service, project, zoneName string) ([]*clouddns.ResourceRecordSet, error) {
+	var records []*clouddns.ResourceRecordSet
+	err := dnsService.ResourceRecordSets.List(project, zoneName).
+		Pages(ctx, func(page *clouddns.ResourceRecordSetsListResponse) error {
+			records = append(records, page.Rrsets...)
+			return nil
+		})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,167 @@
+package zonefile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/zonefile"
+)
+
+var _ = Describe("Export", func() {
+	var (
+		ctx context.Context
+
+		server  *httptest.Server
+		service *clouddns.Service
+
+		zone    *clouddns.ManagedZone
+		records []*clouddns.ResourceRecordSet
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		zone = &clouddns.ManagedZone{
+			Name:    "test-cluster",
+			DnsName: "test-cluster.example.com.",
+			Labels:  map[string]string{registrar.ZoneLabelManagedBy: registrar.ZoneManagedBy},
+		}
+		records = []*clouddns.ResourceRecordSet{
+			{Name: "bastion1.test-cluster.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.2"}},
+			{Name: "*.test-cluster.example.com.", Type: "CNAME", Ttl: 300, Rrdatas: []string{"ingress.test-cluster.example.com."}},
+			{Name: "api.test-cluster.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}},
+			{Name: "test-cluster.example.com.", Type: "NS", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com.", "ns-cloud-a2.googledomains.com."}},
+			{Name: "test-cluster.example.com.", Type: "SOA", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com. cloud-dns-hostmaster.google.com. 1 21600 3600 259200 300"}},
+			{Name: "foreign.test-cluster.example.com.", Type: "TXT", Ttl: 60, Rrdatas: []string{`"created by hand"`}},
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/managedZones/test-cluster"):
+				Expect(json.NewEncoder(w).Encode(zone)).To(Succeed())
+			case strings.HasSuffix(r.URL.Path, "/managedZones/test-cluster/rrsets"):
+				Expect(json.NewEncoder(w).Encode(&clouddns.ResourceRecordSetsListResponse{Rrsets: records})).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+			}
+		}))
+
+		var err error
+		service, err = clouddns.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("writes all records as master file", func() {
+		buffer := &bytes.Buffer{}
+		Expect(zonefile.Export(ctx, service, "test-project", "test-cluster", buffer)).To(Succeed())
+
+		Expect(strings.Split(strings.TrimSpace(buffer.String()), "\n")).To(Equal([]string{
+			"; Cloud DNS zone test-cluster",
+			"$ORIGIN test-cluster.example.com.",
+			"test-cluster.example.com.\t21600\tIN\tSOA\tns-cloud-a1.googledomains.com. cloud-dns-hostmaster.google.com. 1 21600 3600 259200 300",
+			"test-cluster.example.com.\t21600\tIN\tNS\tns-cloud-a1.googledomains.com.",
+			"test-cluster.example.com.\t21600\tIN\tNS\tns-cloud-a2.googledomains.com.",
+			"*.test-cluster.example.com.\t300\tIN\tCNAME\tingress.test-cluster.example.com.",
+			"api.test-cluster.example.com.\t300\tIN\tA\t10.0.0.1",
+			"bastion1.test-cluster.example.com.\t300\tIN\tA\t10.0.0.2",
+			"foreign.test-cluster.example.com.\t60\tIN\tTXT\t\"created by hand\"",
+		}))
+	})
+
+	When("a record has a routing policy", func() {
+		BeforeEach(func() {
+			records[2] = &clouddns.ResourceRecordSet{
+				Name: "api.test-cluster.example.com.",
+				Type: "A",
+				Ttl:  300,
+				RoutingPolicy: &clouddns.RRSetRoutingPolicy{
+					Wrr: &clouddns.RRSetRoutingPolicyWrrPolicy{
+						Items: []*clouddns.RRSetRoutingPolicyWrrPolicyWrrPolicyItem{
+							{Weight: 1, Rrdatas: []string{"10.0.0.1"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("writes the record as comment", func() {
+			buffer := &bytes.Buffer{}
+			Expect(zonefile.Export(ctx, service, "test-project", "test-cluster", buffer)).To(Succeed())
+			Expect(buffer.String()).To(ContainSubstring("; api.test-cluster.example.com. 300 IN A has a routing policy, which can't be exported\n"))
+			Expect(buffer.String()).NotTo(ContainSubstring("10.0.0.1"))
+		})
+	})
+
+	When("records were imported", func() {
+		BeforeEach(func() {
+			records = append(records, &clouddns.ResourceRecordSet{
+				Name:    registrar.ImportedRecordsName("test-cluster.example.com."),
+				Type:    "TXT",
+				Ttl:     300,
+				Rrdatas: []string{`"foreign TXT"`},
+			})
+		})
+
+		It("writes the record listing them as comment", func() {
+			buffer := &bytes.Buffer{}
+			Expect(zonefile.Export(ctx, service, "test-project", "test-cluster", buffer)).To(Succeed())
+			Expect(buffer.String()).To(ContainSubstring("; _dns-operator-gcp-imported.test-cluster.example.com. 300 IN TXT lists the imported records and is maintained by the import\n"))
+			Expect(buffer.String()).NotTo(ContainSubstring(`"foreign TXT"`))
+		})
+	})
+
+	When("the zone was not created by the operator", func() {
+		BeforeEach(func() {
+			zone.Labels = nil
+		})
+
+		It("returns an error", func() {
+			err := zonefile.Export(ctx, service, "test-project", "test-cluster", &bytes.Buffer{})
+			Expect(err).To(MatchError(ContainSubstring("neither created nor adopted by the operator")))
+		})
+
+		When("the zone was adopted", func() {
+			BeforeEach(func() {
+				zone.Labels = map[string]string{registrar.ZoneLabelAdopted: "true"}
+			})
+
+			It("exports the zone", func() {
+				Expect(zonefile.Export(ctx, service, "test-project", "test-cluster", &bytes.Buffer{})).To(Succeed())
+			})
+		})
+	})
+
+	When("the zone does not exist", func() {
+		It("returns an error", func() {
+			err := zonefile.Export(ctx, service, "test-project", "other-cluster", &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("a record has invalid data", func() {
+		BeforeEach(func() {
+			records = append(records, &clouddns.ResourceRecordSet{Name: "broken.test-cluster.example.com.", Type: "A", Ttl: 300, Rrdatas: []string{"not-an-ip"}})
+		})
+
+		It("returns an error", func() {
+			err := zonefile.Export(ctx, service, "test-project", "test-cluster", &bytes.Buffer{})
+			Expect(err).To(MatchError(ContainSubstring(`invalid data "not-an-ip"`)))
+		})
+	})
+})
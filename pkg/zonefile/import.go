@@ -0,0 +1,354 @@
+package zonefile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/miekg/dns"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+)
+
+// ConflictPolicy decides how records of the zone file are handled, which
+// conflict with existing records of the zone.
+type ConflictPolicy string
+
+const (
+	// ConflictFail refuses to apply a plan with conflicts.
+	ConflictFail ConflictPolicy = "fail"
+	// ConflictSkip keeps the existing records and imports the others.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces existing records, which differ from the
+	// zone file and were not imported before. Records of the operator are
+	// never overwritten and are skipped.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+// ConflictPolicies are the valid conflict policies.
+var ConflictPolicies = []ConflictPolicy{ConflictFail, ConflictSkip, ConflictOverwrite}
+
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionConflict  Action = "conflict"
+	ActionSkip      Action = "skip"
+)
+
+const changePollInterval = time.Second
+
+// Change is the planned change of a record set of the zone file.
+type Change struct {
+	Action Action
+	Record *clouddns.ResourceRecordSet
+	// Existing is the record set in the zone, which is replaced by updates.
+	Existing *clouddns.ResourceRecordSet
+	// Reason explains conflicts and skipped record sets.
+	Reason string
+}
+
+// Plan lists the changes importing a zone file into a zone. Imported record
+// sets are listed in the TXT record of registrar.ImportedRecords, so that
+// the operator owns them like its own records.
+type Plan struct {
+	Project string
+	Zone    string
+	Domain  string
+	Policy  ConflictPolicy
+	Changes []Change
+}
+
+// Parse reads the record sets of a zone file. Relative names are resolved
+// against the origin, unless the file sets its own $ORIGIN. Resource records
+// of the same name and type are merged into a record set with the lowest TTL
+// of its records.
+func Parse(reader io.Reader, origin string) ([]*clouddns.ResourceRecordSet, error) {
+	parser := dns.NewZoneParser(reader, dns.Fqdn(origin), "")
+	parser.SetIncludeAllowed(false)
+
+	var records []*clouddns.ResourceRecordSet
+	recordsByKey := map[string]*clouddns.ResourceRecordSet{}
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		header := rr.Header()
+		if header.Class != dns.ClassINET {
+			return nil, microerror.Mask(fmt.Errorf("record %s has unsupported class %s", header.Name, dns.ClassToString[header.Class]))
+		}
+
+		record := &clouddns.ResourceRecordSet{
+			Name: strings.ToLower(header.Name),
+			Type: dns.TypeToString[header.Rrtype],
+			Ttl:  int64(header.Ttl),
+		}
+		key := record.Name + " " + record.Type
+		if existing, ok := recordsByKey[key]; ok {
+			record = existing
+		} else {
+			recordsByKey[key] = record
+			records = append(records, record)
+		}
+
+		if int64(header.Ttl) < record.Ttl {
+			record.Ttl = int64(header.Ttl)
+		}
+		if data := rdata(rr); !contains(record.Rrdatas, data) {
+			record.Rrdatas = append(record.Rrdatas, data)
+		}
+	}
+	if err := parser.Err(); err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return records, nil
+}
+
+// NewPlan compares the record sets of a zone file with the records of the
+// zone. The zone must have been created or adopted by the operator.
+// Record sets conflict with the records of the operator, like api, bastionN
+// and the wildcard, regardless of their type, and with existing records which
+// differ and were not imported before. The SOA and NS records at the apex are
+// managed by Cloud DNS and skipped.
+func NewPlan(ctx context.Context, dnsService *clouddns.Service, project, zoneName string, records []*clouddns.ResourceRecordSet, policy ConflictPolicy) (*Plan, error) {
+	zone, err := getOperatorZone(ctx, dnsService, project, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	existingRecords, err := listRecords(ctx, dnsService, project, zoneName)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	domain := strings.ToLower(zone.DnsName)
+	imported := registrar.ParseImportedRecords(domain, existingRecords)
+	existingByKey := map[string]*clouddns.ResourceRecordSet{}
+	existingTypes := map[string][]string{}
+	for _, record := range existingRecords {
+		name := strings.ToLower(record.Name)
+		existingByKey[name+" "+record.Type] = record
+		existingTypes[name] = append(existingTypes[name], record.Type)
+	}
+
+	plan := &Plan{
+		Project: project,
+		Zone:    zoneName,
+		Domain:  domain,
+		Policy:  policy,
+	}
+	for _, record := range records {
+		if !dns.IsSubDomain(domain, record.Name) {
+			return nil, microerror.Mask(fmt.Errorf("record %s is not in zone %q for %s", record.Name, zoneName, domain))
+		}
+
+		existing := existingByKey[record.Name+" "+record.Type]
+		change := Change{Record: record, Existing: existing}
+		switch {
+		case record.Name == domain && (record.Type == registrar.RecordSOA || record.Type == registrar.RecordNS):
+			change.Action = ActionSkip
+			change.Reason = "managed by Cloud DNS"
+		case record.Name == registrar.ImportedRecordsName(domain) && record.Type == registrar.RecordTXT:
+			change.Action = ActionSkip
+			change.Reason = "maintained by the import"
+		case registrar.IsOperatorName(domain, record.Name) && existing != nil && equalRecordSet(existing, record):
+			// Exports contain the records of the operator, so that they
+			// can be imported again as long as the records didn't change.
+			change.Action = ActionSkip
+			change.Reason = "managed by the operator"
+		case registrar.IsOperatorName(domain, record.Name):
+			change.Action = ActionConflict
+			change.Reason = "name is managed by the operator"
+		case hasCNAMEConflict(record, existingTypes[record.Name]):
+			change.Action = ActionConflict
+			change.Reason = "CNAME records can't coexist with other records of the name"
+		case existing == nil:
+			change.Action = ActionCreate
+		case equalRecordSet(existing, record):
+			change.Action = ActionUnchanged
+		case imported.Contains(existing) || policy == ConflictOverwrite:
+			change.Action = ActionUpdate
+		default:
+			change.Action = ActionConflict
+			change.Reason = "record exists with different data"
+		}
+
+		if change.Action == ActionConflict && policy != ConflictFail {
+			change.Action = ActionSkip
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	// The TXT record listing the imported records is extended by the record
+	// sets of this import.
+	marked := registrar.ImportedRecords{}
+	for key := range imported {
+		marked[key] = true
+	}
+	for _, change := range plan.Changes {
+		if change.Action == ActionCreate || change.Action == ActionUpdate || change.Action == ActionUnchanged {
+			marked.Add(change.Record)
+		}
+	}
+	if len(marked) > len(imported) {
+		marker := marked.Record(domain)
+		existing := existingByKey[marker.Name+" "+marker.Type]
+		change := Change{Action: ActionCreate, Record: marker, Existing: existing}
+		if existing != nil {
+			change.Action = ActionUpdate
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	return plan, nil
+}
+
+// Conflicts returns the conflicting record sets with the reason of the
+// conflict.
+func (p *Plan) Conflicts() []string {
+	var conflicts []string
+	for _, change := range p.Changes {
+		if change.Action == ActionConflict {
+			conflicts = append(conflicts, fmt.Sprintf("%s %s: %s", change.Record.Name, change.Record.Type, change.Reason))
+		}
+	}
+
+	return conflicts
+}
+
+// Write writes the plan as diff of the record sets, followed by a summary.
+// Unchanged record sets are only counted.
+func (p *Plan) Write(writer io.Writer) error {
+	counts := map[Action]int{}
+	for _, change := range p.Changes {
+		counts[change.Action]++
+
+		switch change.Action {
+		case ActionCreate, ActionUpdate:
+			fmt.Fprintf(writer, "%s %s %s\n", change.Action, change.Record.Name, change.Record.Type)
+			if change.Existing != nil {
+				writeRecordSet(writer, "-", change.Existing)
+			}
+			writeRecordSet(writer, "+", change.Record)
+		case ActionConflict, ActionSkip:
+			fmt.Fprintf(writer, "%s %s %s: %s\n", change.Action, change.Record.Name, change.Record.Type, change.Reason)
+		}
+	}
+
+	_, err := fmt.Fprintf(writer, "Plan for zone %q: %d to create, %d to update, %d unchanged, %d skipped, %d conflicts.\n",
+		p.Zone, counts[ActionCreate], counts[ActionUpdate], counts[ActionUnchanged], counts[ActionSkip], counts[ActionConflict])
+	return microerror.Mask(err)
+}
+
+// Apply applies the plan as a single Cloud DNS change, so that either all or
+// no record sets are imported, and waits until the change is done. A plan
+// with conflicts is refused with a ConflictsError.
+func Apply(ctx context.Context, dnsService *clouddns.Service, plan *Plan) error {
+	if conflicts := plan.Conflicts(); len(conflicts) > 0 {
+		return microerror.Mask(NewConflictsError(conflicts))
+	}
+
+	change := &clouddns.Change{}
+	for _, planned := range plan.Changes {
+		switch planned.Action {
+		case ActionCreate:
+			change.Additions = append(change.Additions, planned.Record)
+		case ActionUpdate:
+			change.Deletions = append(change.Deletions, planned.Existing)
+			change.Additions = append(change.Additions, planned.Record)
+		}
+	}
+	if len(change.Additions) == 0 {
+		return nil
+	}
+
+	change, err := dnsService.Changes.Create(plan.Project, plan.Zone, change).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for change.Status != "done" {
+		select {
+		case <-ctx.Done():
+			return microerror.Mask(ctx.Err())
+		case <-time.After(changePollInterval):
+		}
+
+		change, err = dnsService.Changes.Get(plan.Project, plan.Zone, change.Id).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func writeRecordSet(writer io.Writer, prefix string, record *clouddns.ResourceRecordSet) {
+	if record.RoutingPolicy != nil {
+		fmt.Fprintf(writer, "  %s %s %d IN %s with routing policy\n", prefix, record.Name, record.Ttl, record.Type)
+		return
+	}
+
+	for _, rrdata := range record.Rrdatas {
+		fmt.Fprintf(writer, "  %s %s %d IN %s %s\n", prefix, record.Name, record.Ttl, record.Type, rrdata)
+	}
+}
+
+// equalRecordSet returns whether the existing record set has the TTL and
+// data of the record set of the zone file. The data of the existing record
+// set is normalized like the zone file.
+func equalRecordSet(existing, record *clouddns.ResourceRecordSet) bool {
+	if existing.RoutingPolicy != nil || existing.Ttl != record.Ttl || len(existing.Rrdatas) != len(record.Rrdatas) {
+		return false
+	}
+
+	rrs, err := toRRs(existing)
+	if err != nil {
+		return false
+	}
+	for _, rr := range rrs {
+		if !contains(record.Rrdatas, rdata(rr)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasCNAMEConflict returns whether the record set can't be added to the
+// existing record types of its name, because either is a CNAME.
+func hasCNAMEConflict(record *clouddns.ResourceRecordSet, existingTypes []string) bool {
+	for _, existingType := range existingTypes {
+		if existingType == record.Type {
+			continue
+		}
+		if existingType == registrar.RecordCNAME || record.Type == registrar.RecordCNAME {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rdata returns the data of the resource record in presentation format.
+func rdata(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
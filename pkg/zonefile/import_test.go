@@ -0,0 +1,322 @@
+package zonefile_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/zonefile"
+)
+
+var _ = Describe("Parse", func() {
+	It("merges the resource records into record sets", func() {
+		records, err := zonefile.Parse(strings.NewReader(`
+$TTL 300
+legacy    IN A     10.0.0.1
+legacy    60 IN A  10.0.0.2
+Legacy    IN A     10.0.0.2
+mail      IN MX    10 mx.example.org.
+docs      IN CNAME legacy
+@         IN TXT   "v=spf1 -all"
+`), "test-cluster.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(records).To(Equal([]*clouddns.ResourceRecordSet{
+			{Name: "legacy.test-cluster.example.com.", Type: "A", Ttl: 60, Rrdatas: []string{"10.0.0.1", "10.0.0.2"}},
+			{Name: "mail.test-cluster.example.com.", Type: "MX", Ttl: 300, Rrdatas: []string{"10 mx.example.org."}},
+			{Name: "docs.test-cluster.example.com.", Type: "CNAME", Ttl: 300, Rrdatas: []string{"legacy.test-cluster.example.com."}},
+			{Name: "test-cluster.example.com.", Type: "TXT", Ttl: 300, Rrdatas: []string{`"v=spf1 -all"`}},
+		}))
+	})
+
+	It("returns an error for invalid zone files", func() {
+		_, err := zonefile.Parse(strings.NewReader("legacy 300 IN A not-an-ip\n"), "test-cluster.example.com.")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("refuses includes", func() {
+		_, err := zonefile.Parse(strings.NewReader("$INCLUDE /etc/passwd\n"), "test-cluster.example.com.")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Import", func() {
+	const domain = "test-cluster.example.com."
+
+	var (
+		ctx context.Context
+
+		server  *httptest.Server
+		service *clouddns.Service
+
+		zone     *clouddns.ManagedZone
+		existing []*clouddns.ResourceRecordSet
+		changes  []*clouddns.Change
+
+		records []*clouddns.ResourceRecordSet
+		policy  zonefile.ConflictPolicy
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		changes = nil
+		policy = zonefile.ConflictFail
+
+		zone = &clouddns.ManagedZone{
+			Name:    "test-cluster",
+			DnsName: domain,
+			Labels:  map[string]string{registrar.ZoneLabelManagedBy: registrar.ZoneManagedBy},
+		}
+		existing = []*clouddns.ResourceRecordSet{
+			{Name: domain, Type: "SOA", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com. cloud-dns-hostmaster.google.com. 1 21600 3600 259200 300"}},
+			{Name: domain, Type: "NS", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com."}},
+			{Name: "api." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}},
+			{Name: "foreign." + domain, Type: "TXT", Ttl: 300, Rrdatas: []string{`"created by hand"`}},
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/managedZones/test-cluster"):
+				Expect(json.NewEncoder(w).Encode(zone)).To(Succeed())
+			case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/managedZones/test-cluster/rrsets"):
+				Expect(json.NewEncoder(w).Encode(&clouddns.ResourceRecordSetsListResponse{Rrsets: existing})).To(Succeed())
+			case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/managedZones/test-cluster/changes"):
+				change := &clouddns.Change{}
+				Expect(json.NewDecoder(r.Body).Decode(change)).To(Succeed())
+				changes = append(changes, change)
+				change.Id = "1"
+				change.Status = "done"
+				Expect(json.NewEncoder(w).Encode(change)).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+			}
+		}))
+
+		var err error
+		service, err = clouddns.NewService(ctx, option.WithEndpoint(server.URL), option.WithoutAuthentication())
+		Expect(err).NotTo(HaveOccurred())
+
+		records = []*clouddns.ResourceRecordSet{
+			{Name: domain, Type: "SOA", Ttl: 3600, Rrdatas: []string{"ns1.legacy.example.org. hostmaster.example.org. 1 3600 600 86400 300"}},
+			{Name: "legacy." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.1"}},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	importRecords := func() (*zonefile.Plan, error) {
+		plan, err := zonefile.NewPlan(ctx, service, "test-project", "test-cluster", records, policy)
+		Expect(err).NotTo(HaveOccurred())
+		return plan, zonefile.Apply(ctx, service, plan)
+	}
+
+	It("creates the records and marks them as imported", func() {
+		_, err := importRecords()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Deletions).To(BeEmpty())
+		Expect(changes[0].Additions).To(Equal([]*clouddns.ResourceRecordSet{
+			{Name: "legacy." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.1"}},
+			{Name: registrar.ImportedRecordsName(domain), Type: "TXT", Ttl: 300, Rrdatas: []string{`"legacy A"`}},
+		}))
+
+		imported := registrar.ParseImportedRecords(domain, changes[0].Additions)
+		Expect(imported.Contains(records[1])).To(BeTrue())
+	})
+
+	It("writes the plan", func() {
+		plan, err := zonefile.NewPlan(ctx, service, "test-project", "test-cluster", records, policy)
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer := &bytes.Buffer{}
+		Expect(plan.Write(buffer)).To(Succeed())
+		Expect(buffer.String()).To(Equal(`skip test-cluster.example.com. SOA: managed by Cloud DNS
+create legacy.test-cluster.example.com. A
+  + legacy.test-cluster.example.com. 300 IN A 10.0.1.1
+create _dns-operator-gcp-imported.test-cluster.example.com. TXT
+  + _dns-operator-gcp-imported.test-cluster.example.com. 300 IN TXT "legacy A"
+Plan for zone "test-cluster": 2 to create, 0 to update, 0 unchanged, 1 skipped, 0 conflicts.
+`))
+	})
+
+	When("records were imported before", func() {
+		BeforeEach(func() {
+			existing = append(existing,
+				&clouddns.ResourceRecordSet{Name: "legacy." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.9"}},
+				&clouddns.ResourceRecordSet{Name: "old." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.8"}},
+				&clouddns.ResourceRecordSet{Name: registrar.ImportedRecordsName(domain), Type: "TXT", Ttl: 300, Rrdatas: []string{`"legacy A"`, `"old A"`}},
+			)
+		})
+
+		It("updates the imported records", func() {
+			_, err := importRecords()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Deletions).To(HaveLen(1))
+			Expect(changes[0].Deletions[0].Rrdatas).To(Equal([]string{"10.0.0.9"}))
+			Expect(changes[0].Additions).To(Equal([]*clouddns.ResourceRecordSet{
+				{Name: "legacy." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.1"}},
+			}))
+		})
+	})
+
+	When("the records are already in the zone", func() {
+		BeforeEach(func() {
+			records = []*clouddns.ResourceRecordSet{
+				{Name: "foreign." + domain, Type: "TXT", Ttl: 300, Rrdatas: []string{`"created by hand"`}},
+			}
+		})
+
+		It("marks them as imported", func() {
+			_, err := importRecords()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Additions).To(Equal([]*clouddns.ResourceRecordSet{
+				{Name: registrar.ImportedRecordsName(domain), Type: "TXT", Ttl: 300, Rrdatas: []string{`"foreign TXT"`}},
+			}))
+		})
+	})
+
+	When("an export of the zone is imported", func() {
+		BeforeEach(func() {
+			records = append(records,
+				&clouddns.ResourceRecordSet{Name: domain, Type: "NS", Ttl: 21600, Rrdatas: []string{"ns-cloud-a1.googledomains.com."}},
+				&clouddns.ResourceRecordSet{Name: "api." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.0.1"}},
+				&clouddns.ResourceRecordSet{Name: "foreign." + domain, Type: "TXT", Ttl: 300, Rrdatas: []string{`"created by hand"`}},
+				&clouddns.ResourceRecordSet{Name: registrar.ImportedRecordsName(domain), Type: "TXT", Ttl: 300, Rrdatas: []string{`"other A"`}},
+			)
+		})
+
+		It("skips the unchanged records of the operator", func() {
+			plan, err := importRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Conflicts()).To(BeEmpty())
+
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Additions).To(HaveLen(2))
+			Expect(changes[0].Additions[0].Name).To(Equal("legacy." + domain))
+			Expect(changes[0].Additions[1].Rrdatas).To(Equal([]string{`"foreign TXT"`, `"legacy A"`}))
+		})
+	})
+
+	When("records are below the names of the operator", func() {
+		BeforeEach(func() {
+			records = append(records,
+				&clouddns.ResourceRecordSet{Name: "bastion.foo." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.2"}},
+				&clouddns.ResourceRecordSet{Name: "bastion1.foo." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.3"}},
+			)
+		})
+
+		It("imports them", func() {
+			plan, err := importRecords()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan.Conflicts()).To(BeEmpty())
+
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Additions).To(HaveLen(4))
+			Expect(changes[0].Additions[1].Name).To(Equal("bastion.foo." + domain))
+			Expect(changes[0].Additions[2].Name).To(Equal("bastion1.foo." + domain))
+		})
+	})
+
+	When("records conflict", func() {
+		BeforeEach(func() {
+			records = append(records,
+				&clouddns.ResourceRecordSet{Name: "api." + domain, Type: "CNAME", Ttl: 300, Rrdatas: []string{"legacy.example.org."}},
+				&clouddns.ResourceRecordSet{Name: "bastion1." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.2"}},
+				&clouddns.ResourceRecordSet{Name: "*." + domain, Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.3"}},
+				&clouddns.ResourceRecordSet{Name: "foreign." + domain, Type: "TXT", Ttl: 300, Rrdatas: []string{`"imported"`}},
+				&clouddns.ResourceRecordSet{Name: "foreign." + domain, Type: "CNAME", Ttl: 300, Rrdatas: []string{"legacy.example.org."}},
+			)
+		})
+
+		It("refuses to import any record", func() {
+			plan, err := importRecords()
+
+			var conflictsErr *zonefile.ConflictsError
+			Expect(errors.As(err, &conflictsErr)).To(BeTrue())
+			Expect(conflictsErr.Conflicts()).To(Equal(plan.Conflicts()))
+			Expect(conflictsErr.Conflicts()).To(ConsistOf(
+				"api.test-cluster.example.com. CNAME: name is managed by the operator",
+				"bastion1.test-cluster.example.com. A: name is managed by the operator",
+				"*.test-cluster.example.com. A: name is managed by the operator",
+				"foreign.test-cluster.example.com. TXT: record exists with different data",
+				"foreign.test-cluster.example.com. CNAME: CNAME records can't coexist with other records of the name",
+			))
+			Expect(changes).To(BeEmpty())
+		})
+
+		When("conflicts are skipped", func() {
+			BeforeEach(func() {
+				policy = zonefile.ConflictSkip
+			})
+
+			It("imports the other records", func() {
+				_, err := importRecords()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes).To(HaveLen(1))
+				Expect(changes[0].Deletions).To(BeEmpty())
+				Expect(changes[0].Additions).To(HaveLen(2))
+				Expect(changes[0].Additions[0].Name).To(Equal("legacy." + domain))
+				Expect(changes[0].Additions[1].Rrdatas).To(Equal([]string{`"legacy A"`}))
+			})
+		})
+
+		When("conflicts are overwritten", func() {
+			BeforeEach(func() {
+				policy = zonefile.ConflictOverwrite
+			})
+
+			It("overwrites the foreign records, but not the records of the operator", func() {
+				_, err := importRecords()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes).To(HaveLen(1))
+				Expect(changes[0].Deletions).To(HaveLen(1))
+				Expect(changes[0].Deletions[0].Rrdatas).To(Equal([]string{`"created by hand"`}))
+				Expect(changes[0].Additions).To(HaveLen(3))
+				Expect(changes[0].Additions[2].Rrdatas).To(Equal([]string{`"foreign TXT"`, `"legacy A"`}))
+			})
+		})
+	})
+
+	When("the zone was not created by the operator", func() {
+		BeforeEach(func() {
+			zone.Labels = nil
+		})
+
+		It("returns an error", func() {
+			_, err := zonefile.NewPlan(ctx, service, "test-project", "test-cluster", records, policy)
+			Expect(err).To(MatchError(ContainSubstring("neither created nor adopted by the operator")))
+		})
+	})
+
+	When("a record is outside of the zone", func() {
+		BeforeEach(func() {
+			records = append(records, &clouddns.ResourceRecordSet{Name: "legacy.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"10.0.1.1"}})
+		})
+
+		It("returns an error", func() {
+			_, err := zonefile.NewPlan(ctx, service, "test-project", "test-cluster", records, policy)
+			Expect(err).To(MatchError(ContainSubstring("record legacy.example.org. is not in zone")))
+		})
+	})
+})
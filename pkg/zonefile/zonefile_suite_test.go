@@ -0,0 +1,13 @@
+package zonefile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestZonefile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zonefile Suite")
+}
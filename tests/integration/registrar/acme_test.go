@@ -0,0 +1,197 @@
+package registrar_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar/registrarfakes"
+	"github.com/giantswarm/dns-operator-gcp/tests"
+)
+
+var _ = Describe("ACME Challenge", func() {
+	var (
+		ctx context.Context
+
+		service       *clouddns.Service
+		clusters      *registrarfakes.FakeClusterLister
+		acmeChallenge *registrar.ACMEChallenge
+
+		cluster         clusterview.Cluster
+		clusterName     string
+		challengeDomain string
+		request         registrar.ACMEChallengeRequest
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		service, err = clouddns.NewService(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		clusterName = tests.GenerateGUID("test")
+		cluster = clusterview.Cluster{
+			Name:      clusterName,
+			Namespace: "org-test",
+			Project:   gcpProject,
+		}
+		domain := fmt.Sprintf("%s.%s.", clusterName, baseDomain)
+		challengeDomain = fmt.Sprintf("%s.%s", registrar.ACMEChallengeLabel, domain)
+
+		zone := &clouddns.ManagedZone{
+			Name:        clusterName,
+			DnsName:     domain,
+			Description: registrar.ZoneDescription,
+			Visibility:  "public",
+		}
+		_, err = service.ManagedZones.Create(gcpProject, zone).
+			Context(context.Background()).
+			Do()
+		Expect(err).NotTo(HaveOccurred())
+
+		clusters = new(registrarfakes.FakeClusterLister)
+		clusters.ListReturns([]clusterview.Cluster{cluster}, nil)
+		acmeChallenge = registrar.NewACMEChallenge(baseDomain, clusters, false, nil, service)
+
+		request = registrar.ACMEChallengeRequest{
+			ID:        "1234",
+			FQDN:      challengeDomain,
+			Key:       "first-key",
+			Namespace: "org-test",
+		}
+	})
+
+	AfterEach(func() {
+		_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, challengeDomain, registrar.RecordTXT).Do()
+		Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+
+		err = service.ManagedZones.Delete(gcpProject, clusterName).
+			Context(context.Background()).
+			Do()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Present", func() {
+		var presentErr error
+
+		JustBeforeEach(func() {
+			presentErr = acmeChallenge.Present(ctx, request)
+		})
+
+		It("creates the TXT record", func() {
+			Expect(presentErr).NotTo(HaveOccurred())
+
+			record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, challengeDomain, registrar.RecordTXT).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Rrdatas).To(ConsistOf(`"first-key"`))
+		})
+
+		When("another challenge of the same name is pending", func() {
+			BeforeEach(func() {
+				other := request
+				other.Key = "second-key"
+				Expect(acmeChallenge.Present(ctx, other)).To(Succeed())
+			})
+
+			It("adds the key to the record", func() {
+				Expect(presentErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, challengeDomain, registrar.RecordTXT).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf(`"first-key"`, `"second-key"`))
+			})
+		})
+
+		When("the challenge is outside of the base domain", func() {
+			BeforeEach(func() {
+				request.FQDN = "_acme-challenge.example.org."
+			})
+
+			It("returns an error", func() {
+				Expect(presentErr).To(MatchError(ContainSubstring("is not in a cluster domain")))
+			})
+		})
+
+		When("the cluster does not exist", func() {
+			BeforeEach(func() {
+				clusters.ListReturns(nil, nil)
+			})
+
+			It("returns an error", func() {
+				Expect(presentErr).To(MatchError(ContainSubstring("does not exist")))
+			})
+		})
+
+		When("the certificate is in another namespace", func() {
+			BeforeEach(func() {
+				request.Namespace = "org-other"
+			})
+
+			It("returns an error", func() {
+				Expect(presentErr).To(MatchError(ContainSubstring(`does not exist in namespace "org-other"`)))
+			})
+
+			When("cross namespace challenges are allowed", func() {
+				BeforeEach(func() {
+					acmeChallenge = registrar.NewACMEChallenge(baseDomain, clusters, true, nil, service)
+				})
+
+				It("creates the TXT record", func() {
+					Expect(presentErr).NotTo(HaveOccurred())
+				})
+			})
+		})
+
+		When("the zone was not created by the operator", func() {
+			BeforeEach(func() {
+				patch := &clouddns.ManagedZone{Description: "zone created for integration test"}
+				_, err := service.ManagedZones.Patch(gcpProject, clusterName, patch).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("refuses to present the challenge", func() {
+				var conflictErr *registrar.ConflictError
+				Expect(errors.As(presentErr, &conflictErr)).To(BeTrue())
+				Expect(conflictErr.ConditionReason()).To(Equal(registrar.ReasonZoneConflict))
+			})
+		})
+	})
+
+	Describe("CleanUp", func() {
+		BeforeEach(func() {
+			Expect(acmeChallenge.Present(ctx, request)).To(Succeed())
+		})
+
+		It("deletes the record with the last key", func() {
+			Expect(acmeChallenge.CleanUp(ctx, request)).To(Succeed())
+
+			_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, challengeDomain, registrar.RecordTXT).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+		})
+
+		It("keeps the keys of other challenges", func() {
+			other := request
+			other.Key = "second-key"
+			Expect(acmeChallenge.Present(ctx, other)).To(Succeed())
+
+			Expect(acmeChallenge.CleanUp(ctx, request)).To(Succeed())
+
+			record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, challengeDomain, registrar.RecordTXT).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Rrdatas).To(ConsistOf(`"second-key"`))
+		})
+
+		It("is idempotent", func() {
+			Expect(acmeChallenge.CleanUp(ctx, request)).To(Succeed())
+			Expect(acmeChallenge.CleanUp(ctx, request)).To(Succeed())
+		})
+	})
+})
@@ -2,16 +2,18 @@ package registrar_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	clouddns "google.golang.org/api/dns/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar/registrarfakes"
 	"github.com/giantswarm/dns-operator-gcp/tests"
 )
 
@@ -19,10 +21,11 @@ var _ = Describe("API Registrar", func() {
 	var (
 		ctx context.Context
 
-		service      *clouddns.Service
-		apiRegistrar *registrar.API
+		service       *clouddns.Service
+		healthChecker *registrarfakes.FakeHealthChecker
+		apiRegistrar  *registrar.API
 
-		cluster              *capg.GCPCluster
+		cluster              *clusterview.Cluster
 		clusterName          string
 		domain               string
 		apiDomain            string
@@ -37,13 +40,9 @@ var _ = Describe("API Registrar", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		clusterName = tests.GenerateGUID("test")
-		cluster = &capg.GCPCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: clusterName,
-			},
-			Spec: capg.GCPClusterSpec{
-				Project: gcpProject,
-			},
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
 		}
 		domain = fmt.Sprintf("%s.%s.", cluster.Name, baseDomain)
 		apiDomain = fmt.Sprintf("api.%s", domain)
@@ -59,7 +58,8 @@ var _ = Describe("API Registrar", func() {
 			Do()
 		Expect(err).NotTo(HaveOccurred())
 
-		apiRegistrar = registrar.NewAPI(baseDomain, service)
+		healthChecker = new(registrarfakes.FakeHealthChecker)
+		apiRegistrar = registrar.NewAPI(baseDomain, healthChecker, service)
 	})
 
 	AfterEach(func() {
@@ -76,7 +76,7 @@ var _ = Describe("API Registrar", func() {
 			// Any private range IP address will do for the test.
 			// Load Balancers in GCP have a external IP address.
 			controlPlaneEndpoint = "10.0.0.1"
-			cluster.Spec.ControlPlaneEndpoint.Host = controlPlaneEndpoint
+			cluster.ControlPlaneEndpoint.Host = controlPlaneEndpoint
 		})
 
 		JustBeforeEach(func() {
@@ -85,7 +85,7 @@ var _ = Describe("API Registrar", func() {
 
 		AfterEach(func() {
 			_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
-			Expect(err).To(Or(Not(HaveOccurred()), BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
 		})
 
 		It("creates the A record", func() {
@@ -98,14 +98,181 @@ var _ = Describe("API Registrar", func() {
 
 		When("the cluster does not have a control plane endpoint yet", func() {
 			BeforeEach(func() {
-				cluster.Spec.ControlPlaneEndpoint.Host = ""
+				cluster.ControlPlaneEndpoint.Host = ""
 			})
 
 			It("does not create an A record", func() {
 				Expect(registErr).NotTo(HaveOccurred())
 
 				_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
-				Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the cluster declares a weighted routing policy", func() {
+			BeforeEach(func() {
+				cluster.Annotations = map[string]string{
+					annotation.APIRoutingPolicy: `{"type":"weighted","endpoints":[{"address":"10.0.0.3","weight":3},{"address":"10.0.0.4","weight":1}]}`,
+				}
+			})
+
+			It("creates the A record with the routing policy", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(BeEmpty())
+				Expect(record.RoutingPolicy.Wrr.Items).To(HaveLen(2))
+				Expect(record.RoutingPolicy.Wrr.Items[0].Rrdatas).To(ConsistOf("10.0.0.3"))
+				Expect(record.RoutingPolicy.Wrr.Items[0].Weight).To(Equal(3.0))
+				Expect(record.RoutingPolicy.Wrr.Items[1].Rrdatas).To(ConsistOf("10.0.0.4"))
+				Expect(record.RoutingPolicy.Wrr.Items[1].Weight).To(Equal(1.0))
+			})
+
+			When("the routing policy is removed", func() {
+				It("points the record to the control plane endpoint again", func() {
+					cluster.Annotations = nil
+					err := apiRegistrar.Register(ctx, cluster)
+					Expect(err).NotTo(HaveOccurred())
+
+					record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(record.RoutingPolicy).To(BeNil())
+					Expect(record.Rrdatas).To(ConsistOf(controlPlaneEndpoint))
+				})
+			})
+		})
+
+		When("the cluster declares a geo routing policy", func() {
+			BeforeEach(func() {
+				cluster.Annotations = map[string]string{
+					annotation.APIRoutingPolicy: `{"type":"geo","endpoints":[{"address":"10.0.0.3","location":"europe-west1"},{"address":"10.0.0.4","location":"us-east1"}]}`,
+				}
+			})
+
+			It("creates the A record with the routing policy", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(BeEmpty())
+				Expect(record.RoutingPolicy.Geo.Items).To(HaveLen(2))
+				Expect(record.RoutingPolicy.Geo.Items[0].Location).To(Equal("europe-west1"))
+				Expect(record.RoutingPolicy.Geo.Items[0].Rrdatas).To(ConsistOf("10.0.0.3"))
+				Expect(record.RoutingPolicy.Geo.Items[1].Location).To(Equal("us-east1"))
+				Expect(record.RoutingPolicy.Geo.Items[1].Rrdatas).To(ConsistOf("10.0.0.4"))
+			})
+		})
+
+		When("the record exists without the routing policy", func() {
+			It("updates the record", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				cluster.Annotations = map[string]string{
+					annotation.APIRoutingPolicy: `{"type":"weighted","endpoints":[{"address":"10.0.0.3","weight":1}]}`,
+				}
+				err := apiRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(BeEmpty())
+				Expect(record.RoutingPolicy.Wrr.Items).To(HaveLen(1))
+				Expect(record.RoutingPolicy.Wrr.Items[0].Rrdatas).To(ConsistOf("10.0.0.3"))
+			})
+		})
+
+		When("the routing policy is invalid", func() {
+			BeforeEach(func() {
+				cluster.Annotations = map[string]string{
+					annotation.APIRoutingPolicy: `{"type":"geo","endpoints":[{"address":"10.0.0.3"}]}`,
+				}
+			})
+
+			It("returns an invalid config error", func() {
+				var invalidErr *registrar.InvalidConfigError
+				Expect(errors.As(registErr, &invalidErr)).To(BeTrue())
+				Expect(invalidErr.ConditionReason()).To(Equal(registrar.ReasonInvalidRoutingPolicy))
+			})
+		})
+
+		It("does not health check the endpoint", func() {
+			Expect(healthChecker.WatchCallCount()).To(Equal(0))
+			Expect(healthChecker.ForgetCallCount()).To(Equal(1))
+		})
+
+		When("the cluster has a backup endpoint", func() {
+			BeforeEach(func() {
+				cluster.ControlPlaneEndpoint.Port = 6443
+				cluster.Annotations = map[string]string{
+					annotation.APIBackupEndpoint: "10.0.0.5",
+				}
+			})
+
+			It("health checks the endpoints", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				Expect(healthChecker.WatchCallCount()).To(Equal(1))
+				_, primary, backup := healthChecker.WatchArgsForCall(0)
+				Expect(primary).To(Equal("10.0.0.1:6443"))
+				Expect(backup).To(Equal("10.0.0.5:6443"))
+			})
+
+			It("points the record to the control plane endpoint", func() {
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf(controlPlaneEndpoint))
+			})
+
+			When("the health checker failed over", func() {
+				BeforeEach(func() {
+					healthChecker.UseBackupReturns(true)
+				})
+
+				It("points the record to the backup endpoint", func() {
+					Expect(registErr).NotTo(HaveOccurred())
+
+					record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(record.Rrdatas).To(ConsistOf("10.0.0.5"))
+				})
+			})
+
+			When("the backup endpoint has a port", func() {
+				BeforeEach(func() {
+					cluster.Annotations[annotation.APIBackupEndpoint] = "10.0.0.5:8443"
+				})
+
+				It("probes the backup endpoint on that port", func() {
+					Expect(registErr).NotTo(HaveOccurred())
+
+					_, _, backup := healthChecker.WatchArgsForCall(0)
+					Expect(backup).To(Equal("10.0.0.5:8443"))
+				})
+			})
+
+			When("the backup endpoint is not an IPv4 address", func() {
+				BeforeEach(func() {
+					cluster.Annotations[annotation.APIBackupEndpoint] = "backup.example.com"
+				})
+
+				It("returns an invalid config error", func() {
+					var invalidErr *registrar.InvalidConfigError
+					Expect(errors.As(registErr, &invalidErr)).To(BeTrue())
+					Expect(invalidErr.ConditionReason()).To(Equal(registrar.ReasonInvalidBackupEndpoint))
+				})
+			})
+
+			When("the cluster also declares a routing policy", func() {
+				BeforeEach(func() {
+					cluster.Annotations[annotation.APIRoutingPolicy] = `{"type":"weighted","endpoints":[{"address":"10.0.0.3","weight":1}]}`
+				})
+
+				It("returns an invalid config error", func() {
+					var invalidErr *registrar.InvalidConfigError
+					Expect(errors.As(registErr, &invalidErr)).To(BeTrue())
+					Expect(invalidErr.ConditionReason()).To(Equal(registrar.ReasonInvalidBackupEndpoint))
+				})
 			})
 		})
 
@@ -125,6 +292,18 @@ var _ = Describe("API Registrar", func() {
 				err := apiRegistrar.Register(ctx, cluster)
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			When("the control plane endpoint changed", func() {
+				It("updates the record", func() {
+					cluster.ControlPlaneEndpoint.Host = "10.0.0.2"
+					err := apiRegistrar.Register(ctx, cluster)
+					Expect(err).NotTo(HaveOccurred())
+
+					record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(record.Rrdatas).To(ConsistOf("10.0.0.2"))
+				})
+			})
 		})
 	})
 
@@ -144,7 +323,12 @@ var _ = Describe("API Registrar", func() {
 			Expect(unregistErr).NotTo(HaveOccurred())
 
 			_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiDomain, registrar.RecordA).Do()
-			Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+		})
+
+		It("stops health checking the endpoints", func() {
+			Expect(healthChecker.ForgetCallCount()).To(Equal(1))
+			Expect(healthChecker.ForgetArgsForCall(0)).To(Equal(cluster))
 		})
 
 		When("the context has been cancelled", func() {
@@ -0,0 +1,314 @@
+package registrar_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar/registrarfakes"
+	"github.com/giantswarm/dns-operator-gcp/tests"
+)
+
+var _ = Describe("API Internal Registrar", func() {
+	var (
+		ctx context.Context
+
+		service         *clouddns.Service
+		addressResolver *registrarfakes.FakeAddressResolver
+		privateZone     bool
+		apiRegistrar    *registrar.APIInternal
+
+		cluster           *clusterview.Cluster
+		clusterName       string
+		privateZoneName   string
+		domain            string
+		apiInternalDomain string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		service, err = clouddns.NewService(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		clusterName = tests.GenerateGUID("test")
+		privateZoneName = registrar.PrivateZoneName(clusterName)
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
+			Network: "default",
+			// Any private range IP address will do for the test.
+			InternalAPIAddress: "10.0.0.1",
+		}
+		domain = fmt.Sprintf("%s.%s.", cluster.Name, baseDomain)
+		apiInternalDomain = fmt.Sprintf("api-internal.%s", domain)
+
+		zone := &clouddns.ManagedZone{
+			Name:        cluster.Name,
+			DnsName:     domain,
+			Description: "zone created for integration test",
+			Visibility:  "public",
+		}
+		_, err = service.ManagedZones.Create(gcpProject, zone).
+			Context(context.Background()).
+			Do()
+		Expect(err).NotTo(HaveOccurred())
+
+		addressResolver = new(registrarfakes.FakeAddressResolver)
+		privateZone = false
+	})
+
+	JustBeforeEach(func() {
+		apiRegistrar = registrar.NewAPIInternal(baseDomain, "", privateZone, addressResolver, service)
+	})
+
+	AfterEach(func() {
+		Expect(apiRegistrar.Unregister(ctx, cluster)).To(Succeed())
+
+		err := service.ManagedZones.Delete(gcpProject, cluster.Name).
+			Context(context.Background()).
+			Do()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Register", func() {
+		var registErr error
+
+		JustBeforeEach(func() {
+			registErr = apiRegistrar.Register(ctx, cluster)
+		})
+
+		It("creates the A record in the cluster zone", func() {
+			Expect(registErr).NotTo(HaveOccurred())
+
+			record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Rrdatas).To(ConsistOf("10.0.0.1"))
+		})
+
+		When("the cluster does not have an internal api endpoint", func() {
+			BeforeEach(func() {
+				cluster.InternalAPIAddress = ""
+			})
+
+			It("does not create an A record", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the internal api endpoint is a compute address", func() {
+			BeforeEach(func() {
+				cluster.InternalAPIAddress = "https://www.googleapis.com/compute/v1/projects/test/regions/europe-west1/addresses/test"
+				addressResolver.ResolveAddressReturns("10.0.0.2", nil)
+			})
+
+			It("creates the A record with the address", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				Expect(addressResolver.ResolveAddressCallCount()).To(Equal(1))
+				_, selfLink := addressResolver.ResolveAddressArgsForCall(0)
+				Expect(selfLink).To(Equal(cluster.InternalAPIAddress))
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("10.0.0.2"))
+			})
+
+			When("resolving the address fails", func() {
+				BeforeEach(func() {
+					addressResolver.ResolveAddressReturns("", errors.New("boom"))
+				})
+
+				It("returns an error", func() {
+					Expect(registErr).To(MatchError(ContainSubstring("boom")))
+				})
+			})
+		})
+
+		When("the internal api endpoint changed", func() {
+			BeforeEach(func() {
+				Expect(registrar.NewAPIInternal(baseDomain, "", false, addressResolver, service).Register(ctx, cluster)).To(Succeed())
+				cluster.InternalAPIAddress = "10.0.0.3"
+			})
+
+			It("updates the A record", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("10.0.0.3"))
+			})
+		})
+
+		When("the record is published in a private zone", func() {
+			BeforeEach(func() {
+				privateZone = true
+			})
+
+			It("creates the private zone in the network of the cluster", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				zone, err := service.ManagedZones.Get(gcpProject, privateZoneName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(zone.DnsName).To(Equal(domain))
+				Expect(zone.Visibility).To(Equal("private"))
+				Expect(zone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelManagedBy, registrar.ZoneManagedBy))
+				Expect(zone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelPrivate, "true"))
+				Expect(zone.PrivateVisibilityConfig.Networks).To(HaveLen(1))
+				Expect(zone.PrivateVisibilityConfig.Networks[0].NetworkUrl).To(HaveSuffix("/networks/default"))
+			})
+
+			It("creates the A record in the private zone only", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, privateZoneName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("10.0.0.1"))
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+
+			When("the record was published in the cluster zone before", func() {
+				BeforeEach(func() {
+					Expect(registrar.NewAPIInternal(baseDomain, "", false, addressResolver, service).Register(ctx, cluster)).To(Succeed())
+				})
+
+				It("moves the A record to the private zone", func() {
+					Expect(registErr).NotTo(HaveOccurred())
+
+					_, err := service.ResourceRecordSets.Get(gcpProject, privateZoneName, apiInternalDomain, registrar.RecordA).Do()
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+					Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+				})
+			})
+
+			When("the network of the cluster is not known yet", func() {
+				BeforeEach(func() {
+					cluster.Network = ""
+				})
+
+				It("returns a pending error", func() {
+					var pendingErr *registrar.PendingError
+					Expect(errors.As(registErr, &pendingErr)).To(BeTrue())
+					Expect(pendingErr.ConditionReason()).To(Equal(registrar.ReasonNetworkPending))
+				})
+			})
+		})
+	})
+
+	Describe("Unregister", func() {
+		BeforeEach(func() {
+			privateZone = true
+		})
+
+		JustBeforeEach(func() {
+			Expect(apiRegistrar.Register(ctx, cluster)).To(Succeed())
+			Expect(registrar.NewAPIInternal(baseDomain, "", false, addressResolver, service).Register(ctx, cluster)).To(Succeed())
+		})
+
+		It("deletes the records and the private zone", func() {
+			Expect(apiRegistrar.Unregister(ctx, cluster)).To(Succeed())
+
+			_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, apiInternalDomain, registrar.RecordA).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+			_, err = service.ManagedZones.Get(gcpProject, privateZoneName).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+		})
+
+		It("is idempotent", func() {
+			Expect(apiRegistrar.Unregister(ctx, cluster)).To(Succeed())
+			Expect(apiRegistrar.Unregister(ctx, cluster)).To(Succeed())
+		})
+
+		When("the private zone belongs to another cluster", func() {
+			JustBeforeEach(func() {
+				_, err := service.ManagedZones.Patch(gcpProject, privateZoneName, &clouddns.ManagedZone{
+					Labels: map[string]string{
+						registrar.ZoneLabelManagedBy:        registrar.ZoneManagedBy,
+						registrar.ZoneLabelPrivate:          "true",
+						registrar.ZoneLabelClusterName:      "other-cluster",
+						registrar.ZoneLabelClusterNamespace: "other",
+					},
+				}).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := service.ResourceRecordSets.Delete(gcpProject, privateZoneName, apiInternalDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(service.ManagedZones.Delete(gcpProject, privateZoneName).Do()).To(Succeed())
+			})
+
+			It("keeps the zone", func() {
+				Expect(apiRegistrar.Unregister(ctx, cluster)).To(Succeed())
+
+				_, err := service.ManagedZones.Get(gcpProject, privateZoneName).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Retain", func() {
+		BeforeEach(func() {
+			privateZone = true
+		})
+
+		JustBeforeEach(func() {
+			Expect(apiRegistrar.Register(ctx, cluster)).To(Succeed())
+		})
+
+		It("labels the private zone as retained", func() {
+			Expect(apiRegistrar.Retain(ctx, cluster)).To(Succeed())
+
+			zone, err := service.ManagedZones.Get(gcpProject, privateZoneName).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelRetained, "true"))
+		})
+
+		It("removes the label when the cluster registers again", func() {
+			Expect(apiRegistrar.Retain(ctx, cluster)).To(Succeed())
+			Expect(apiRegistrar.Register(ctx, cluster)).To(Succeed())
+
+			zone, err := service.ManagedZones.Get(gcpProject, privateZoneName).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zone.Labels).NotTo(HaveKey(registrar.ZoneLabelRetained))
+		})
+	})
+})
+
+var _ = Describe("PrivateZoneName", func() {
+	It("appends the suffix to the cluster name", func() {
+		Expect(registrar.PrivateZoneName("test-cluster")).To(Equal("test-cluster-internal"))
+	})
+
+	When("the cluster name is too long for the suffix", func() {
+		It("shortens the name to the maximum length of zone names", func() {
+			clusterName := strings.Repeat("a", 60)
+			name := registrar.PrivateZoneName(clusterName)
+			Expect(name).To(HaveLen(63))
+			Expect(name).To(HavePrefix(strings.Repeat("a", 45) + "-"))
+			Expect(name).To(HaveSuffix("-internal"))
+		})
+
+		It("returns different names for clusters sharing a prefix", func() {
+			Expect(registrar.PrivateZoneName(strings.Repeat("a", 59) + "b")).
+				NotTo(Equal(registrar.PrivateZoneName(strings.Repeat("a", 59) + "c")))
+		})
+	})
+})
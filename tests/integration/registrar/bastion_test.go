@@ -2,15 +2,16 @@ package registrar_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	clouddns "google.golang.org/api/dns/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/k8sclient"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar/registrarfakes"
 	"github.com/giantswarm/dns-operator-gcp/tests"
@@ -25,10 +26,11 @@ var _ = Describe("Bastion Registrar", func() {
 
 		bastionsClient *registrarfakes.FakeBastionsClient
 
-		cluster       *capg.GCPCluster
-		clusterName   string
-		domain        string
-		bastionDomain string
+		cluster        *clusterview.Cluster
+		clusterName    string
+		domain         string
+		bastionDomain  string
+		bastionsDomain string
 	)
 
 	BeforeEach(func() {
@@ -41,16 +43,13 @@ var _ = Describe("Bastion Registrar", func() {
 		bastionsClient = new(registrarfakes.FakeBastionsClient)
 
 		clusterName = tests.GenerateGUID("test")
-		cluster = &capg.GCPCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: clusterName,
-			},
-			Spec: capg.GCPClusterSpec{
-				Project: gcpProject,
-			},
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
 		}
 		domain = fmt.Sprintf("%s.%s.", cluster.Name, baseDomain)
 		bastionDomain = fmt.Sprintf("bastion1.%s", domain)
+		bastionsDomain = fmt.Sprintf("bastion.%s", domain)
 
 		zone := &clouddns.ManagedZone{
 			Name:        cluster.Name,
@@ -63,7 +62,9 @@ var _ = Describe("Bastion Registrar", func() {
 			Do()
 		Expect(err).NotTo(HaveOccurred())
 
-		bastionsClient.GetBastionIPListReturns([]string{"1.2.3.4"}, nil)
+		bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+			{Name: "bastion-1", Index: 1, IP: "1.2.3.4"},
+		}, nil)
 
 		bastionRegistrar = registrar.NewBastion(baseDomain, bastionsClient, service)
 	})
@@ -84,7 +85,10 @@ var _ = Describe("Bastion Registrar", func() {
 
 		AfterEach(func() {
 			_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
-			Expect(err).To(Or(Not(HaveOccurred()), BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+
+			_, err = service.ResourceRecordSets.Delete(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+			Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
 		})
 
 		It("creates the bastion A record", func() {
@@ -95,6 +99,14 @@ var _ = Describe("Bastion Registrar", func() {
 			Expect(record.Rrdatas).To(ConsistOf("1.2.3.4"))
 		})
 
+		It("creates the round robin bastion A record", func() {
+			Expect(registErr).NotTo(HaveOccurred())
+
+			record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record.Rrdatas).To(ConsistOf("1.2.3.4"))
+		})
+
 		When("the context has been cancelled", func() {
 			It("returns an error", func() {
 				var cancel context.CancelFunc
@@ -112,6 +124,186 @@ var _ = Describe("Bastion Registrar", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		When("the bastion IP changes", func() {
+			It("updates the bastion A record", func() {
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-1", Index: 1, IP: "1.2.3.5"},
+				}, nil)
+
+				err := bastionRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.5"))
+			})
+		})
+
+		When("the number of bastions shrinks", func() {
+			var secondBastionDomain string
+
+			BeforeEach(func() {
+				secondBastionDomain = fmt.Sprintf("bastion2.%s", domain)
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-1", Index: 1, IP: "1.2.3.4"},
+					{Name: "bastion-2", Index: 2, IP: "1.2.3.5"},
+				}, nil)
+			})
+
+			AfterEach(func() {
+				_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			})
+
+			It("deletes the stale bastion A records", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.5"))
+
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-1", Index: 1, IP: "1.2.3.4"},
+				}, nil)
+				err = bastionRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+				record, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.4"))
+			})
+		})
+
+		When("a bastion with a lower index is removed", func() {
+			var secondBastionDomain string
+
+			BeforeEach(func() {
+				secondBastionDomain = fmt.Sprintf("bastion2.%s", domain)
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-1", Index: 1, IP: "1.2.3.4"},
+					{Name: "bastion-2", Index: 2, IP: "1.2.3.5"},
+				}, nil)
+			})
+
+			AfterEach(func() {
+				_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			})
+
+			It("keeps the records of the remaining bastions stable", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.4", "1.2.3.5"))
+
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-2", Index: 2, IP: "1.2.3.5"},
+				}, nil)
+				err = bastionRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+				record, err = service.ResourceRecordSets.Get(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.5"))
+
+				record, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.5"))
+			})
+		})
+
+		When("a bastion does not have an IP yet", func() {
+			var secondBastionDomain string
+
+			BeforeEach(func() {
+				secondBastionDomain = fmt.Sprintf("bastion2.%s", domain)
+				bastionsClient.GetBastionsReturns([]k8sclient.Bastion{
+					{Name: "bastion-1", Index: 1, IP: "1.2.3.4"},
+					{Name: "bastion-2", Index: 2},
+				}, nil)
+			})
+
+			It("registers the ready bastions and returns a pending error", func() {
+				var pendingErr *registrar.PendingError
+				Expect(errors.As(registErr, &pendingErr)).To(BeTrue())
+				Expect(pendingErr.ConditionReason()).To(Equal(registrar.ReasonBastionsPending))
+				Expect(pendingErr.Error()).To(ContainSubstring("bastion-2"))
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf("1.2.3.4"))
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, secondBastionDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the zone contains records below the bastion names", func() {
+			var foreignRecords []*clouddns.ResourceRecordSet
+
+			BeforeEach(func() {
+				foreignRecords = []*clouddns.ResourceRecordSet{
+					{Name: fmt.Sprintf("bastion.foo.%s", domain), Type: registrar.RecordA, Ttl: 300, Rrdatas: []string{"10.0.0.1"}},
+					{Name: fmt.Sprintf("bastion1.foo.%s", domain), Type: registrar.RecordA, Ttl: 300, Rrdatas: []string{"10.0.0.2"}},
+				}
+				for _, record := range foreignRecords {
+					_, err := service.ResourceRecordSets.Create(gcpProject, clusterName, record).Do()
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			AfterEach(func() {
+				for _, record := range foreignRecords {
+					_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, record.Name, record.Type).Do()
+					Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+				}
+			})
+
+			It("keeps them", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				bastionsClient.GetBastionsReturns(nil, nil)
+				err := bastionRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, record := range foreignRecords {
+					_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, record.Name, record.Type).Do()
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				err = bastionRegistrar.Unregister(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, record := range foreignRecords {
+					_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, record.Name, record.Type).Do()
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+		})
+
+		When("there are no bastions left", func() {
+			It("deletes all bastion A records", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				bastionsClient.GetBastionsReturns(nil, nil)
+				err := bastionRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
 	})
 
 	Describe("Unregister", func() {
@@ -138,11 +330,14 @@ var _ = Describe("Bastion Registrar", func() {
 				unregistErr = bastionRegistrar.Unregister(ctx, cluster)
 			})
 
-			It("deletes the bastion A record", func() {
+			It("deletes the bastion A records", func() {
 				Expect(unregistErr).NotTo(HaveOccurred())
 
 				_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, bastionDomain, registrar.RecordA).Do()
-				Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, clusterName, bastionsDomain, registrar.RecordA).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
 			})
 
 			When("the context has been cancelled", func() {
@@ -1,16 +1,19 @@
 package registrar_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	clouddns "google.golang.org/api/dns/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 
+	"github.com/giantswarm/dns-operator-gcp/pkg/audit"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
 	"github.com/giantswarm/dns-operator-gcp/tests"
 )
@@ -22,7 +25,7 @@ var _ = Describe("Wildcard Registrar", func() {
 		service           *clouddns.Service
 		wildcardRegistrar *registrar.Wildcard
 
-		cluster        *capg.GCPCluster
+		cluster        *clusterview.Cluster
 		clusterName    string
 		domain         string
 		wildcardDomain string
@@ -37,13 +40,9 @@ var _ = Describe("Wildcard Registrar", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		clusterName = tests.GenerateGUID("test")
-		cluster = &capg.GCPCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: clusterName,
-			},
-			Spec: capg.GCPClusterSpec{
-				Project: gcpProject,
-			},
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
 		}
 		domain = fmt.Sprintf("%s.%s.", cluster.Name, baseDomain)
 		wildcardDomain = fmt.Sprintf("*.%s", domain)
@@ -79,7 +78,7 @@ var _ = Describe("Wildcard Registrar", func() {
 
 		AfterEach(func() {
 			_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, wildcardDomain, registrar.RecordCNAME).Do()
-			Expect(err).To(Or(Not(HaveOccurred()), BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
 		})
 
 		It("creates the CNAME record", func() {
@@ -107,6 +106,67 @@ var _ = Describe("Wildcard Registrar", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		When("the record exists with another target", func() {
+			BeforeEach(func() {
+				record := &clouddns.ResourceRecordSet{
+					Name:    wildcardDomain,
+					Rrdatas: []string{"other.example.com."},
+					Type:    registrar.RecordCNAME,
+				}
+				_, err := service.ResourceRecordSets.Create(gcpProject, clusterName, record).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("updates the record", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, wildcardDomain, registrar.RecordCNAME).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(record.Rrdatas).To(ConsistOf(ingressDomain))
+			})
+
+			When("the mutations are audited", func() {
+				var buffer *bytes.Buffer
+
+				BeforeEach(func() {
+					buffer = &bytes.Buffer{}
+					ctx = audit.IntoContext(ctx, audit.NewRecorder(audit.NewJSONLines(buffer, ""), "org-test/"+clusterName, "test"))
+				})
+
+				It("records the update with the previous target", func() {
+					Expect(registErr).NotTo(HaveOccurred())
+
+					var entry audit.Entry
+					Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+					Expect(entry.Action).To(Equal(audit.ActionUpdate))
+					Expect(entry.Name).To(Equal(wildcardDomain))
+					Expect(entry.Before).To(ConsistOf("other.example.com."))
+					Expect(entry.After).To(ConsistOf(ingressDomain))
+					Expect(entry.Cluster).To(Equal("org-test/" + clusterName))
+				})
+			})
+
+			When("the zone was adopted", func() {
+				BeforeEach(func() {
+					patch := &clouddns.ManagedZone{
+						Labels: map[string]string{registrar.ZoneLabelAdopted: "true"},
+					}
+					_, err := service.ManagedZones.Patch(gcpProject, clusterName, patch).Do()
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("refuses to overwrite the record", func() {
+					var conflictErr *registrar.ConflictError
+					Expect(errors.As(registErr, &conflictErr)).To(BeTrue())
+					Expect(conflictErr.ConditionReason()).To(Equal(registrar.ReasonRecordConflict))
+
+					record, err := service.ResourceRecordSets.Get(gcpProject, clusterName, wildcardDomain, registrar.RecordCNAME).Do()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(record.Rrdatas).To(ConsistOf("other.example.com."))
+				})
+			})
+		})
 	})
 
 	Describe("Unregister", func() {
@@ -125,7 +185,7 @@ var _ = Describe("Wildcard Registrar", func() {
 			Expect(unregistErr).NotTo(HaveOccurred())
 
 			_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, wildcardDomain, registrar.RecordCNAME).Do()
-			Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
 		})
 
 		When("the context has been cancelled", func() {
@@ -145,5 +205,30 @@ var _ = Describe("Wildcard Registrar", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		When("the mutations are audited", func() {
+			var buffer *bytes.Buffer
+
+			BeforeEach(func() {
+				buffer = &bytes.Buffer{}
+				ctx = audit.IntoContext(ctx, audit.NewRecorder(audit.NewJSONLines(buffer, ""), "org-test/"+clusterName, "test"))
+			})
+
+			It("records the deletion with the deleted target", func() {
+				Expect(unregistErr).NotTo(HaveOccurred())
+
+				var entry audit.Entry
+				Expect(json.Unmarshal(buffer.Bytes(), &entry)).To(Succeed())
+				Expect(entry.Action).To(Equal(audit.ActionDelete))
+				Expect(entry.Name).To(Equal(wildcardDomain))
+				Expect(entry.Before).To(ConsistOf(ingressDomain))
+			})
+
+			It("does not record records which no longer exist", func() {
+				buffer.Reset()
+				Expect(wildcardRegistrar.Unregister(ctx, cluster)).To(Succeed())
+				Expect(buffer.Len()).To(BeZero())
+			})
+		})
 	})
 })
@@ -2,15 +2,16 @@ package registrar_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	clouddns "google.golang.org/api/dns/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	capg "sigs.k8s.io/cluster-api-provider-gcp/api/v1beta1"
 
+	"github.com/giantswarm/dns-operator-gcp/pkg/annotation"
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
 	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
 	"github.com/giantswarm/dns-operator-gcp/tests"
 )
@@ -22,7 +23,7 @@ var _ = Describe("Zone Registrar", func() {
 		service       *clouddns.Service
 		zoneRegistrar *registrar.Zone
 
-		cluster     *capg.GCPCluster
+		cluster     *clusterview.Cluster
 		clusterName string
 		domain      string
 	)
@@ -35,17 +36,13 @@ var _ = Describe("Zone Registrar", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		clusterName = tests.GenerateGUID("test")
-		cluster = &capg.GCPCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: clusterName,
-			},
-			Spec: capg.GCPClusterSpec{
-				Project: gcpProject,
-			},
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
 		}
 		domain = fmt.Sprintf("%s.%s.", cluster.Name, baseDomain)
 
-		zoneRegistrar = registrar.NewZone(baseDomain, parentDNSZone, gcpProject, service)
+		zoneRegistrar = registrar.NewZone(baseDomain, parentDNSZone, gcpProject, "", false, false, service)
 	})
 
 	Describe("Register", func() {
@@ -72,12 +69,47 @@ var _ = Describe("Zone Registrar", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(actualZone.Name).To(Equal(cluster.Name))
 			Expect(actualZone.DnsName).To(Equal(domain))
+			Expect(actualZone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelManagedBy, registrar.ZoneManagedBy))
+			Expect(actualZone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelClusterName, clusterName))
+			Expect(actualZone.Labels).To(HaveKey(registrar.ZoneLabelOperatorVersion))
 
 			record, err := service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
 			Expect(err).NotTo(HaveOccurred())
 			Expect(record.Rrdatas).To(ConsistOf(actualZone.NameServers))
 		})
 
+		It("does not log queries by default", func() {
+			actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualZone.CloudLoggingConfig == nil || !actualZone.CloudLoggingConfig.EnableLogging).To(BeTrue())
+		})
+
+		When("query logging is enabled on the cluster", func() {
+			BeforeEach(func() {
+				cluster.Annotations = map[string]string{
+					annotation.CloudLogging: "true",
+				}
+			})
+
+			It("logs the queries of the zone", func() {
+				actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualZone.CloudLoggingConfig.EnableLogging).To(BeTrue())
+			})
+
+			When("query logging is disabled again", func() {
+				It("updates the existing zone", func() {
+					cluster.Annotations[annotation.CloudLogging] = "false"
+					err := zoneRegistrar.Register(ctx, cluster)
+					Expect(err).NotTo(HaveOccurred())
+
+					actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(actualZone.CloudLoggingConfig.EnableLogging).To(BeFalse())
+				})
+			})
+		})
+
 		When("the context has been cancelled", func() {
 			It("returns an error", func() {
 				var cancel context.CancelFunc
@@ -94,6 +126,170 @@ var _ = Describe("Zone Registrar", func() {
 				err := zoneRegistrar.Register(ctx, cluster)
 				Expect(err).NotTo(HaveOccurred())
 			})
+
+			It("labels the zone as managed by the operator", func() {
+				actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualZone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelManagedBy, registrar.ZoneManagedBy))
+				Expect(actualZone.Labels).NotTo(HaveKey(registrar.ZoneLabelAdopted))
+			})
+		})
+	})
+
+	Describe("adopting existing zones", func() {
+		var (
+			zone      *clouddns.ManagedZone
+			registErr error
+		)
+
+		BeforeEach(func() {
+			zone = &clouddns.ManagedZone{
+				Name:        clusterName,
+				DnsName:     domain,
+				Description: "zone created for integration test",
+				Visibility:  "public",
+			}
+		})
+
+		JustBeforeEach(func() {
+			_, err := service.ManagedZones.Create(gcpProject, zone).Do()
+			Expect(err).NotTo(HaveOccurred())
+
+			registErr = zoneRegistrar.Register(ctx, cluster)
+		})
+
+		AfterEach(func() {
+			_, err := service.ResourceRecordSets.Delete(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+			Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+
+			err = service.ManagedZones.Delete(gcpProject, clusterName).Do()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("labels the zone as adopted", func() {
+			Expect(registErr).NotTo(HaveOccurred())
+
+			actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualZone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelAdopted, "true"))
+		})
+
+		When("the zone is for another domain", func() {
+			BeforeEach(func() {
+				zone.DnsName = fmt.Sprintf("other.%s", domain)
+			})
+
+			It("refuses to adopt the zone", func() {
+				var conflictErr *registrar.ConflictError
+				Expect(errors.As(registErr, &conflictErr)).To(BeTrue())
+				Expect(conflictErr.ConditionReason()).To(Equal(registrar.ReasonZoneConflict))
+
+				_, err := service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the zone belongs to another management cluster", func() {
+			BeforeEach(func() {
+				zone.Labels = map[string]string{
+					registrar.ZoneLabelManagedBy:         registrar.ZoneManagedBy,
+					registrar.ZoneLabelClusterName:       registrar.SanitizeLabelValue(clusterName),
+					registrar.ZoneLabelManagementCluster: "other-management-cluster",
+				}
+			})
+
+			It("refuses to take over the zone", func() {
+				var conflictErr *registrar.ConflictError
+				Expect(errors.As(registErr, &conflictErr)).To(BeTrue())
+				Expect(conflictErr.ConditionReason()).To(Equal(registrar.ReasonZoneConflict))
+
+				actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualZone.Labels).To(HaveKeyWithValue(registrar.ZoneLabelManagementCluster, "other-management-cluster"))
+			})
+
+			It("does not delete the zone when unregistering", func() {
+				err := zoneRegistrar.Unregister(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the adopted zone is unregistered", func() {
+			It("releases the zone and keeps it and its delegation", func() {
+				Expect(registErr).NotTo(HaveOccurred())
+
+				err := zoneRegistrar.Unregister(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualZone.Labels).NotTo(HaveKey(registrar.ZoneLabelAdopted))
+				Expect(actualZone.Labels).NotTo(HaveKey(registrar.ZoneLabelClusterName))
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Retain", func() {
+		BeforeEach(func() {
+			err := zoneRegistrar.Register(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := zoneRegistrar.Unregister(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("labels the zone as retained", func() {
+			err := zoneRegistrar.Retain(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualZone.Labels).To(HaveKey(registrar.ZoneLabelRetained))
+		})
+
+		When("a cluster of the same name adopts the zone", func() {
+			It("removes the retained label", func() {
+				err := zoneRegistrar.Retain(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = zoneRegistrar.Register(ctx, cluster)
+				Expect(err).NotTo(HaveOccurred())
+
+				actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualZone.Labels).NotTo(HaveKey(registrar.ZoneLabelRetained))
+			})
+		})
+	})
+
+	Describe("UnregisterDelegation", func() {
+		BeforeEach(func() {
+			err := zoneRegistrar.Register(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := zoneRegistrar.Unregister(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("only deletes the NS record", func() {
+			err := zoneRegistrar.UnregisterDelegation(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = service.ManagedZones.Get(gcpProject, clusterName).Do()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
 		})
 	})
 
@@ -115,11 +311,11 @@ var _ = Describe("Zone Registrar", func() {
 
 		It("deletes the dns zone and NS record", func() {
 			actualZone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
-			Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
 			Expect(actualZone).To(BeNil())
 
 			record, err := service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
-			Expect(err).To(BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
 			Expect(record).To(BeNil())
 		})
 
@@ -134,6 +330,95 @@ var _ = Describe("Zone Registrar", func() {
 			})
 		})
 
+		When("the zone contains records of the operator", func() {
+			BeforeEach(func() {
+				record := &clouddns.ResourceRecordSet{
+					Name:    fmt.Sprintf("%s.%s", registrar.EndpointAPI, domain),
+					Rrdatas: []string{"1.2.3.4"},
+					Type:    registrar.RecordA,
+				}
+				_, err := service.ResourceRecordSets.Create(gcpProject, clusterName, record).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("deletes the records and the zone", func() {
+				Expect(deleteErr).NotTo(HaveOccurred())
+
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the zone contains records imported from a zone file", func() {
+			BeforeEach(func() {
+				record := &clouddns.ResourceRecordSet{
+					Name:    fmt.Sprintf("legacy.%s", domain),
+					Rrdatas: []string{"1.2.3.4"},
+					Type:    registrar.RecordA,
+				}
+				imported := registrar.ImportedRecords{}
+				imported.Add(record)
+
+				_, err := service.Changes.Create(gcpProject, clusterName, &clouddns.Change{
+					Additions: []*clouddns.ResourceRecordSet{record, imported.Record(domain)},
+				}).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("deletes the records and the zone", func() {
+				Expect(deleteErr).NotTo(HaveOccurred())
+
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+			})
+		})
+
+		When("the zone contains records not managed by the operator", func() {
+			var foreignDomain string
+
+			BeforeEach(func() {
+				foreignDomain = fmt.Sprintf("foreign.%s", domain)
+				record := &clouddns.ResourceRecordSet{
+					Name:    foreignDomain,
+					Rrdatas: []string{`"created by hand"`},
+					Type:    "TXT",
+				}
+				_, err := service.ResourceRecordSets.Create(gcpProject, clusterName, record).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_ = zoneRegistrar.Unregister(ctx, forcedCluster(cluster))
+			})
+
+			It("returns an error listing the foreign records", func() {
+				var foreignRecordsErr *registrar.ForeignRecordsError
+				Expect(errors.As(deleteErr, &foreignRecordsErr)).To(BeTrue())
+				Expect(foreignRecordsErr.Records()).To(ConsistOf(foreignDomain + " TXT"))
+			})
+
+			It("keeps the zone and the delegation", func() {
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			When("the deletion is forced", func() {
+				BeforeEach(func() {
+					cluster = forcedCluster(cluster)
+				})
+
+				It("deletes the records and the zone", func() {
+					Expect(deleteErr).NotTo(HaveOccurred())
+
+					_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+					Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+				})
+			})
+		})
+
 		When("the zone does not exists", func() {
 			It("return an error", func() {
 				err := zoneRegistrar.Unregister(ctx, cluster)
@@ -142,3 +427,11 @@ var _ = Describe("Zone Registrar", func() {
 		})
 	})
 })
+
+func forcedCluster(cluster *clusterview.Cluster) *clusterview.Cluster {
+	forced := *cluster
+	forced.Annotations = map[string]string{
+		annotation.ForceZoneDeletion: "true",
+	}
+	return &forced
+}
@@ -0,0 +1,30 @@
+package sweeper_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/giantswarm/dns-operator-gcp/tests"
+)
+
+var (
+	baseDomain    string
+	parentDNSZone string
+	gcpProject    string
+)
+
+func TestSweeper(t *testing.T) {
+	suiteConfig, reporterConfig := GinkgoConfiguration()
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sweeper Suite", suiteConfig, reporterConfig)
+}
+
+var _ = BeforeSuite(func() {
+	tests.GetEnvOrSkip("GOOGLE_APPLICATION_CREDENTIALS")
+	baseDomain = tests.GetEnvOrSkip("CLOUD_DNS_BASE_DOMAIN")
+	parentDNSZone = tests.GetEnvOrSkip("CLOUD_DNS_PARENT_ZONE")
+	gcpProject = tests.GetEnvOrSkip("GCP_PROJECT_ID")
+})
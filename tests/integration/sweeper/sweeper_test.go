@@ -0,0 +1,218 @@
+package sweeper_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clouddns "google.golang.org/api/dns/v1"
+
+	"github.com/giantswarm/dns-operator-gcp/pkg/clusterview"
+	"github.com/giantswarm/dns-operator-gcp/pkg/registrar"
+	"github.com/giantswarm/dns-operator-gcp/pkg/sweeper"
+	"github.com/giantswarm/dns-operator-gcp/pkg/sweeper/sweeperfakes"
+	"github.com/giantswarm/dns-operator-gcp/tests"
+)
+
+var _ = Describe("Sweeper", func() {
+	var (
+		ctx context.Context
+
+		service       *clouddns.Service
+		zoneRegistrar *registrar.Zone
+		clusters      *sweeperfakes.FakeClusterLister
+		config        sweeper.Config
+
+		cluster     *clusterview.Cluster
+		clusterName string
+		sweepDomain string
+		domain      string
+
+		sweepErr error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		var err error
+		service, err = clouddns.NewService(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		clusterName = tests.GenerateGUID("test")
+		cluster = &clusterview.Cluster{
+			Name:    clusterName,
+			Project: gcpProject,
+		}
+		// Use a base domain unique to the test, so that the sweeper does not
+		// touch zones created by other tests running in parallel.
+		sweepDomain = fmt.Sprintf("%s.%s", tests.GenerateGUID("sweeper"), baseDomain)
+		domain = fmt.Sprintf("%s.%s.", cluster.Name, sweepDomain)
+
+		zoneRegistrar = registrar.NewZone(sweepDomain, parentDNSZone, gcpProject, "", false, false, service)
+		Expect(zoneRegistrar.Register(ctx, cluster)).To(Succeed())
+
+		clusters = new(sweeperfakes.FakeClusterLister)
+		clusters.ListReturns([]clusterview.Cluster{*cluster}, nil)
+
+		config = sweeper.Config{
+			BaseDomain:       sweepDomain,
+			ParentDNSZone:    parentDNSZone,
+			ParentGCPProject: gcpProject,
+		}
+	})
+
+	JustBeforeEach(func() {
+		sweepErr = sweeper.New(config, clusters, service).Sweep(ctx)
+	})
+
+	AfterEach(func() {
+		Expect(zoneRegistrar.Unregister(ctx, cluster)).To(Succeed())
+	})
+
+	It("keeps the zone and delegation of existing clusters", func() {
+		Expect(sweepErr).NotTo(HaveOccurred())
+
+		_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("the cluster no longer exists", func() {
+		BeforeEach(func() {
+			clusters.ListReturns([]clusterview.Cluster{}, nil)
+		})
+
+		It("deletes the orphaned zone and delegation", func() {
+			Expect(sweepErr).NotTo(HaveOccurred())
+
+			_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+
+			_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+			Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+		})
+
+		When("the orphan has not exceeded the grace period", func() {
+			BeforeEach(func() {
+				config.GracePeriod = time.Hour
+			})
+
+			It("keeps the zone", func() {
+				Expect(sweepErr).NotTo(HaveOccurred())
+
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the zone contains records not managed by the operator", func() {
+			var foreignRecord *clouddns.ResourceRecordSet
+
+			BeforeEach(func() {
+				foreignRecord = &clouddns.ResourceRecordSet{
+					Name:    fmt.Sprintf("foreign.%s", domain),
+					Type:    registrar.RecordA,
+					Rrdatas: []string{"10.0.0.1"},
+					Ttl:     300,
+				}
+				_, err := service.ResourceRecordSets.Create(gcpProject, clusterName, foreignRecord).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, err := service.ResourceRecordSets.Delete(gcpProject, clusterName, foreignRecord.Name, foreignRecord.Type).Do()
+				Expect(err).To(Or(Not(HaveOccurred()), tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound)))
+			})
+
+			It("keeps the zone and delegation", func() {
+				Expect(sweepErr).NotTo(HaveOccurred())
+
+				_, err := service.ResourceRecordSets.Get(gcpProject, clusterName, foreignRecord.Name, foreignRecord.Type).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			When("the deletion is forced", func() {
+				BeforeEach(func() {
+					config.ForceDeletion = true
+				})
+
+				It("deletes the orphaned zone", func() {
+					Expect(sweepErr).NotTo(HaveOccurred())
+
+					_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+					Expect(err).To(tests.BeGoogleAPIErrorWithStatus(http.StatusNotFound))
+				})
+			})
+		})
+
+		When("the zone belongs to another management cluster sharing the parent zone", func() {
+			var labels map[string]string
+
+			BeforeEach(func() {
+				zone, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				labels = zone.Labels
+				otherLabels := map[string]string{}
+				for key, value := range zone.Labels {
+					otherLabels[key] = value
+				}
+				otherLabels[registrar.ZoneLabelManagementCluster] = "other-management-cluster"
+				_, err = service.ManagedZones.Patch(gcpProject, clusterName, &clouddns.ManagedZone{Labels: otherLabels}).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				config.ManagementCluster = "test-management-cluster"
+			})
+
+			AfterEach(func() {
+				_, err := service.ManagedZones.Patch(gcpProject, clusterName, &clouddns.ManagedZone{Labels: labels}).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("keeps the zone and delegation", func() {
+				Expect(sweepErr).NotTo(HaveOccurred())
+
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("the sweeper is in report only mode", func() {
+			BeforeEach(func() {
+				config.ReportOnly = true
+			})
+
+			It("keeps the zone and delegation", func() {
+				Expect(sweepErr).NotTo(HaveOccurred())
+
+				_, err := service.ManagedZones.Get(gcpProject, clusterName).Do()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = service.ResourceRecordSets.Get(gcpProject, parentDNSZone, domain, registrar.RecordNS).Do()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	When("listing the clusters fails", func() {
+		BeforeEach(func() {
+			clusters.ListReturns(nil, errors.New("boom"))
+		})
+
+		It("returns an error", func() {
+			Expect(sweepErr).To(MatchError(ContainSubstring("boom")))
+		})
+	})
+})
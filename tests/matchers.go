@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"google.golang.org/api/googleapi"
+)
+
+type beGoogleAPIErrorWithStatusMatcher struct {
+	expected int
+}
+
+func BeGoogleAPIErrorWithStatus(expected int) types.GomegaMatcher {
+	return &beGoogleAPIErrorWithStatusMatcher{expected: expected}
+}
+
+func (m *beGoogleAPIErrorWithStatusMatcher) Match(actual interface{}) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+
+	actualError, isError := actual.(error)
+	if !isError {
+		return false, fmt.Errorf("%#v is not an error", actual)
+	}
+
+	matches, err := gomega.BeAssignableToTypeOf(actualError).Match(&googleapi.Error{})
+	if err != nil || !matches {
+		return false, err
+	}
+
+	googleAPIError, isGoogleAPIError := actual.(*googleapi.Error)
+	if !isGoogleAPIError {
+		return false, fmt.Errorf("%#v is not a google api error", actual)
+	}
+	return gomega.Equal(googleAPIError.Code).Match(m.expected)
+}
+
+func (m *beGoogleAPIErrorWithStatusMatcher) FailureMessage(actual interface{}) (message string) {
+	return format.Message(
+		actual,
+		fmt.Sprintf("to be a google api error with status code: %s", m.getExpectedStatusText()),
+	)
+}
+
+func (m *beGoogleAPIErrorWithStatusMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return format.Message(
+		actual,
+		fmt.Sprintf("to not be a google api error with status: %s", m.getExpectedStatusText()),
+	)
+}
+
+func (m *beGoogleAPIErrorWithStatusMatcher) getExpectedStatusText() string {
+	return fmt.Sprintf("%d %s", m.expected, http.StatusText(m.expected))
+}